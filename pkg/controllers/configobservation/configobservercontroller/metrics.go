@@ -0,0 +1,34 @@
+package configobservercontroller
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+var observationErrors = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Name:           "openshift_authentication_operator_config_observation_errors_total",
+		Help:           "Counts the errors returned by the observers of the oauth-server config.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"observer"},
+)
+
+func init() {
+	legacyregistry.MustRegister(observationErrors)
+}
+
+// withObservationErrorsCounted counts the errors the observer returns under
+// the given name of the observer
+func withObservationErrorsCounted(name string, observe configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observe(listers, recorder, existingConfig)
+		if len(errs) > 0 {
+			observationErrors.WithLabelValues(name).Add(float64(len(errs)))
+		}
+		return observedConfig, errs
+	}
+}
@@ -65,19 +65,19 @@ func NewConfigObserver(
 	}
 
 	oauthServerObservers := []configobserver.ObserveConfigFunc{}
-	for _, o := range []configobserver.ObserveConfigFunc{
-		apiserver.ObserveAdditionalCORSAllowedOrigins,
-		apiserver.ObserveTLSSecurityProfile,
-		infrastructure.ObserveAPIServerURL,
-		oauth.ObserveIdentityProviders,
-		oauth.ObserveTemplates,
-		oauth.ObserveTokenConfig,
-		oauth.ObserveAudit,
-		configobserveroauth.ObserveAccessTokenInactivityTimeout,
-		routersecret.ObserveRouterSecret,
+	for name, o := range map[string]configobserver.ObserveConfigFunc{
+		"additionalCORSAllowedOrigins": apiserver.ObserveAdditionalCORSAllowedOrigins,
+		"tlsSecurityProfile":           apiserver.ObserveTLSSecurityProfile,
+		"apiServerURL":                 infrastructure.ObserveAPIServerURL,
+		"identityProviders":            oauth.ObserveIdentityProviders,
+		"templates":                    oauth.ObserveTemplates,
+		"tokenConfig":                  oauth.ObserveTokenConfig,
+		"audit":                        oauth.ObserveAudit,
+		"accessTokenInactivityTimeout": configobserveroauth.ObserveAccessTokenInactivityTimeout,
+		"routerSecret":                 routersecret.ObserveRouterSecret,
 	} {
 		oauthServerObservers = append(oauthServerObservers,
-			configobserver.WithPrefix(o, configobservation.OAuthServerConfigPrefix))
+			withObservationErrorsCounted(name, configobserver.WithPrefix(o, configobservation.OAuthServerConfigPrefix)))
 	}
 
 	listers := configobservation.Listers{
@@ -98,7 +98,8 @@ func NewConfigObserver(
 		listers.PreRunCachesSynced = append(listers.PreRunCachesSynced, configInformer.Config().V1().Consoles().Informer().HasSynced)
 		informers = append(informers, configInformer.Config().V1().Consoles().Informer())
 		listers.ConsoleLister = configInformer.Config().V1().Consoles().Lister()
-		oauthServerObservers = append(oauthServerObservers, configobserver.WithPrefix(console.ObserveConsoleURL, configobservation.OAuthServerConfigPrefix))
+		oauthServerObservers = append(oauthServerObservers,
+			withObservationErrorsCounted("consoleURL", configobserver.WithPrefix(console.ObserveConsoleURL, configobservation.OAuthServerConfigPrefix)))
 	}
 
 	return configobserver.NewNestedConfigObserver(
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+// idpIndexPattern matches the index of an identity provider in the names and
+// the mount paths of the resources synced for it. The index follows the
+// position of the provider in the OAuth config, it is not part of the identity
+// of the provider.
+var (
+	idpIndexPattern     = regexp.MustCompile(`v4-0-config-user-idp-(\d+)-`)
+	idpPathIndexPattern = regexp.MustCompile(`/var/config/user/idp/\d+/`)
+)
+
+// describeIdentityProviderChanges returns a description of the identity
+// providers that were added, removed or modified between the two observations,
+// or an empty string when there are none. Only the names of the providers are
+// described so that none of their settings end up in the events.
+func describeIdentityProviderChanges(existingIDPs []interface{}, existingSyncData *datasync.ConfigSyncData, observedIDPs []interface{}, observedSyncData *datasync.ConfigSyncData) string {
+	existing := identityProviderFingerprints(existingIDPs, existingSyncData)
+	observed := identityProviderFingerprints(observedIDPs, observedSyncData)
+
+	existingNames := sets.StringKeySet(existing)
+	observedNames := sets.StringKeySet(observed)
+
+	var modified []string
+	for _, name := range existingNames.Intersection(observedNames).List() {
+		if existing[name] != observed[name] {
+			modified = append(modified, name)
+		}
+	}
+
+	var changes []string
+	for _, change := range []struct {
+		verb  string
+		names []string
+	}{
+		{verb: "added", names: observedNames.Difference(existingNames).List()},
+		{verb: "removed", names: existingNames.Difference(observedNames).List()},
+		{verb: "modified", names: modified},
+	} {
+		if len(change.names) == 0 {
+			continue
+		}
+		quoted := make([]string, 0, len(change.names))
+		for _, name := range change.names {
+			quoted = append(quoted, fmt.Sprintf("%q", name))
+		}
+		changes = append(changes, fmt.Sprintf("%s %s", change.verb, strings.Join(quoted, ", ")))
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	return "identity providers changed: " + strings.Join(changes, "; ")
+}
+
+// identityProviderFingerprints maps the names of the observed identity
+// providers to their config along with the resources synced for them, with
+// the indexes of the providers left out so that moving a provider within the
+// list does not count as modifying it
+func identityProviderFingerprints(idps []interface{}, syncData *datasync.ConfigSyncData) map[string]string {
+	fingerprints := map[string]string{}
+	for _, idp := range idps {
+		idpMap, ok := idp.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := idpMap["name"].(string)
+
+		config, err := json.Marshal(idpMap)
+		if err != nil {
+			// the observed config was decoded from JSON in the first place
+			continue
+		}
+
+		var sources []string
+		if match := idpIndexPattern.FindSubmatch(config); match != nil {
+			destPrefix := fmt.Sprintf("v4-0-config-user-idp-%s-", match[1])
+			for _, resource := range syncData.Resources() {
+				if strings.HasPrefix(resource.Dest, destPrefix) {
+					sources = append(sources, fmt.Sprintf("%s=%s/%s:%s", strings.TrimPrefix(resource.Dest, destPrefix), resource.Type, resource.Source, resource.Key))
+				}
+			}
+		}
+
+		config = idpIndexPattern.ReplaceAll(config, []byte("v4-0-config-user-idp-"))
+		config = idpPathIndexPattern.ReplaceAll(config, []byte("/var/config/user/idp/"))
+		fingerprints[name] = string(config) + "\n" + strings.Join(sources, "\n")
+	}
+	return fingerprints
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -64,6 +65,11 @@ func convertIdentityProviders(
 	errs := []error{}
 
 	for i, idp := range defaultIDPMappingMethods(identityProviders) {
+		if err := validateRequiredIDPFields(&idp.IdentityProviderConfig); err != nil {
+			errs = append(errs, fmt.Errorf("failed to apply IDP %s config: %v", idp.Name, err))
+			continue
+		}
+
 		data, err := convertProviderConfigToIDPData(cmLister, secretsLister, &idp.IdentityProviderConfig, syncData, i)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to apply IDP %s config: %v", idp.Name, err))
@@ -242,6 +248,11 @@ func convertProviderConfigToIDPData(
 			return nil, fmt.Errorf(missingProviderFmt, providerConfig.Type)
 		}
 
+		extraScopes, err := normalizeExtraScopes(openIDConfig.ExtraScopes)
+		if err != nil {
+			return nil, err
+		}
+
 		urls, err := discoverOpenIDURLs(cmLister, openIDConfig.Issuer, corev1.ServiceAccountRootCAKey, openIDConfig.CA)
 		if err != nil {
 			return nil, err
@@ -256,7 +267,7 @@ func convertProviderConfigToIDPData(
 			CA:                       syncData.AddIDPConfigMap(i, openIDConfig.CA, "ca", corev1.ServiceAccountRootCAKey),
 			ClientID:                 openIDConfig.ClientID,
 			ClientSecret:             createFileStringSource(syncData.AddIDPSecret(i, openIDConfig.ClientSecret, "client-secret", configv1.ClientSecretKey)),
-			ExtraScopes:              openIDConfig.ExtraScopes,
+			ExtraScopes:              extraScopes,
 			ExtraAuthorizeParameters: openIDConfig.ExtraAuthorizeParameters,
 			URLs:                     *urls,
 			Claims: osinv1.OpenIDClaims{
@@ -464,6 +475,43 @@ func isValidURL(rawurl string, optional bool) bool {
 	return u.Scheme == "https" && len(u.Host) > 0 && len(u.Fragment) == 0
 }
 
+// normalizeExtraScopes sorts and deduplicates the extra scopes so that
+// reordering them does not change the observed config and thus does not roll
+// out oauth-server. The scopes must be scope-tokens as defined in RFC 6749.
+func normalizeExtraScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if err := validateScopeToken(scope); err != nil {
+			return nil, fmt.Errorf("invalid extra scope %q: %v", scope, err)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+func validateScopeToken(scope string) error {
+	if len(scope) == 0 {
+		return fmt.Errorf("scope must not be empty")
+	}
+	for _, c := range scope {
+		// scope-token = 1*( %x21 / %x23-5B / %x5D-7E )
+		if c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return fmt.Errorf("scope must only contain printable ASCII characters except for space, '\"' and '\\'")
+		}
+	}
+	return nil
+}
+
 func createFileStringSource(filepath string) configv1.StringSource {
 	return configv1.StringSource{
 		StringSourceSpec: configv1.StringSourceSpec{
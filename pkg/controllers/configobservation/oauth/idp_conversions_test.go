@@ -159,6 +159,85 @@ func Test_convertProviderConfigToIDPData(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "OIDC idp with extra scopes",
+			providerConfig: &configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeOpenID,
+				OpenID: &configv1.OpenIDIdentityProvider{
+					ClientID: "someclientid",
+					ClientSecret: configv1.SecretNameReference{
+						Name: "clientsecretsecret",
+					},
+					CA: configv1.ConfigMapNameReference{
+						Name: "customca",
+					},
+					ExtraScopes: []string{"profile", "groups", "email", "groups"},
+				},
+			},
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{Name: "customca", Namespace: "openshift-config"},
+				Data:       map[string]string{"ca.crt": getCertBytesFromCAConfig(t, ca)},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: "clientsecretsecret", Namespace: "openshift-config"},
+				Data:       map[string][]byte{"clientSecret": []byte("veeery_random")},
+			},
+			oidcDiscoveryContent: `{
+				"issuer": "${OIDC_URL}",
+				"authorization_endpoint": "${OIDC_URL}/authorization",
+				"token_endpoint": "${OIDC_URL}/token"
+				}`,
+			want: &idpData{
+				challenge: false,
+				login:     true,
+				provider: &osinv1.OpenIDIdentityProvider{
+					ClientID: "someclientid",
+					ClientSecret: configv1.StringSource{
+						StringSourceSpec: configv1.StringSourceSpec{
+							File: "/var/config/user/idp/0/secret/v4-0-config-user-idp-0-client-secret/clientSecret",
+						},
+					},
+					CA:          "/var/config/user/idp/0/configMap/v4-0-config-user-idp-0-ca/ca.crt",
+					ExtraScopes: []string{"email", "groups", "profile"},
+					URLs: osinv1.OpenIDURLs{
+						Authorize: "${OIDC_URL}/authorization",
+						Token:     "${OIDC_URL}/token",
+					},
+					Claims: osinv1.OpenIDClaims{
+						ID:     []string{"sub"},
+						Groups: []string{},
+					},
+				},
+			},
+		},
+		{
+			name: "OIDC idp with an empty extra scope",
+			providerConfig: &configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeOpenID,
+				OpenID: &configv1.OpenIDIdentityProvider{
+					ClientID: "someclientid",
+					ClientSecret: configv1.SecretNameReference{
+						Name: "clientsecretsecret",
+					},
+					ExtraScopes: []string{"groups", ""},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "OIDC idp with a whitespace-separated extra scope",
+			providerConfig: &configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeOpenID,
+				OpenID: &configv1.OpenIDIdentityProvider{
+					ClientID: "someclientid",
+					ClientSecret: configv1.SecretNameReference{
+						Name: "clientsecretsecret",
+					},
+					ExtraScopes: []string{"groups profile"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "OIDC basic idp - bogus discovery info",
 			providerConfig: &configv1.IdentityProviderConfig{
@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// identityProviderDeprecationsPath holds the warnings about the deprecated
+// settings the identity providers use so that they can be surfaced in the
+// operator status
+var identityProviderDeprecationsPath = []string{"identityProviderDeprecations"}
+
+// deprecatedIDPSetting is a setting of an identity provider that still works
+// but is going to be removed
+type deprecatedIDPSetting struct {
+	idpType     configv1.IdentityProviderType
+	field       string
+	replacement string
+	inUse       func(*configv1.IdentityProviderConfig) bool
+}
+
+var deprecatedIDPSettings = []deprecatedIDPSetting{
+	{
+		idpType:     configv1.IdentityProviderTypeLDAP,
+		field:       "ldap.insecure",
+		replacement: "use an ldaps:// URL or StartTLS with ldap.ca instead",
+		inUse: func(config *configv1.IdentityProviderConfig) bool {
+			return config.LDAP != nil && config.LDAP.Insecure
+		},
+	},
+}
+
+// getIdentityProviderDeprecations returns a warning for every deprecated
+// setting used by the given identity providers, in the order of the providers
+func getIdentityProviderDeprecations(identityProviders []configv1.IdentityProvider) []interface{} {
+	var warnings []interface{}
+	for _, idp := range identityProviders {
+		for _, setting := range deprecatedIDPSettings {
+			if idp.Type != setting.idpType || !setting.inUse(&idp.IdentityProviderConfig) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("identity provider %q: %s is deprecated, %s", idp.Name, setting.field, setting.replacement))
+		}
+	}
+	return warnings
+}
+
+// GetIdentityProviderDeprecations returns the warnings about the deprecated
+// identity provider settings from the observed configuration
+func GetIdentityProviderDeprecations(observedConfig map[string]interface{}) ([]string, error) {
+	warnings, _, err := unstructured.NestedStringSlice(observedConfig, identityProviderDeprecationsPath...)
+	return warnings, err
+}
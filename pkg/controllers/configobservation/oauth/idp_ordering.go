@@ -0,0 +1,80 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// identityProviderPrioritiesAnnotation can be set on oauth.config.openshift.io/cluster
+// to control the order in which the identity providers are listed on the login
+// page. The value is a JSON object mapping IdP names to their priority, e.g.
+// '{"corp-ldap": 1, "github": 2}'. Providers with a lower priority are listed
+// first, providers without a priority are listed after all the prioritized ones.
+// Ties keep the order of spec.identityProviders. The names of the providers
+// that are not configured are ignored so that removing a provider does not
+// require updating the annotation at the same time.
+const identityProviderPrioritiesAnnotation = "auth.openshift.io/identity-provider-priorities"
+
+// getIdentityProviderPriorities parses the IdP priorities from the OAuth config
+// annotation, the priorities of the IdPs that are not configured are dropped
+// with a warning
+func getIdentityProviderPriorities(oauthConfig *configv1.OAuth) (map[string]int, error) {
+	prioritiesJSON, ok := oauthConfig.Annotations[identityProviderPrioritiesAnnotation]
+	if !ok || len(prioritiesJSON) == 0 {
+		return nil, nil
+	}
+
+	priorities := map[string]int{}
+	if err := json.Unmarshal([]byte(prioritiesJSON), &priorities); err != nil {
+		return nil, fmt.Errorf("failed to parse the %q annotation: %w", identityProviderPrioritiesAnnotation, err)
+	}
+
+	configuredIDPs := map[string]bool{}
+	for _, idp := range oauthConfig.Spec.IdentityProviders {
+		configuredIDPs[idp.Name] = true
+	}
+	for name := range priorities {
+		if !configuredIDPs[name] {
+			klog.Warningf("ignoring the priority of the unknown identity provider %q in the %q annotation", name, identityProviderPrioritiesAnnotation)
+			delete(priorities, name)
+		}
+	}
+
+	return priorities, nil
+}
+
+// sortIdentityProviders reorders the converted identity providers from the
+// observed config according to their priorities. The sort is stable so that
+// the result does not change between reconciles.
+func sortIdentityProviders(identityProviders []interface{}, priorities map[string]int) {
+	if len(priorities) == 0 {
+		return
+	}
+
+	priorityOf := func(idp interface{}) (int, bool) {
+		idpMap, ok := idp.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		name, _ := idpMap["name"].(string)
+		priority, ok := priorities[name]
+		return priority, ok
+	}
+
+	sort.SliceStable(identityProviders, func(i, j int) bool {
+		iPriority, iFound := priorityOf(identityProviders[i])
+		jPriority, jFound := priorityOf(identityProviders[j])
+		switch {
+		case iFound && jFound:
+			return iPriority < jPriority
+		default:
+			// prioritized providers go first
+			return iFound && !jFound
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func testOAuthWithIDPs(priorities string, names ...string) *configv1.OAuth {
+	oauth := &configv1.OAuth{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	if len(priorities) > 0 {
+		oauth.Annotations = map[string]string{identityProviderPrioritiesAnnotation: priorities}
+	}
+	for _, name := range names {
+		oauth.Spec.IdentityProviders = append(oauth.Spec.IdentityProviders, configv1.IdentityProvider{Name: name})
+	}
+	return oauth
+}
+
+func TestSortIdentityProviders(t *testing.T) {
+	tests := []struct {
+		name       string
+		oauth      *configv1.OAuth
+		wantOrder  []string
+		wantErrMsg string
+	}{
+		{
+			name:      "no priorities keep the spec order",
+			oauth:     testOAuthWithIDPs("", "github", "ldap", "htpasswd"),
+			wantOrder: []string{"github", "ldap", "htpasswd"},
+		},
+		{
+			name:      "configured order",
+			oauth:     testOAuthWithIDPs(`{"htpasswd": 1, "ldap": 2, "github": 3}`, "github", "ldap", "htpasswd"),
+			wantOrder: []string{"htpasswd", "ldap", "github"},
+		},
+		{
+			name:      "providers without priority go last",
+			oauth:     testOAuthWithIDPs(`{"htpasswd": 5}`, "github", "ldap", "htpasswd"),
+			wantOrder: []string{"htpasswd", "github", "ldap"},
+		},
+		{
+			name:      "ties keep the spec order",
+			oauth:     testOAuthWithIDPs(`{"htpasswd": 1, "ldap": 1, "github": 1, "gitlab": 0}`, "github", "ldap", "htpasswd", "gitlab"),
+			wantOrder: []string{"gitlab", "github", "ldap", "htpasswd"},
+		},
+		{
+			name:      "unknown providers are ignored",
+			oauth:     testOAuthWithIDPs(`{"keycloak": 1, "ldap": 2}`, "github", "ldap"),
+			wantOrder: []string{"ldap", "github"},
+		},
+		{
+			name:       "malformed annotation",
+			oauth:      testOAuthWithIDPs(`["github"]`, "github"),
+			wantErrMsg: `failed to parse the "auth.openshift.io/identity-provider-priorities" annotation: json: cannot unmarshal array into Go value of type map[string]int`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priorities, err := getIdentityProviderPriorities(tt.oauth)
+			if len(tt.wantErrMsg) > 0 {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Fatalf("expected error %q, got %v", tt.wantErrMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// run the sort several times to make sure the order is stable across reconciles
+			for i := 0; i < 3; i++ {
+				idps := []interface{}{}
+				for _, idp := range tt.oauth.Spec.IdentityProviders {
+					idps = append(idps, map[string]interface{}{"name": idp.Name})
+				}
+				sortIdentityProviders(idps, priorities)
+
+				gotOrder := []string{}
+				for _, idp := range idps {
+					gotOrder = append(gotOrder, idp.(map[string]interface{})["name"].(string))
+				}
+				if !cmp.Equal(tt.wantOrder, gotOrder) {
+					t.Errorf("unexpected order: %s", cmp.Diff(tt.wantOrder, gotOrder))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package oauth
+
+import (
+	"fmt"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+// ValidateIdentityProvider runs the checks the identity provider has to pass
+// for the config observer to sync it into the oauth-server config: the
+// required fields are set, the OpenID issuer can be discovered and the
+// referenced secrets and configmaps exist with valid data under the expected
+// keys. The checks of every provider are independent of the other providers
+// so that the errors can be attributed to the offending one.
+func ValidateIdentityProvider(cmLister corelistersv1.ConfigMapLister, secretsLister corelistersv1.SecretLister, idp *configv1.IdentityProvider) error {
+	if err := validateRequiredIDPFields(&idp.IdentityProviderConfig); err != nil {
+		return err
+	}
+
+	syncData := datasync.NewConfigSyncData()
+	if _, err := convertProviderConfigToIDPData(cmLister, secretsLister, &idp.IdentityProviderConfig, syncData, 0); err != nil {
+		return err
+	}
+	return utilerrors.NewAggregate(syncData.Validate(cmLister, secretsLister))
+}
+
+// validateRequiredIDPFields checks that the fields oauth-server requires for the
+// identity provider type are set so that a half-configured provider does not
+// get any of its resources mounted only to be rejected at oauth-server startup.
+// A missing provider configuration is reported by the conversion.
+func validateRequiredIDPFields(providerConfig *configv1.IdentityProviderConfig) error {
+	var missing []string
+	require := func(value, field string) {
+		if len(value) == 0 {
+			missing = append(missing, field)
+		}
+	}
+
+	switch providerConfig.Type {
+	case configv1.IdentityProviderTypeBasicAuth:
+		if c := providerConfig.BasicAuth; c != nil {
+			require(c.URL, "basicAuth.url")
+		}
+	case configv1.IdentityProviderTypeGitHub:
+		if c := providerConfig.GitHub; c != nil {
+			require(c.ClientID, "github.clientID")
+			require(c.ClientSecret.Name, "github.clientSecret.name")
+		}
+	case configv1.IdentityProviderTypeGitLab:
+		if c := providerConfig.GitLab; c != nil {
+			require(c.URL, "gitlab.url")
+			require(c.ClientID, "gitlab.clientID")
+			require(c.ClientSecret.Name, "gitlab.clientSecret.name")
+		}
+	case configv1.IdentityProviderTypeGoogle:
+		if c := providerConfig.Google; c != nil {
+			require(c.ClientID, "google.clientID")
+			require(c.ClientSecret.Name, "google.clientSecret.name")
+		}
+	case configv1.IdentityProviderTypeHTPasswd:
+		if c := providerConfig.HTPasswd; c != nil {
+			require(c.FileData.Name, "htpasswd.fileData.name")
+		}
+	case configv1.IdentityProviderTypeKeystone:
+		if c := providerConfig.Keystone; c != nil {
+			require(c.URL, "keystone.url")
+			require(c.DomainName, "keystone.domainName")
+		}
+	case configv1.IdentityProviderTypeLDAP:
+		if c := providerConfig.LDAP; c != nil {
+			require(c.URL, "ldap.url")
+		}
+	case configv1.IdentityProviderTypeOpenID:
+		if c := providerConfig.OpenID; c != nil {
+			require(c.Issuer, "openID.issuer")
+			require(c.ClientID, "openID.clientID")
+			require(c.ClientSecret.Name, "openID.clientSecret.name")
+		}
+	case configv1.IdentityProviderTypeRequestHeader:
+		if c := providerConfig.RequestHeader; c != nil {
+			require(c.ClientCA.Name, "requestHeader.ca.name")
+			require(strings.Join(c.Headers, ""), "requestHeader.headers")
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestConvertIdentityProvidersRequiredFields(t *testing.T) {
+	htpasswdIDP := configv1.IdentityProvider{
+		Name: "htpasswd",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type: configv1.IdentityProviderTypeHTPasswd,
+			HTPasswd: &configv1.HTPasswdIdentityProvider{
+				FileData: configv1.SecretNameReference{Name: "htpasswd-secret"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		idps              []configv1.IdentityProvider
+		wantErrs          []string
+		wantConverted     int
+		wantSyncDataItems int
+	}{
+		{
+			name:              "valid provider",
+			idps:              []configv1.IdentityProvider{htpasswdIDP},
+			wantConverted:     1,
+			wantSyncDataItems: 1,
+		},
+		{
+			name: "github without clientID",
+			idps: []configv1.IdentityProvider{
+				{
+					Name: "github",
+					IdentityProviderConfig: configv1.IdentityProviderConfig{
+						Type: configv1.IdentityProviderTypeGitHub,
+						GitHub: &configv1.GitHubIdentityProvider{
+							ClientSecret: configv1.SecretNameReference{Name: "github-secret"},
+							CA:           configv1.ConfigMapNameReference{Name: "github-ca"},
+						},
+					},
+				},
+				htpasswdIDP,
+			},
+			wantErrs:          []string{"failed to apply IDP github config: missing required fields: github.clientID"},
+			wantConverted:     1,
+			wantSyncDataItems: 1,
+		},
+		{
+			name: "openid without issuer and client secret",
+			idps: []configv1.IdentityProvider{
+				{
+					Name: "openid",
+					IdentityProviderConfig: configv1.IdentityProviderConfig{
+						Type: configv1.IdentityProviderTypeOpenID,
+						OpenID: &configv1.OpenIDIdentityProvider{
+							ClientID: "oidc-client",
+						},
+					},
+				},
+			},
+			wantErrs: []string{"failed to apply IDP openid config: missing required fields: openID.issuer, openID.clientSecret.name"},
+		},
+		{
+			name: "request header without headers",
+			idps: []configv1.IdentityProvider{
+				{
+					Name: "requestheader",
+					IdentityProviderConfig: configv1.IdentityProviderConfig{
+						Type: configv1.IdentityProviderTypeRequestHeader,
+						RequestHeader: &configv1.RequestHeaderIdentityProvider{
+							ClientCA: configv1.ConfigMapNameReference{Name: "rh-ca"},
+						},
+					},
+				},
+			},
+			wantErrs: []string{"failed to apply IDP requestheader config: missing required fields: requestHeader.headers"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := secretsIndexer.Add(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "htpasswd-secret", Namespace: "openshift-config"},
+				Data:       map[string][]byte{configv1.HTPasswdDataKey: []byte("user:password")},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			cmLister := corelistersv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+			secretsLister := corelistersv1.NewSecretLister(secretsIndexer)
+
+			converted, syncData, errs := convertIdentityProviders(cmLister, secretsLister, tt.idps)
+
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("expected errors %v, got %v", tt.wantErrs, errs)
+			}
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), tt.wantErrs[i]) {
+					t.Errorf("expected error %q, got %q", tt.wantErrs[i], err)
+				}
+			}
+			if len(converted) != tt.wantConverted {
+				t.Errorf("expected %d converted providers, got %d", tt.wantConverted, len(converted))
+			}
+			if got := syncData.Len(); got != tt.wantSyncDataItems {
+				t.Errorf("expected %d sync data items, got %d", tt.wantSyncDataItems, got)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package oauth
 
 import (
+	"fmt"
+	"path"
+
 	"k8s.io/klog/v2"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	"github.com/openshift/library-go/pkg/operator/events"
 
@@ -14,12 +18,23 @@ import (
 	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
 )
 
-var identityProvidersMounts = []string{"volumesToMount", "identityProviders"}
+var (
+	identityProvidersMounts = []string{"volumesToMount", "identityProviders"}
+	// identityProviderCountPath holds the number of the observed identity
+	// providers so that the things derived from it get recomputed and the
+	// deployment rolls out whenever it changes
+	identityProviderCountPath = []string{"identityProviderCount"}
+)
+
+// identityProviderVolumesWarningThreshold is the number of volumes the identity
+// providers may add to the oauth-server pods before we warn the admin that the
+// pods will be slow to start
+const identityProviderVolumesWarningThreshold = 50
 
 func ObserveIdentityProviders(genericlisters configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
 	identityProvidersPath := []string{"oauthConfig", "identityProviders"}
 	defer func() {
-		ret = configobserver.Pruned(ret, identityProvidersPath, identityProvidersMounts)
+		ret = configobserver.Pruned(ret, identityProvidersPath, identityProvidersMounts, identityProviderCountPath, identityProviderDeprecationsPath)
 	}()
 
 	listers := genericlisters.(configobservation.Listers)
@@ -57,6 +72,12 @@ func ObserveIdentityProviders(genericlisters configobserver.Listers, recorder ev
 		return existingConfig, append(errs, idpErrs...)
 	}
 
+	idpPriorities, err := getIdentityProviderPriorities(oauthConfig)
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	sortIdentityProviders(convertedObservedIdentityProviders, idpPriorities)
+
 	observedConfig := map[string]interface{}{}
 	if len(convertedObservedIdentityProviders) > 0 {
 		if err := unstructured.SetNestedField(observedConfig, convertedObservedIdentityProviders, identityProvidersPath...); err != nil {
@@ -73,10 +94,43 @@ func ObserveIdentityProviders(genericlisters configobserver.Listers, recorder ev
 		recorder.Eventf("ObserveIdentityProviders", "identity providers changed to %q", convertedObservedIdentityProviders)
 	}
 
-	if syncDataErrs := observedSyncData.Validate(listers.ConfigMapLister, listers.SecretsLister); len(syncDataErrs) > 0 {
+	existingIdentityProviderCount, _, err := unstructured.NestedFloat64(existingConfig, identityProviderCountPath...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	observedIdentityProviderCount := float64(len(convertedObservedIdentityProviders))
+	if err := unstructured.SetNestedField(observedConfig, observedIdentityProviderCount, identityProviderCountPath...); err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if existingIdentityProviderCount != observedIdentityProviderCount && observedSyncData.Len() > identityProviderVolumesWarningThreshold {
+		recorder.Warningf("IdentityProviderVolumesHigh", "the %d identity providers require %d volumes to be mounted to the oauth-server pods, the pods may be slow to start", len(convertedObservedIdentityProviders), observedSyncData.Len())
+	}
+
+	// the deprecated settings still apply, the admin only gets warned about them
+	existingDeprecations, _, err := unstructured.NestedSlice(existingConfig, identityProviderDeprecationsPath...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	observedDeprecations := getIdentityProviderDeprecations(oauthConfig.Spec.IdentityProviders)
+	if len(observedDeprecations) > 0 {
+		if err := unstructured.SetNestedSlice(observedConfig, observedDeprecations, identityProviderDeprecationsPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(existingDeprecations, observedDeprecations) {
+		for _, warning := range observedDeprecations {
+			recorder.Warningf("IdentityProviderSettingDeprecated", "%s", warning)
+		}
+	}
+
+	if syncDataErrs := observedSyncData.ValidateIdPSync(listers.ConfigMapLister, listers.SecretsLister); len(syncDataErrs) > 0 {
 		return existingConfig, append(errs, syncDataErrs...)
 	}
 
+	if changes := describeIdentityProviderChanges(existingIDPsSlice, existingSyncData, convertedObservedIdentityProviders, observedSyncData); len(changes) > 0 {
+		recorder.Eventf("IdentityProvidersChanged", "%s", changes)
+	}
+
 	datasync.HandleIdPConfigSync(resourceSyncer, existingSyncData, observedSyncData)
 
 	if err := unstructured.SetNestedField(observedConfig, string(observedSyncDataBytes), identityProvidersMounts...); err != nil {
@@ -86,6 +140,16 @@ func ObserveIdentityProviders(genericlisters configobserver.Listers, recorder ev
 	return observedConfig, errs
 }
 
+// GetIdentityProviderCount returns the number of the identity providers from
+// the observed configuration
+func GetIdentityProviderCount(observedConfig map[string]interface{}) (int, error) {
+	count, _, err := unstructured.NestedFloat64(observedConfig, identityProviderCountPath...)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
 // GetIDPConfigSyncData returns the data that should be synchronized and mounted
 // to the oauth-server container from the observed configuration
 func GetIDPConfigSyncData(observedConfig map[string]interface{}) (*datasync.ConfigSyncData, error) {
@@ -100,3 +164,145 @@ func GetIDPConfigSyncData(observedConfig map[string]interface{}) (*datasync.Conf
 
 	return datasync.NewConfigSyncDataFromJSON(currentSyncDataBytes)
 }
+
+// IdentityProviderSummary is the name and the type of an identity provider,
+// without any of its configuration
+type IdentityProviderSummary struct {
+	Name string                        `json:"name"`
+	Type configv1.IdentityProviderType `json:"type"`
+}
+
+// identityProviderTypes maps the kinds of the osin identity providers to the
+// types of the config API they were converted from
+var identityProviderTypes = map[string]configv1.IdentityProviderType{
+	"BasicAuthPasswordIdentityProvider": configv1.IdentityProviderTypeBasicAuth,
+	"GitHubIdentityProvider":            configv1.IdentityProviderTypeGitHub,
+	"GitLabIdentityProvider":            configv1.IdentityProviderTypeGitLab,
+	"GoogleIdentityProvider":            configv1.IdentityProviderTypeGoogle,
+	"HTPasswdPasswordIdentityProvider":  configv1.IdentityProviderTypeHTPasswd,
+	"KeystonePasswordIdentityProvider":  configv1.IdentityProviderTypeKeystone,
+	"LDAPPasswordIdentityProvider":      configv1.IdentityProviderTypeLDAP,
+	"OpenIDIdentityProvider":            configv1.IdentityProviderTypeOpenID,
+	"RequestHeaderIdentityProvider":     configv1.IdentityProviderTypeRequestHeader,
+}
+
+// GetIdentityProviderSummaries returns the names and the types of the identity
+// providers from the observed configuration in their order
+func GetIdentityProviderSummaries(observedConfig map[string]interface{}) ([]IdentityProviderSummary, error) {
+	identityProviders, _, err := unstructured.NestedSlice(observedConfig, "oauthConfig", "identityProviders")
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]IdentityProviderSummary, 0, len(identityProviders))
+	for i, identityProvider := range identityProviders {
+		idp, ok := identityProvider.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("identity provider %d: unexpected type %T", i, identityProvider)
+		}
+		name, _, err := unstructured.NestedString(idp, "name")
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %d: %w", i, err)
+		}
+		kind, _, err := unstructured.NestedString(idp, "provider", "kind")
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %s: %w", name, err)
+		}
+
+		idpType, ok := identityProviderTypes[kind]
+		if !ok {
+			idpType = configv1.IdentityProviderType(kind)
+		}
+		summaries = append(summaries, IdentityProviderSummary{Name: name, Type: idpType})
+	}
+	return summaries, nil
+}
+
+// IdentityProviderEndpoint is the remote endpoint oauth-server connects to
+// on behalf of an identity provider
+type IdentityProviderEndpoint struct {
+	Name string
+	URL  string
+}
+
+// GetIdentityProviderEndpoints returns the endpoints of the identity providers
+// from the observed configuration in their order, the identity providers
+// served by oauth-server itself such as htpasswd have none
+func GetIdentityProviderEndpoints(observedConfig map[string]interface{}) ([]IdentityProviderEndpoint, error) {
+	identityProviders, _, err := unstructured.NestedSlice(observedConfig, "oauthConfig", "identityProviders")
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []IdentityProviderEndpoint
+	for i, identityProvider := range identityProviders {
+		idp, ok := identityProvider.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("identity provider %d: unexpected type %T", i, identityProvider)
+		}
+		name, _, err := unstructured.NestedString(idp, "name")
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %d: %w", i, err)
+		}
+		kind, _, err := unstructured.NestedString(idp, "provider", "kind")
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %s: %w", name, err)
+		}
+
+		var endpoint string
+		switch kind {
+		case "BasicAuthPasswordIdentityProvider", "GitLabIdentityProvider", "KeystonePasswordIdentityProvider", "LDAPPasswordIdentityProvider":
+			endpoint, _, err = unstructured.NestedString(idp, "provider", "url")
+		case "OpenIDIdentityProvider":
+			// the token endpoint is the one oauth-server calls itself, the
+			// authorize endpoint only needs to be reachable by the browsers
+			endpoint, _, err = unstructured.NestedString(idp, "provider", "urls", "token")
+		case "GitHubIdentityProvider":
+			var hostname string
+			hostname, _, err = unstructured.NestedString(idp, "provider", "hostname")
+			if len(hostname) == 0 {
+				hostname = "github.com"
+			}
+			endpoint = "https://" + hostname
+		case "GoogleIdentityProvider":
+			endpoint = "https://accounts.google.com"
+		}
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %s: %w", name, err)
+		}
+		if len(endpoint) > 0 {
+			endpoints = append(endpoints, IdentityProviderEndpoint{Name: name, URL: endpoint})
+		}
+	}
+	return endpoints, nil
+}
+
+// GetClientCAConfigMaps returns the names of the synced configmaps with the
+// client CAs of the request header identity providers from the observed
+// configuration, oauth-server only reads these when it starts
+func GetClientCAConfigMaps(observedConfig map[string]interface{}) ([]string, error) {
+	identityProviders, _, err := unstructured.NestedSlice(observedConfig, "oauthConfig", "identityProviders")
+	if err != nil {
+		return nil, err
+	}
+
+	var configMaps []string
+	for i, identityProvider := range identityProviders {
+		idp, ok := identityProvider.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("identity provider %d: unexpected type %T", i, identityProvider)
+		}
+		if kind, _, _ := unstructured.NestedString(idp, "provider", "kind"); kind != "RequestHeaderIdentityProvider" {
+			continue
+		}
+		clientCA, _, err := unstructured.NestedString(idp, "provider", "clientCA")
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %d: %w", i, err)
+		}
+		// the file is mounted from the directory named after the configmap
+		if len(clientCA) > 0 {
+			configMaps = append(configMaps, path.Base(path.Dir(clientCA)))
+		}
+	}
+	return configMaps, nil
+}
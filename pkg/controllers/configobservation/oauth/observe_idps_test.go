@@ -2,6 +2,7 @@ package oauth
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -9,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	corelistersv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -99,6 +101,7 @@ func TestObserveIdentityProviders(t *testing.T) {
 			previouslyObservedConfig: map[string]interface{}{},
 			previousSyncerData:       map[string]string{},
 			expected: map[string]interface{}{
+				"identityProviderCount": float64(1),
 				"oauthConfig": map[string]interface{}{
 					"identityProviders": []interface{}{
 						map[string]interface{}{
@@ -121,7 +124,7 @@ func TestObserveIdentityProviders(t *testing.T) {
 			expectedSyncerData: map[string]string{
 				"secret/v4-0-config-user-idp-0-file-data.openshift-authentication": "secret/somesecret.openshift-config",
 			},
-			expectedEvents: 1,
+			expectedEvents: 2,
 			errors:         []error{},
 		},
 		{
@@ -139,6 +142,7 @@ func TestObserveIdentityProviders(t *testing.T) {
 				},
 			},
 			previouslyObservedConfig: map[string]interface{}{
+				"identityProviderCount": float64(1),
 				"oauthConfig": map[string]interface{}{
 					"identityProviders": []interface{}{
 						map[string]interface{}{
@@ -162,6 +166,7 @@ func TestObserveIdentityProviders(t *testing.T) {
 				"secret/v4-0-config-user-idp-0-file-data.openshift-authentication": "secret/somesecret.openshift-config",
 			},
 			expected: map[string]interface{}{
+				"identityProviderCount": float64(0),
 				"volumesToMount": map[string]interface{}{
 					"identityProviders": string(`{}`),
 				},
@@ -169,7 +174,7 @@ func TestObserveIdentityProviders(t *testing.T) {
 			expectedSyncerData: map[string]string{
 				"secret/v4-0-config-user-idp-0-file-data.openshift-authentication": "DELETE",
 			},
-			expectedEvents: 1,
+			expectedEvents: 2,
 			errors:         []error{},
 		},
 	}
@@ -228,3 +233,271 @@ func eventsReasonMessage(e []*corev1.Event) []string {
 	}
 	return reasonMessages
 }
+
+func TestObserveIdentityProvidersDeprecations(t *testing.T) {
+	ldapIDP := func(insecure bool) configv1.IdentityProvider {
+		return configv1.IdentityProvider{
+			Name: "some ldap provider",
+			IdentityProviderConfig: configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeLDAP,
+				LDAP: &configv1.LDAPIdentityProvider{
+					URL:      "ldap://ldap.example.com/ou=users,dc=example,dc=com?uid",
+					Insecure: insecure,
+					Attributes: configv1.LDAPAttributeMapping{
+						ID: []string{"dn"},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		idps                     []configv1.IdentityProvider
+		previouslyObservedConfig map[string]interface{}
+		expectedDeprecations     []string
+		expectedWarnings         int
+	}{
+		{
+			name: "clean config",
+			idps: []configv1.IdentityProvider{ldapIDP(false)},
+		},
+		{
+			name:                 "deprecated setting",
+			idps:                 []configv1.IdentityProvider{ldapIDP(true)},
+			expectedDeprecations: []string{`identity provider "some ldap provider": ldap.insecure is deprecated, use an ldaps:// URL or StartTLS with ldap.ca instead`},
+			expectedWarnings:     1,
+		},
+		{
+			name: "deprecated setting already observed",
+			idps: []configv1.IdentityProvider{ldapIDP(true)},
+			previouslyObservedConfig: map[string]interface{}{
+				"identityProviderDeprecations": []interface{}{`identity provider "some ldap provider": ldap.insecure is deprecated, use an ldaps:// URL or StartTLS with ldap.ca instead`},
+			},
+			expectedDeprecations: []string{`identity provider "some ldap provider": ldap.insecure is deprecated, use an ldaps:// URL or StartTLS with ldap.ca instead`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.OAuthSpec{IdentityProviders: tt.idps},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			listers := configobservation.Listers{
+				ConfigMapLister: corelistersv1.NewConfigMapLister(indexer),
+				SecretsLister:   corelistersv1.NewSecretLister(indexer),
+				OAuthLister_:    configlistersv1.NewOAuthLister(indexer),
+				ResourceSync:    &mockResourceSyncer{t: t, synced: map[string]string{}},
+			}
+			eventsRecorder := events.NewInMemoryRecorder(t.Name())
+
+			previouslyObservedConfig := tt.previouslyObservedConfig
+			if previouslyObservedConfig == nil {
+				previouslyObservedConfig = map[string]interface{}{}
+			}
+			got, errs := ObserveIdentityProviders(listers, eventsRecorder, previouslyObservedConfig)
+			if len(errs) > 0 {
+				t.Fatalf("Expected 0 errors, got %v.", errs)
+			}
+
+			// the deprecated settings do not block the config from being applied
+			if count, err := GetIdentityProviderCount(got); err != nil || count != len(tt.idps) {
+				t.Errorf("expected %d identity providers to be observed, got %d: %v", len(tt.idps), count, err)
+			}
+
+			deprecations, err := GetIdentityProviderDeprecations(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(tt.expectedDeprecations, deprecations) {
+				t.Errorf("deprecations do not match: %s", cmp.Diff(tt.expectedDeprecations, deprecations))
+			}
+
+			warnings := 0
+			for _, ev := range eventsRecorder.Events() {
+				if ev.Reason == "IdentityProviderSettingDeprecated" {
+					warnings++
+				}
+			}
+			if warnings != tt.expectedWarnings {
+				t.Errorf("Expected %d deprecation warnings, got %v.", tt.expectedWarnings, eventsReasonMessage(eventsRecorder.Events()))
+			}
+		})
+	}
+}
+
+func TestObserveIdentityProvidersChangeEvents(t *testing.T) {
+	htpasswdIDP := func(name, secretName string) configv1.IdentityProvider {
+		return configv1.IdentityProvider{
+			Name: name,
+			IdentityProviderConfig: configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeHTPasswd,
+				HTPasswd: &configv1.HTPasswdIdentityProvider{
+					FileData: configv1.SecretNameReference{Name: secretName},
+				},
+			},
+		}
+	}
+
+	steps := []struct {
+		name          string
+		idps          []configv1.IdentityProvider
+		expectedEvent string
+	}{
+		{
+			name:          "identity provider added",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("alpha", "alpha-users")},
+			expectedEvent: `identity providers changed: added "alpha"`,
+		},
+		{
+			name:          "identity providers added and removed",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("beta", "beta-users"), htpasswdIDP("gamma", "gamma-users")},
+			expectedEvent: `identity providers changed: added "beta", "gamma"; removed "alpha"`,
+		},
+		{
+			name: "identity providers reordered",
+			idps: []configv1.IdentityProvider{htpasswdIDP("gamma", "gamma-users"), htpasswdIDP("beta", "beta-users")},
+		},
+		{
+			name:          "identity provider secret changed",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("gamma", "alpha-users"), htpasswdIDP("beta", "beta-users")},
+			expectedEvent: `identity providers changed: modified "gamma"`,
+		},
+		{
+			name:          "identity provider removed",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("beta", "beta-users")},
+			expectedEvent: `identity providers changed: removed "gamma"`,
+		},
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, step := range steps {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		if err := indexer.Add(&configv1.OAuth{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec:       configv1.OAuthSpec{IdentityProviders: step.idps},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for _, secretName := range []string{"alpha-users", "beta-users", "gamma-users"} {
+			if err := indexer.Add(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "openshift-config"},
+				Data:       map[string][]byte{"htpasswd": []byte("user:$2y$05$secrethash")},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		listers := configobservation.Listers{
+			ConfigMapLister: corelistersv1.NewConfigMapLister(indexer),
+			SecretsLister:   corelistersv1.NewSecretLister(indexer),
+			OAuthLister_:    configlistersv1.NewOAuthLister(indexer),
+			ResourceSync:    &mockResourceSyncer{t: t, synced: map[string]string{}},
+		}
+		eventsRecorder := events.NewInMemoryRecorder(t.Name())
+
+		got, errs := ObserveIdentityProviders(listers, eventsRecorder, observedConfig)
+		if len(errs) > 0 {
+			t.Fatalf("%s: expected 0 errors, got %v", step.name, errs)
+		}
+		observedConfig = got
+
+		var changeEvents []string
+		for _, ev := range eventsRecorder.Events() {
+			if ev.Reason == "IdentityProvidersChanged" {
+				changeEvents = append(changeEvents, ev.Message)
+				if strings.Contains(ev.Message, "secrethash") {
+					t.Errorf("%s: expected the event not to contain any secret data, got %q", step.name, ev.Message)
+				}
+			}
+		}
+		var expectedEvents []string
+		if len(step.expectedEvent) > 0 {
+			expectedEvents = []string{step.expectedEvent}
+		}
+		if !equality.Semantic.DeepEqual(expectedEvents, changeEvents) {
+			t.Errorf("%s: expected the change events %q, got %q", step.name, expectedEvents, changeEvents)
+		}
+	}
+}
+
+func TestObserveIdentityProvidersRemovedPrioritizedProvider(t *testing.T) {
+	htpasswdIDP := func(name string) configv1.IdentityProvider {
+		return configv1.IdentityProvider{
+			Name: name,
+			IdentityProviderConfig: configv1.IdentityProviderConfig{
+				Type:     configv1.IdentityProviderTypeHTPasswd,
+				HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: name + "-users"}},
+			},
+		}
+	}
+
+	steps := []struct {
+		name          string
+		idps          []configv1.IdentityProvider
+		expectedOrder []string
+	}{
+		{
+			name:          "prioritized providers",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("alpha"), htpasswdIDP("beta")},
+			expectedOrder: []string{"beta", "alpha"},
+		},
+		{
+			// the annotation still lists the removed provider
+			name:          "prioritized provider removed",
+			idps:          []configv1.IdentityProvider{htpasswdIDP("alpha")},
+			expectedOrder: []string{"alpha"},
+		},
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, step := range steps {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		if err := indexer.Add(&configv1.OAuth{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cluster",
+				Annotations: map[string]string{identityProviderPrioritiesAnnotation: `{"beta": 1, "alpha": 2}`},
+			},
+			Spec: configv1.OAuthSpec{IdentityProviders: step.idps},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for _, secretName := range []string{"alpha-users", "beta-users"} {
+			if err := indexer.Add(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "openshift-config"},
+				Data:       map[string][]byte{"htpasswd": []byte("user:$2y$05$secrethash")},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		listers := configobservation.Listers{
+			ConfigMapLister: corelistersv1.NewConfigMapLister(indexer),
+			SecretsLister:   corelistersv1.NewSecretLister(indexer),
+			OAuthLister_:    configlistersv1.NewOAuthLister(indexer),
+			ResourceSync:    &mockResourceSyncer{t: t, synced: map[string]string{}},
+		}
+
+		got, errs := ObserveIdentityProviders(listers, events.NewInMemoryRecorder(t.Name()), observedConfig)
+		if len(errs) > 0 {
+			t.Fatalf("%s: expected 0 errors, got %v", step.name, errs)
+		}
+		observedConfig = got
+
+		idps, _, err := unstructured.NestedSlice(got, "oauthConfig", "identityProviders")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotOrder []string
+		for _, idp := range idps {
+			gotOrder = append(gotOrder, idp.(map[string]interface{})["name"].(string))
+		}
+		if !cmp.Equal(step.expectedOrder, gotOrder) {
+			t.Errorf("%s: unexpected identity providers: %s", step.name, cmp.Diff(step.expectedOrder, gotOrder))
+		}
+	}
+}
@@ -18,10 +18,15 @@ import (
 	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
 )
 
+// templateReferencesPath holds the names of the admin-provided template
+// secrets so that swapping a template for another secret rolls out the
+// deployment even before the synced copy changes
+var templateReferencesPath = []string{"templateReferences"}
+
 func ObserveTemplates(genericlisters configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
 	templatesPath := []string{"oauthConfig", "templates"}
 	defer func() {
-		ret = configobserver.Pruned(ret, templatesPath)
+		ret = configobserver.Pruned(ret, templatesPath, templateReferencesPath)
 	}()
 
 	listers := genericlisters.(configobservation.Listers)
@@ -62,6 +67,12 @@ func ObserveTemplates(genericlisters configobserver.Listers, recorder events.Rec
 		}
 	}
 
+	if templateReferences := templateReferencesFrom(&oauthConfig.Spec.Templates); len(templateReferences) > 0 {
+		if err := unstructured.SetNestedStringMap(observedConfig, templateReferences, templateReferencesPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+
 	if !equality.Semantic.DeepEqual(existingTemplates, observedTemplates) {
 		recorder.Eventf("ObserveTemplates", "templates changed to %q", observedTemplates)
 	}
@@ -71,6 +82,30 @@ func ObserveTemplates(genericlisters configobserver.Listers, recorder events.Rec
 	return observedConfig, errs
 }
 
+// templateReferencesFrom returns the names of the configured template secrets
+// keyed by the template they are used for, the cleared references are omitted
+// as the default templates get used for them
+func templateReferencesFrom(templates *configv1.OAuthTemplates) map[string]string {
+	references := map[string]string{}
+	if len(templates.Login.Name) > 0 {
+		references["login"] = templates.Login.Name
+	}
+	if len(templates.ProviderSelection.Name) > 0 {
+		references["providerSelection"] = templates.ProviderSelection.Name
+	}
+	if len(templates.Error.Name) > 0 {
+		references["error"] = templates.Error.Name
+	}
+	return references
+}
+
+// GetTemplateReferences returns the names of the admin-provided template
+// secrets from the observed configuration
+func GetTemplateReferences(observedConfig map[string]interface{}) (map[string]string, error) {
+	references, _, err := unstructured.NestedStringMap(observedConfig, templateReferencesPath...)
+	return references, err
+}
+
 func syncTemplateSecrets(syncer resourcesynccontroller.ResourceSyncer, syncData map[string]string) {
 	// we need to go through each key to remove synced secrets that no longer should be synced
 	srcName := syncData[configv1.LoginTemplateKey]
@@ -53,6 +53,45 @@ func TestObserveTemplates(t *testing.T) {
 						"providerSelection": "/var/config/user/template/secret/v4-0-config-user-template-provider-selection/providers.html",
 					},
 				},
+				"templateReferences": map[string]interface{}{
+					"error":             "error-template",
+					"login":             "login-template",
+					"providerSelection": "ps-template",
+				},
+			},
+			errors: []error{},
+		},
+		{
+			name: "change a template reference",
+			config: &configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.OAuthSpec{
+					Templates: configv1.OAuthTemplates{
+						Login: configv1.SecretNameReference{Name: "new-login-template"},
+					},
+				},
+			},
+			previouslyObservedConfig: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"templates": map[string]interface{}{
+						"login": "/var/config/user/template/secret/v4-0-config-user-template-login/login.html",
+					},
+				},
+				"templateReferences": map[string]interface{}{
+					"login": "login-template",
+				},
+			},
+			expected: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"templates": map[string]interface{}{
+						"error":             "",
+						"login":             "/var/config/user/template/secret/v4-0-config-user-template-login/login.html",
+						"providerSelection": "",
+					},
+				},
+				"templateReferences": map[string]interface{}{
+					"login": "new-login-template",
+				},
 			},
 			errors: []error{},
 		},
@@ -69,7 +108,13 @@ func TestObserveTemplates(t *testing.T) {
 						"login":             "/var/config/user/template/secret/v4-0-config-user-template-login/login.html",
 						"providerSelection": "/var/config/user/template/secret/v4-0-config-user-template-provider-selection/providers.html",
 					},
-				}},
+				},
+				"templateReferences": map[string]interface{}{
+					"error":             "error-template",
+					"login":             "login-template",
+					"providerSelection": "ps-template",
+				},
+			},
 			expected: map[string]interface{}{},
 			errors:   []error{},
 		},
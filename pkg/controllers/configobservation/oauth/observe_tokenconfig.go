@@ -17,6 +17,13 @@ const (
 	defaultAuthorizeTokenMaxAgeSeconds = float64(300)   // 5 minutes
 )
 
+// ObserveTokenConfig observes the token config of oauth.config/cluster.
+//
+// The OAuth API treats accessTokenMaxAgeSeconds=0, which is also what an unset
+// field decodes to, as "use the default", it never means tokens that do not
+// expire. We always write the default explicitly into the observed config so
+// that the value, and with it the oauth-server config and the deployment hash,
+// is the same whether the field is unset, zero or set to the default.
 func ObserveTokenConfig(genericlisters configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
 	tokenConfigPath := []string{"oauthConfig", "tokenConfig"}
 	defer func() {
@@ -26,12 +33,14 @@ func ObserveTokenConfig(genericlisters configobserver.Listers, recorder events.R
 	listers := genericlisters.(configobservation.Listers)
 	errs = []error{}
 
-	existingConfig, _, err := unstructured.NestedMap(existingConfig, tokenConfigPath...)
+	// keep the existingConfig intact so that it can be returned untouched on errors,
+	// returning only its token config would make it get pruned
+	existingTokenConfig, _, err := unstructured.NestedMap(existingConfig, tokenConfigPath...)
 	if err != nil {
 		return existingConfig, append(errs, err)
 	}
 
-	existingAccessTokenMaxAgeSeconds, _, err := unstructured.NestedFloat64(existingConfig, "accessTokenMaxAgeSeconds")
+	existingAccessTokenMaxAgeSeconds, _, err := unstructured.NestedFloat64(existingTokenConfig, "accessTokenMaxAgeSeconds")
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -54,6 +63,7 @@ func ObserveTokenConfig(genericlisters configobserver.Listers, recorder events.R
 	}
 
 	observedAccessTokenMaxAgeSeconds := float64(oauthConfig.Spec.TokenConfig.AccessTokenMaxAgeSeconds)
+	// unset and zero both mean the default
 	if observedAccessTokenMaxAgeSeconds == 0 {
 		observedAccessTokenMaxAgeSeconds = defaultAccessTokenMaxAgeSeconds
 	}
@@ -68,7 +78,7 @@ func ObserveTokenConfig(genericlisters configobserver.Listers, recorder events.R
 	}
 
 	if !(existingAccessTokenMaxAgeSeconds == observedAccessTokenMaxAgeSeconds) {
-		recorder.Eventf("ObserveTokenConfig", "accessTokenMaxAgeSeconds changed from %d to %d", existingAccessTokenMaxAgeSeconds, observedAccessTokenMaxAgeSeconds)
+		recorder.Eventf("ObserveTokenConfig", "accessTokenMaxAgeSeconds changed from %.0f to %.0f", existingAccessTokenMaxAgeSeconds, observedAccessTokenMaxAgeSeconds)
 	}
 
 	return observedConfig, errs
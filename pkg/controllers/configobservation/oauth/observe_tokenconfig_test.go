@@ -22,6 +22,7 @@ func TestObserveTokenConfig(t *testing.T) {
 		config                   *configv1.OAuth
 		previouslyObservedConfig map[string]interface{}
 		expected                 map[string]interface{}
+		expectedEvents           int
 		errors                   []error
 	}{
 		{
@@ -59,6 +60,62 @@ func TestObserveTokenConfig(t *testing.T) {
 					},
 				},
 			},
+			expectedEvents: 1,
+			errors:         []error{},
+		},
+		{
+			name: "unset max age keeps the observed default",
+			config: &configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster",
+				},
+			},
+			previouslyObservedConfig: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"tokenConfig": map[string]interface{}{
+						"accessTokenMaxAgeSeconds":    float64(86400),
+						"authorizeTokenMaxAgeSeconds": float64(300),
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"tokenConfig": map[string]interface{}{
+						"accessTokenMaxAgeSeconds":    float64(86400),
+						"authorizeTokenMaxAgeSeconds": float64(300),
+					},
+				},
+			},
+			errors: []error{},
+		},
+		{
+			name: "explicit default max age is the same as unset",
+			config: &configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster",
+				},
+				Spec: configv1.OAuthSpec{
+					TokenConfig: configv1.TokenConfig{
+						AccessTokenMaxAgeSeconds: 86400,
+					},
+				},
+			},
+			previouslyObservedConfig: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"tokenConfig": map[string]interface{}{
+						"accessTokenMaxAgeSeconds":    float64(86400),
+						"authorizeTokenMaxAgeSeconds": float64(300),
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"oauthConfig": map[string]interface{}{
+					"tokenConfig": map[string]interface{}{
+						"accessTokenMaxAgeSeconds":    float64(86400),
+						"authorizeTokenMaxAgeSeconds": float64(300),
+					},
+				},
+			},
 			errors: []error{},
 		},
 		{
@@ -82,7 +139,8 @@ func TestObserveTokenConfig(t *testing.T) {
 					},
 				},
 			},
-			errors: []error{},
+			expectedEvents: 1,
+			errors:         []error{},
 		},
 		{
 			name: "max age < 0 defaults to whatever the osin default is", // this is disabled by CR admission
@@ -105,7 +163,8 @@ func TestObserveTokenConfig(t *testing.T) {
 					},
 				},
 			},
-			errors: []error{},
+			expectedEvents: 1,
+			errors:         []error{},
 		},
 	}
 	for _, tt := range tests {
@@ -119,10 +178,14 @@ func TestObserveTokenConfig(t *testing.T) {
 			listers := configobservation.Listers{
 				OAuthLister_: configlistersv1.NewOAuthLister(indexer),
 			}
-			got, errs := ObserveTokenConfig(listers, events.NewInMemoryRecorder(t.Name()), tt.previouslyObservedConfig)
+			eventsRecorder := events.NewInMemoryRecorder(t.Name())
+			got, errs := ObserveTokenConfig(listers, eventsRecorder, tt.previouslyObservedConfig)
 			if len(errs) > 0 {
 				t.Errorf("Expected 0 errors, got %v.", len(errs))
 			}
+			if gotEvents := eventsRecorder.Events(); tt.expectedEvents != len(gotEvents) {
+				t.Errorf("Expected %d events, got %v.", tt.expectedEvents, eventsReasonMessage(gotEvents))
+			}
 			if !equality.Semantic.DeepEqual(tt.expected, got) {
 				t.Errorf("result does not match expected config: %s", cmp.Diff(tt.expected, got))
 			}
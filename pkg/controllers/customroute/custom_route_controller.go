@@ -2,8 +2,10 @@ package customroute
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"net/url"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -11,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
@@ -26,6 +29,7 @@ import (
 	routev1lister "github.com/openshift/client-go/route/listers/route/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
@@ -99,6 +103,14 @@ func NewCustomRouteController(
 }
 
 func (c *customRouteController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(spec.ManagementState) {
+		return nil
+	}
+
 	ingressConfig, err := c.ingressLister.Get("cluster")
 	if err != nil {
 		return err
@@ -111,7 +123,7 @@ func (c *customRouteController) sync(ctx context.Context, syncCtx factory.SyncCo
 	if errors != nil {
 		// log if there is an issue updating the ingressConfig resource
 		if updateIngressConfigErr := c.updateIngressConfigStatus(ctx, ingressConfigCopy, errors); updateIngressConfigErr != nil {
-			klog.Infof("Error updating ingress with custom route status: %v", err)
+			klog.Infof("Error updating ingress with custom route status: %v", updateIngressConfigErr)
 		}
 		return fmt.Errorf("custom route configuration failed verification: %v", errors)
 	}
@@ -139,15 +151,15 @@ func (c *customRouteController) getOAuthRouteAndSecretName(ingressConfig *config
 	// check if a user is overriding route defaults
 	if componentRoute := common.GetComponentRouteSpec(ingressConfig, OAuthComponentRouteNamespace, OAuthComponentRouteName); componentRoute != nil {
 		var errors []error
-		// Check if the provided secret is valid
-		secretName = componentRoute.ServingCertKeyPairSecret.Name
-		if err := c.validateCustomTLSSecret(secretName); err != nil {
-			errors = append(errors, err)
-		}
-
 		// Check if the provided hostname is valid
 		hostname := string(componentRoute.Hostname)
-		if _, err := url.Parse(hostname); err != nil {
+		if msgs := validation.IsDNS1123Subdomain(hostname); len(msgs) > 0 {
+			errors = append(errors, fmt.Errorf("invalid hostname %q: %s", hostname, strings.Join(msgs, ", ")))
+		}
+
+		// Check if the provided secret is valid for the hostname
+		secretName = componentRoute.ServingCertKeyPairSecret.Name
+		if err := c.validateCustomTLSSecret(secretName, hostname); err != nil {
 			errors = append(errors, err)
 		}
 
@@ -161,7 +173,7 @@ func (c *customRouteController) getOAuthRouteAndSecretName(ingressConfig *config
 	return route, secretName, nil
 }
 
-func (c *customRouteController) validateCustomTLSSecret(secretName string) error {
+func (c *customRouteController) validateCustomTLSSecret(secretName, hostname string) error {
 	if secretName != "" {
 		secret, err := c.secretLister.Secrets("openshift-config").Get(secretName)
 		if err != nil {
@@ -183,6 +195,10 @@ func (c *customRouteController) validateCustomTLSSecret(secretName string) error
 			errors = append(errors, datasync.ValidateServerCert(certData)...)
 		}
 
+		if len(errors) == 0 {
+			errors = append(errors, validateCertKeyPairForHostname(certData, privateKeyData, hostname)...)
+		}
+
 		if len(errors) != 0 {
 			return fmt.Errorf("error validating secret %s/%s: %v", "openshift-config", secretName, errors)
 		}
@@ -190,6 +206,24 @@ func (c *customRouteController) validateCustomTLSSecret(secretName string) error
 	return nil
 }
 
+// validateCertKeyPairForHostname checks that the private key belongs to the
+// serving certificate and that the certificate is valid for the hostname the
+// router serves it for
+func validateCertKeyPairForHostname(certData, privateKeyData []byte, hostname string) []error {
+	keyPair, err := tls.X509KeyPair(certData, privateKeyData)
+	if err != nil {
+		return []error{fmt.Errorf("the certificate does not match the private key: %v", err)}
+	}
+	serving, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return []error{fmt.Errorf("failed to parse the certificate: %v", err)}
+	}
+	if err := serving.VerifyHostname(hostname); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
 func (c *customRouteController) applyRoute(ctx context.Context, expectedRoute *routev1.Route) error {
 	route, err := c.routeClient.Get(ctx, "oauth-openshift", metav1.GetOptions{})
 	if errors.IsNotFound(err) {
@@ -230,7 +264,7 @@ func (c *customRouteController) updateIngressConfigStatus(ctx context.Context, i
 		WithName(c.componentRoute.Name).
 		WithDefaultHostname(configv1.Hostname("oauth-openshift." + ingressConfig.Spec.Domain)).
 		WithCurrentHostnames(configv1.Hostname(route.Spec.Host)).
-		WithConsumingUsers("system:serviceaccount:oauth-openshift:authentication-operator").
+		WithConsumingUsers("system:serviceaccount:openshift-authentication-operator:authentication-operator").
 		WithRelatedObjects(
 			applyconfigv1.ObjectReference().
 				WithNamespace("openshift-authentication").
@@ -0,0 +1,130 @@
+package customroute
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+func testServingCert(t *testing.T, hostnames ...string) ([]byte, []byte) {
+	caConfig, err := crypto.MakeSelfSignedCAConfigForDuration("test-ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &crypto.CA{Config: caConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	servingCert, err := ca.MakeServerCertForDuration(sets.NewString(hostnames...), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := servingCert.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, keyPEM
+}
+
+func TestGetOAuthRouteAndSecretName(t *testing.T) {
+	certPEM, keyPEM := testServingCert(t, "login.example.com")
+	_, otherKeyPEM := testServingCert(t, "login.example.com")
+
+	tests := []struct {
+		name            string
+		hostname        string
+		secretData      map[string][]byte
+		wantHost        string
+		wantSecret      string
+		wantErrContains string
+	}{
+		{
+			name:     "default hostname",
+			wantHost: "oauth-openshift.apps.example.com",
+		},
+		{
+			name:       "custom hostname and serving certificate",
+			hostname:   "login.example.com",
+			secretData: map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+			wantHost:   "login.example.com",
+			wantSecret: "login-cert",
+		},
+		{
+			name:            "invalid hostname",
+			hostname:        "https://login.example.com",
+			secretData:      map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+			wantErrContains: `invalid hostname "https://login.example.com"`,
+		},
+		{
+			name:            "certificate for another hostname",
+			hostname:        "sso.example.com",
+			secretData:      map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+			wantErrContains: "certificate is valid for login.example.com, not sso.example.com",
+		},
+		{
+			name:            "private key of another certificate",
+			hostname:        "login.example.com",
+			secretData:      map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: otherKeyPEM},
+			wantErrContains: "the certificate does not match the private key",
+		},
+		{
+			name:            "missing private key",
+			hostname:        "login.example.com",
+			secretData:      map[string][]byte{corev1.TLSCertKey: certPEM},
+			wantErrContains: "custom route secret must include key tls.key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			ingressConfig := &configv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.IngressSpec{Domain: "apps.example.com"},
+			}
+			if len(tt.hostname) > 0 {
+				ingressConfig.Spec.ComponentRoutes = []configv1.ComponentRouteSpec{{
+					Namespace:                OAuthComponentRouteNamespace,
+					Name:                     OAuthComponentRouteName,
+					Hostname:                 configv1.Hostname(tt.hostname),
+					ServingCertKeyPairSecret: configv1.SecretNameReference{Name: "login-cert"},
+				}}
+				if err := secretIndexer.Add(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "login-cert", Namespace: "openshift-config"},
+					Data:       tt.secretData,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := &customRouteController{secretLister: corev1listers.NewSecretLister(secretIndexer)}
+
+			route, secretName, errs := c.getOAuthRouteAndSecretName(ingressConfig)
+			if len(tt.wantErrContains) > 0 {
+				found := false
+				for _, err := range errs {
+					if strings.Contains(err.Error(), tt.wantErrContains) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if route.Spec.Host != tt.wantHost {
+				t.Errorf("expected the route host %q, got %q", tt.wantHost, route.Spec.Host)
+			}
+			if secretName != tt.wantSecret {
+				t.Errorf("expected the secret %q, got %q", tt.wantSecret, secretName)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package deployment
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+const (
+	// caBundleSuffix is appended to the name of the synced CA configmap to
+	// get the name of the CA bundle configmap that gets mounted in its place
+	caBundleSuffix = "-bundle"
+	// caBundleLabel marks the CA bundle configmaps managed by the operator
+	caBundleLabel = "auth.openshift.io/ca-rotation-bundle"
+
+	caBundleCurrentKey   = "current-ca.crt"
+	caBundlePreviousKey  = "previous-ca.crt"
+	caBundleRotatedAtKey = "rotated-at"
+)
+
+// nextCABundleData returns the data of the CA bundle configmap for the CA that
+// is currently synced given the data of the existing bundle. Once the CA
+// changes, the mounted bundle is the union of the new and the previous CA until
+// the grace period passes, the remaining grace period is returned along.
+func nextCABundleData(existing map[string]string, currentCA string, now time.Time, grace time.Duration) (map[string]string, time.Duration) {
+	single := map[string]string{
+		corev1.ServiceAccountRootCAKey: currentCA,
+		caBundleCurrentKey:             currentCA,
+	}
+
+	previousCA := existing[caBundleCurrentKey]
+	rotatedAt := now
+	if len(previousCA) == 0 {
+		return single, 0
+	}
+	if previousCA == currentCA {
+		// the rotation, if any, is in progress
+		previousCA = existing[caBundlePreviousKey]
+		if len(previousCA) == 0 {
+			return single, 0
+		}
+		var err error
+		if rotatedAt, err = time.Parse(time.RFC3339, existing[caBundleRotatedAtKey]); err != nil {
+			return single, 0
+		}
+	}
+
+	remaining := rotatedAt.Add(grace).Sub(now)
+	if remaining <= 0 {
+		return single, 0
+	}
+
+	return map[string]string{
+		corev1.ServiceAccountRootCAKey: unionCABundles(currentCA, previousCA),
+		caBundleCurrentKey:             currentCA,
+		caBundlePreviousKey:            previousCA,
+		caBundleRotatedAtKey:           rotatedAt.UTC().Format(time.RFC3339),
+	}, remaining
+}
+
+// unionCABundles returns the certificates of all the bundles in their order,
+// without the duplicates
+func unionCABundles(bundles ...string) string {
+	var union []byte
+	seen := map[string]bool{}
+	for _, bundle := range bundles {
+		rest := []byte(bundle)
+		for {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" || seen[string(block.Bytes)] {
+				continue
+			}
+			seen[string(block.Bytes)] = true
+			union = append(union, pem.EncodeToMemory(block)...)
+		}
+	}
+	return string(union)
+}
+
+// syncCABundles maintains a CA bundle configmap for every synced identity
+// provider CA when there is a grace period and removes the bundles that are no
+// longer needed. It returns the names of the bundles that should be mounted
+// instead of the synced CAs and the time to the end of the nearest grace period.
+func (c *oauthServerDeploymentSyncer) syncCABundles(ctx context.Context, recorder events.Recorder, idpSyncData *datasync.ConfigSyncData, grace time.Duration) (map[string]string, time.Duration, error) {
+	bundles := map[string]string{}
+	var requeue time.Duration
+
+	if grace > 0 {
+		for _, resource := range idpSyncData.Resources() {
+			if resource.Type != datasync.ConfigMapType || resource.Key != corev1.ServiceAccountRootCAKey {
+				continue
+			}
+
+			synced, err := c.configMapLister.ConfigMaps(targetNamespace).Get(resource.Dest)
+			if errors.IsNotFound(err) {
+				// mount the synced CA directly once it appears
+				continue
+			} else if err != nil {
+				return nil, 0, err
+			}
+
+			bundleName := resource.Dest + caBundleSuffix
+			var existingData map[string]string
+			if existing, err := c.configMapLister.ConfigMaps(targetNamespace).Get(bundleName); err == nil {
+				existingData = existing.Data
+			} else if !errors.IsNotFound(err) {
+				return nil, 0, err
+			}
+
+			data, remaining := nextCABundleData(existingData, synced.Data[resource.Key], c.clock.Now(), grace)
+			if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMaps, recorder, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      bundleName,
+					Namespace: targetNamespace,
+					Labels:    map[string]string{caBundleLabel: "true"},
+				},
+				Data: data,
+			}); err != nil {
+				return nil, 0, fmt.Errorf("unable to apply the CA bundle %s: %w", bundleName, err)
+			}
+
+			bundles[resource.Dest] = bundleName
+			if remaining > 0 && (requeue == 0 || remaining < requeue) {
+				requeue = remaining
+			}
+		}
+	}
+
+	existingBundles, err := c.configMapLister.ConfigMaps(targetNamespace).List(labels.SelectorFromSet(labels.Set{caBundleLabel: "true"}))
+	if err != nil {
+		return nil, 0, err
+	}
+	required := map[string]bool{}
+	for _, bundleName := range bundles {
+		required[bundleName] = true
+	}
+	for _, bundle := range existingBundles {
+		if required[bundle.Name] {
+			continue
+		}
+		if err := c.configMaps.ConfigMaps(targetNamespace).Delete(ctx, bundle.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return nil, 0, fmt.Errorf("unable to remove the CA bundle %s: %w", bundle.Name, err)
+		}
+	}
+
+	return bundles, requeue, nil
+}
+
+// useCABundles makes the volumes of the synced CAs mount the given bundles
+func useCABundles(templateSpec *corev1.PodSpec, bundles map[string]string) {
+	for i := range templateSpec.Volumes {
+		configMap := templateSpec.Volumes[i].ConfigMap
+		if configMap == nil {
+			continue
+		}
+		if bundleName, ok := bundles[configMap.Name]; ok {
+			configMap.Name = bundleName
+		}
+	}
+}
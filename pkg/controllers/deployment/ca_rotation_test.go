@@ -0,0 +1,213 @@
+package deployment
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+func testCAPEM(t *testing.T, name string) string {
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, _, err := ca.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(certPEM)
+}
+
+func TestNextCABundleData(t *testing.T) {
+	oldCA, newCA := testCAPEM(t, "old-ca"), testCAPEM(t, "new-ca")
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	grace := time.Hour
+
+	rotating := map[string]string{
+		"ca.crt":          oldCA + newCA,
+		"current-ca.crt":  newCA,
+		"previous-ca.crt": oldCA,
+		"rotated-at":      now.Add(-10 * time.Minute).Format(time.RFC3339),
+	}
+
+	tests := []struct {
+		name          string
+		existing      map[string]string
+		currentCA     string
+		wantData      map[string]string
+		wantRemaining time.Duration
+	}{
+		{
+			name:      "no bundle yet",
+			currentCA: oldCA,
+			wantData:  map[string]string{"ca.crt": oldCA, "current-ca.crt": oldCA},
+		},
+		{
+			name:      "unchanged CA",
+			existing:  map[string]string{"ca.crt": oldCA, "current-ca.crt": oldCA},
+			currentCA: oldCA,
+			wantData:  map[string]string{"ca.crt": oldCA, "current-ca.crt": oldCA},
+		},
+		{
+			name:      "CA rotated",
+			existing:  map[string]string{"ca.crt": oldCA, "current-ca.crt": oldCA},
+			currentCA: newCA,
+			wantData: map[string]string{
+				"ca.crt":          newCA + oldCA,
+				"current-ca.crt":  newCA,
+				"previous-ca.crt": oldCA,
+				"rotated-at":      now.Format(time.RFC3339),
+			},
+			wantRemaining: grace,
+		},
+		{
+			name:      "within the grace period",
+			existing:  rotating,
+			currentCA: newCA,
+			wantData: map[string]string{
+				"ca.crt":          newCA + oldCA,
+				"current-ca.crt":  newCA,
+				"previous-ca.crt": oldCA,
+				"rotated-at":      rotating["rotated-at"],
+			},
+			wantRemaining: 50 * time.Minute,
+		},
+		{
+			name: "grace period passed",
+			existing: map[string]string{
+				"ca.crt":          newCA + oldCA,
+				"current-ca.crt":  newCA,
+				"previous-ca.crt": oldCA,
+				"rotated-at":      now.Add(-2 * time.Hour).Format(time.RFC3339),
+			},
+			currentCA: newCA,
+			wantData:  map[string]string{"ca.crt": newCA, "current-ca.crt": newCA},
+		},
+		{
+			name:      "CA rotated back within the grace period",
+			existing:  rotating,
+			currentCA: oldCA,
+			wantData: map[string]string{
+				"ca.crt":          oldCA + newCA,
+				"current-ca.crt":  oldCA,
+				"previous-ca.crt": newCA,
+				"rotated-at":      now.Format(time.RFC3339),
+			},
+			wantRemaining: grace,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, remaining := nextCABundleData(tt.existing, tt.currentCA, now, grace)
+			if !equality.Semantic.DeepEqual(data, tt.wantData) {
+				t.Errorf("expected the bundle data\n%v\ngot\n%v", tt.wantData, data)
+			}
+			if remaining != tt.wantRemaining {
+				t.Errorf("expected the remaining grace period %v, got %v", tt.wantRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestSyncCABundles(t *testing.T) {
+	oldCA, newCA := testCAPEM(t, "old-ca"), testCAPEM(t, "new-ca")
+	rotatedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		overrides    string
+		now          time.Time
+		wantBundle   string
+		wantNoBundle bool
+	}{
+		{
+			name:         "no grace period",
+			now:          rotatedAt.Add(10 * time.Minute),
+			wantNoBundle: true,
+		},
+		{
+			name:       "within the grace period",
+			overrides:  `{"oauthServer":{"caRotationGracePeriod":"1h"}}`,
+			now:        rotatedAt.Add(10 * time.Minute),
+			wantBundle: newCA + oldCA,
+		},
+		{
+			name:       "grace period passed",
+			overrides:  `{"oauthServer":{"caRotationGracePeriod":"1h"}}`,
+			now:        rotatedAt.Add(2 * time.Hour),
+			wantBundle: newCA,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorConfig := testOperatorConfig(tt.overrides)
+			operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(`{"oauthServer":{"volumesToMount":{"identityProviders":` +
+				`"{\"v4-0-config-user-idp-0-ca\":{\"name\":\"idp-ca\",\"mountPath\":\"/var/config/user/idp/0/configMap/v4-0-config-user-idp-0-ca\",\"key\":\"ca.crt\",\"type\":\"configMap\"}}"}}}`)}
+
+			syncedCA := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "v4-0-config-user-idp-0-ca", Namespace: "openshift-authentication"},
+				Data:       map[string]string{"ca.crt": newCA},
+			}
+			existingBundle := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "v4-0-config-user-idp-0-ca-bundle",
+					Namespace: "openshift-authentication",
+					Labels:    map[string]string{caBundleLabel: "true"},
+				},
+				Data: map[string]string{
+					"ca.crt":          newCA + oldCA,
+					"current-ca.crt":  newCA,
+					"previous-ca.crt": oldCA,
+					"rotated-at":      rotatedAt.Format(time.RFC3339),
+				},
+			}
+			syncer, kubeClient := newTestSyncer(operatorConfig, syncedCA, existingBundle)
+			syncer.clock = clocktesting.NewFakePassiveClock(tt.now)
+
+			if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			bundle, err := kubeClient.CoreV1().ConfigMaps("openshift-authentication").Get(context.Background(), existingBundle.Name, metav1.GetOptions{})
+			if tt.wantNoBundle {
+				if err == nil {
+					t.Errorf("expected the CA bundle to be removed")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if got := bundle.Data["ca.crt"]; got != tt.wantBundle {
+				t.Errorf("expected the bundle with %d certificates, got %d", strings.Count(tt.wantBundle, "BEGIN"), strings.Count(got, "BEGIN"))
+			}
+
+			deployment, err := kubeClient.AppsV1().Deployments("openshift-authentication").Get(context.Background(), "oauth-openshift", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			wantSource := "v4-0-config-user-idp-0-ca-bundle"
+			if tt.wantNoBundle {
+				wantSource = "v4-0-config-user-idp-0-ca"
+			}
+			found := false
+			for _, volume := range deployment.Spec.Template.Spec.Volumes {
+				if volume.Name == "v4-0-config-user-idp-0-ca" {
+					found = true
+					if volume.ConfigMap == nil || volume.ConfigMap.Name != wantSource {
+						t.Errorf("expected the CA volume to mount %s, got %#v", wantSource, volume.VolumeSource)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("expected the CA volume to be mounted")
+			}
+		})
+	}
+}
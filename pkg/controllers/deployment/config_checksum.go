@@ -0,0 +1,191 @@
+package deployment
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+)
+
+const (
+	configChecksumContainerName = "config-checksum"
+
+	// pendingRolloutConfigMapName holds the rvs-hash of the deployment the
+	// operator computed last. While the rollout of that deployment is held
+	// back, the pods of the current one get started even though the synced
+	// config moved on already.
+	pendingRolloutConfigMapName = "oauth-openshift-pending-rollout"
+	pendingRolloutKey           = "rvs-hash"
+)
+
+// configChecksumScript recomputes the checksum of the files in the directories
+// it gets as arguments the same way configChecksum does, the files of the
+// atomic writer of the kubelet (..data and the timestamped directories) are
+// skipped as the keys are symlinks to them
+const configChecksumScript = `actual=$(find -L "$@" -type f ! -path '*/..*' -print0 | LC_ALL=C sort -z | xargs -0 -r sha256sum | sha256sum | cut -d' ' -f1)
+if [ "${actual}" != "${EXPECTED_CONFIG_CHECKSUM}" ] && [ -n "${PENDING_RVS_HASH}" ] && [ "${PENDING_RVS_HASH}" != "${EXPECTED_RVS_HASH}" ]; then
+  echo "warning: the mounted config does not match the config of rvs-hash ${EXPECTED_RVS_HASH}, starting anyway as the rollout of rvs-hash ${PENDING_RVS_HASH} is pending" >&2
+  exit 0
+fi
+if [ "${actual}" != "${EXPECTED_CONFIG_CHECKSUM}" ]; then
+  echo "the mounted config does not match the config of rvs-hash ${EXPECTED_RVS_HASH}: expected checksum ${EXPECTED_CONFIG_CHECKSUM}, got ${actual}" >&2
+  exit 1
+fi
+echo "the mounted config matches the config of rvs-hash ${EXPECTED_RVS_HASH}"
+`
+
+// configChecksum returns the checksum of the files given by their paths, the
+// same as `sha256sum` of the sorted files piped to another `sha256sum`
+func configChecksum(files map[string][]byte) string {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	checksum := sha256.New()
+	for _, filePath := range paths {
+		fmt.Fprintf(checksum, "%x  %s\n", sha256.Sum256(files[filePath]), filePath)
+	}
+	return fmt.Sprintf("%x", checksum.Sum(nil))
+}
+
+// addConfigChecksumCheck adds an init container to the pod template that checks
+// the oauth-server container mounts the current content of the v4-0-config-
+// resources the rvs-hash was computed from. The reload-only resources are not
+// checked as their changes do not roll out.
+func (c *oauthServerDeploymentSyncer) addConfigChecksumCheck(templateSpec *corev1.PodSpec, rvsHash string, reloadOnly sets.String) error {
+	container := &templateSpec.Containers[0]
+
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range templateSpec.Volumes {
+		volumes[volume.Name] = volume
+	}
+
+	files := map[string][]byte{}
+	var mounts []corev1.VolumeMount
+	var dirs []string
+	for _, mount := range container.VolumeMounts {
+		volume, ok := volumes[mount.Name]
+		if !ok || len(mount.SubPath) > 0 {
+			continue
+		}
+
+		var data map[string][]byte
+		var items []corev1.KeyToPath
+		switch {
+		case volume.ConfigMap != nil && strings.HasPrefix(volume.ConfigMap.Name, "v4-0-config-"):
+			cm, err := c.configMapLister.ConfigMaps(targetNamespace).Get(volume.ConfigMap.Name)
+			if errors.IsNotFound(err) {
+				// mounted as an empty directory
+			} else if err != nil {
+				return err
+			} else if reloadOnly.Has(cm.Name) {
+				continue
+			} else {
+				data = map[string][]byte{}
+				for key, value := range cm.Data {
+					data[key] = []byte(value)
+				}
+				for key, value := range cm.BinaryData {
+					data[key] = value
+				}
+			}
+			items = volume.ConfigMap.Items
+		case volume.Secret != nil && strings.HasPrefix(volume.Secret.SecretName, "v4-0-config-"):
+			secret, err := c.secretLister.Secrets(targetNamespace).Get(volume.Secret.SecretName)
+			if errors.IsNotFound(err) {
+				// mounted as an empty directory
+			} else if err != nil {
+				return err
+			} else if reloadOnly.Has(secret.Name) {
+				continue
+			} else {
+				data = secret.Data
+			}
+			items = volume.Secret.Items
+		default:
+			continue
+		}
+
+		if len(items) == 0 {
+			for key := range data {
+				items = append(items, corev1.KeyToPath{Key: key, Path: key})
+			}
+		}
+		for _, item := range items {
+			if value, ok := data[item.Key]; ok {
+				files[path.Join(mount.MountPath, item.Path)] = value
+			}
+		}
+
+		mounts = append(mounts, corev1.VolumeMount{Name: mount.Name, MountPath: mount.MountPath, ReadOnly: true})
+		dirs = append(dirs, mount.MountPath)
+	}
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	templateSpec.InitContainers = append(templateSpec.InitContainers, corev1.Container{
+		Name:    configChecksumContainerName,
+		Image:   container.Image,
+		Command: append([]string{"/bin/bash", "-ec", configChecksumScript, configChecksumContainerName}, dirs...),
+		Env: []corev1.EnvVar{
+			{Name: "EXPECTED_RVS_HASH", Value: rvsHash},
+			{Name: "EXPECTED_CONFIG_CHECKSUM", Value: configChecksum(files)},
+			{Name: "PENDING_RVS_HASH", ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: pendingRolloutConfigMapName},
+					Key:                  pendingRolloutKey,
+					Optional:             pointer.Bool(true),
+				},
+			}},
+		},
+		VolumeMounts: mounts,
+		SecurityContext: &corev1.SecurityContext{
+			// the files of the secrets might only be readable by root
+			RunAsUser:              pointer.Int64(0),
+			ReadOnlyRootFilesystem: pointer.Bool(true),
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	})
+
+	return nil
+}
+
+// syncPendingRollout records the rvs-hash of the expected deployment for the
+// config checksum check, the configmap is removed once the check is disabled
+func (c *oauthServerDeploymentSyncer) syncPendingRollout(ctx context.Context, recorder events.Recorder, rvsHash string, enabled bool) error {
+	if !enabled {
+		if _, err := c.configMapLister.ConfigMaps(targetNamespace).Get(pendingRolloutConfigMapName); errors.IsNotFound(err) {
+			return nil
+		}
+		err := c.configMaps.ConfigMaps(targetNamespace).Delete(ctx, pendingRolloutConfigMapName, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	_, _, err := resourceapply.ApplyConfigMap(ctx, c.configMaps, recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pendingRolloutConfigMapName,
+			Namespace: targetNamespace,
+			Labels:    map[string]string{"app": "oauth-openshift"},
+		},
+		Data: map[string]string{pendingRolloutKey: rvsHash},
+	})
+	return err
+}
@@ -0,0 +1,169 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncConfigChecksumCheck(t *testing.T) {
+	cliConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "v4-0-config-system-cliconfig", Namespace: "openshift-authentication", ResourceVersion: "1"},
+		Data:       map[string]string{"v4-0-config-system-cliconfig": "{}"},
+	}
+	session := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "v4-0-config-system-session", Namespace: "openshift-authentication", ResourceVersion: "1"},
+		Data:       map[string][]byte{"v4-0-config-system-session": []byte("secret")},
+	}
+
+	syncer, _ := newTestSyncer(testOperatorConfig(""), cliConfig, session)
+	deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(deployment.Spec.Template.Spec.InitContainers) > 0 {
+		t.Fatalf("expected no init containers by default, got %#v", deployment.Spec.Template.Spec.InitContainers)
+	}
+
+	syncer, _ = newTestSyncer(testOperatorConfig(`{"oauthServer":{"configChecksumCheck":true,"initContainerResources":{"requests":{"cpu":"5m"}}}}`), cliConfig, session)
+	deployment, _, errs = syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	templateSpec := deployment.Spec.Template.Spec
+	if len(templateSpec.InitContainers) != 1 || templateSpec.InitContainers[0].Name != configChecksumContainerName {
+		t.Fatalf("expected the %s init container, got %#v", configChecksumContainerName, templateSpec.InitContainers)
+	}
+	initContainer := templateSpec.InitContainers[0]
+
+	if initContainer.Image != templateSpec.Containers[0].Image {
+		t.Errorf("expected the init container to run the oauth-server image %q, got %q", templateSpec.Containers[0].Image, initContainer.Image)
+	}
+	if initContainer.Resources.Requests.Cpu().String() != "5m" {
+		t.Errorf("expected the init container resources to apply, got %#v", initContainer.Resources)
+	}
+
+	wantEnv := []corev1.EnvVar{
+		{Name: "EXPECTED_RVS_HASH", Value: deployment.Spec.Template.Annotations[deploymentVersionHashKey]},
+		{Name: "EXPECTED_CONFIG_CHECKSUM", Value: configChecksum(map[string][]byte{
+			"/var/config/system/configmaps/v4-0-config-system-cliconfig/v4-0-config-system-cliconfig": []byte("{}"),
+			"/var/config/system/secrets/v4-0-config-system-session/v4-0-config-system-session":        []byte("secret"),
+		})},
+		{Name: "PENDING_RVS_HASH", ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: pendingRolloutConfigMapName},
+				Key:                  pendingRolloutKey,
+				Optional:             pointer.Bool(true),
+			},
+		}},
+	}
+	if !equality.Semantic.DeepEqual(initContainer.Env, wantEnv) {
+		t.Errorf("expected the env\n%#v\ngot\n%#v", wantEnv, initContainer.Env)
+	}
+
+	// every v4-0-config- volume of oauth-server is checked, the others are not mounted
+	oauthServerMounts := map[string]corev1.VolumeMount{}
+	for _, mount := range templateSpec.Containers[0].VolumeMounts {
+		oauthServerMounts[mount.Name] = mount
+	}
+	checked := map[string]bool{}
+	for _, mount := range initContainer.VolumeMounts {
+		checked[mount.Name] = true
+		if oauthServerMount, ok := oauthServerMounts[mount.Name]; !ok || oauthServerMount.MountPath != mount.MountPath {
+			t.Errorf("expected the init container to share the mount %s of oauth-server, got %#v", mount.Name, mount)
+		}
+		if !mount.ReadOnly {
+			t.Errorf("expected the mount %s to be read-only", mount.Name)
+		}
+	}
+	for _, name := range []string{"v4-0-config-system-cliconfig", "v4-0-config-system-session", "v4-0-config-user-template-login"} {
+		if !checked[name] {
+			t.Errorf("expected the init container to check %s", name)
+		}
+	}
+	for _, name := range []string{"audit-policies", "audit-dir"} {
+		if checked[name] {
+			t.Errorf("expected the init container not to mount %s", name)
+		}
+	}
+}
+
+func TestSyncConfigChecksumCheckDuringRolloutHold(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+	overrides := func(gogc int) string {
+		return fmt.Sprintf(`{"oauthServer":{"configChecksumCheck":true,"rolloutCooldown":"0s","minRolloutInterval":"1h","gogc":"%d"}}`, gogc)
+	}
+
+	syncer, kubeClient := newTestSyncer(testOperatorConfig(overrides(100)), existingDeployment)
+	syncer.clock = clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	authentications := syncer.auth.(*fakeAuthenticationsGetter).authentications
+
+	pendingRollout := func() string {
+		t.Helper()
+		cm, err := kubeClient.CoreV1().ConfigMaps("openshift-authentication").Get(context.Background(), pendingRolloutConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected the pending rollout to be recorded: %v", err)
+		}
+		return cm.Data[pendingRolloutKey]
+	}
+	checkedRVSHash := func(deployment *appsv1.Deployment) string {
+		if env := findEnvVar(deployment.Spec.Template.Spec.InitContainers[0].Env, "EXPECTED_RVS_HASH"); env != nil {
+			return env.Value
+		}
+		return ""
+	}
+
+	rolledOut, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if pending := pendingRollout(); pending != checkedRVSHash(rolledOut) {
+		t.Errorf("expected the rolled out rvs-hash %q to be recorded as pending so that the check fails on mismatch, got %q", checkedRVSHash(rolledOut), pending)
+	}
+
+	// the rollout of the change is held back by the minimum interval, the
+	// pods of the current deployment only warn about the mismatch
+	authentications.authentication = testOperatorConfig(overrides(200))
+	held, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if checkedRVSHash(held) != checkedRVSHash(rolledOut) {
+		t.Fatalf("expected the rollout to be held back")
+	}
+	if pending := pendingRollout(); pending == checkedRVSHash(held) || len(pending) == 0 {
+		t.Errorf("expected the held back rvs-hash to be recorded as pending, got %q", pending)
+	}
+
+	// the informer observed the recorded pending rollout
+	pendingRolloutConfigMap, err := kubeClient.CoreV1().ConfigMaps("openshift-authentication").Get(context.Background(), pendingRolloutConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := configMapIndexer.Add(pendingRolloutConfigMap); err != nil {
+		t.Fatal(err)
+	}
+	syncer.configMapLister = corev1listers.NewConfigMapLister(configMapIndexer)
+
+	authentications.authentication = testOperatorConfig(`{"oauthServer":{"rolloutCooldown":"0s","minRolloutInterval":"1h","gogc":"200"}}`)
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-authentication").Get(context.Background(), pendingRolloutConfigMapName, metav1.GetOptions{}); err == nil {
+		t.Errorf("expected the pending rollout to be removed once the check is disabled")
+	}
+}
@@ -0,0 +1,216 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	configValidationPodName = "oauth-openshift-config-validation"
+	// configValidationHashAnnotation carries the rvs-hash of the deployment
+	// whose config the validation pod checks
+	configValidationHashAnnotation = "operator.openshift.io/validated-rvs-hash"
+	// configValidationTimeout is how long the validation pod may take to
+	// finish before the validation is reported as failed, the rollout stays
+	// held until the pod finishes
+	configValidationTimeout = 5 * time.Minute
+)
+
+// configValidator checks the oauth-server config of a deployment before it
+// gets rolled out
+type configValidator interface {
+	// Validate returns true once the validation of the config of the given
+	// deployment finished. A non-nil error means the config is invalid or
+	// that it could not be validated in time.
+	Validate(ctx context.Context, deployment *appsv1.Deployment) (bool, error)
+}
+
+var _ configValidator = &podConfigValidator{}
+
+// podConfigValidator runs a short-lived pod with the pod template of the
+// deployment that only validates the mounted config and exits. The pod is
+// deleted once it finished, its result is kept for the rvs-hash it validated
+// so that the pod does not get recreated on every sync; after an operator
+// restart the config gets validated once more.
+type podConfigValidator struct {
+	pods       corev1client.PodsGetter
+	podsLister corev1listers.PodLister
+	clock      clock.PassiveClock
+
+	validatedHash string
+	validationErr error
+}
+
+func (v *podConfigValidator) Validate(ctx context.Context, deployment *appsv1.Deployment) (bool, error) {
+	expectedHash := deployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	if len(v.validatedHash) > 0 && v.validatedHash == expectedHash {
+		return true, v.validationErr
+	}
+
+	pod, err := v.podsLister.Pods(deployment.Namespace).Get(configValidationPodName)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, fmt.Errorf("unable to get the config validation pod: %w", err)
+	}
+
+	if pod != nil && pod.Annotations[configValidationHashAnnotation] != expectedHash {
+		klog.V(4).Infof("removing stale config validation pod for rvs-hash %q", pod.Annotations[configValidationHashAnnotation])
+		if err := v.deletePod(ctx, pod); err != nil {
+			return false, fmt.Errorf("unable to delete the stale config validation pod: %w", err)
+		}
+		// wait for the informer to observe the deletion before creating a new pod
+		return false, nil
+	}
+
+	if pod == nil {
+		if _, err := v.pods.Pods(deployment.Namespace).Create(ctx, configValidationPod(deployment, expectedHash), metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return false, fmt.Errorf("unable to create the config validation pod: %w", err)
+		}
+		return false, nil
+	}
+
+	var validationErr error
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+	case corev1.PodFailed:
+		validationErr = fmt.Errorf("oauth-server config validation failed: %s", podTerminationMessage(pod))
+	default:
+		if waiting := v.clock.Since(pod.CreationTimestamp.Time); waiting > configValidationTimeout {
+			return false, fmt.Errorf("oauth-server config validation did not finish in %v: %s", configValidationTimeout, podWaitingReasons(pod))
+		}
+		return false, nil
+	}
+
+	if err := v.deletePod(ctx, pod); err != nil {
+		return false, fmt.Errorf("unable to delete the finished config validation pod: %w", err)
+	}
+	v.validatedHash, v.validationErr = expectedHash, validationErr
+
+	return true, validationErr
+}
+
+func (v *podConfigValidator) deletePod(ctx context.Context, pod *corev1.Pod) error {
+	// only delete the pod that was observed, a newer one is left for the next sync
+	err := v.pods.Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &pod.UID}})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// configValidationPod creates a pod that runs oauth-server with the config of
+// the deployment in validation-only mode. The pod only reads the config, it
+// runs as the user of the oauth-server pods but without their privileges,
+// host mounts and init containers.
+func configValidationPod(deployment *appsv1.Deployment, rvsHash string) *corev1.Pod {
+	templateSpec := deployment.Spec.Template.Spec.DeepCopy()
+	podSpec := corev1.PodSpec{
+		RestartPolicy:                 corev1.RestartPolicyNever,
+		AutomountServiceAccountToken:  pointer.Bool(false),
+		ServiceAccountName:            templateSpec.ServiceAccountName,
+		NodeSelector:                  templateSpec.NodeSelector,
+		Tolerations:                   templateSpec.Tolerations,
+		ImagePullSecrets:              templateSpec.ImagePullSecrets,
+		SecurityContext:               templateSpec.SecurityContext,
+		TerminationGracePeriodSeconds: pointer.Int64(0),
+	}
+
+	// the audit logs go to the host, validating the config writes none
+	for _, volume := range templateSpec.Volumes {
+		if volume.HostPath != nil {
+			volume.VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		}
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+
+	template := templateSpec.Containers[0]
+	// the config gets read as the user of the oauth-server pods so that the
+	// permissions of the mounted files apply the same way
+	runAsUser := pointer.Int64(0)
+	if template.SecurityContext != nil && template.SecurityContext.RunAsUser != nil {
+		runAsUser = template.SecurityContext.RunAsUser
+	}
+	args := template.Args[0]
+	// the trust bundle copy that precedes oauth-server needs root and is not
+	// needed to validate the config
+	if i := strings.Index(args, "exec oauth-server osinserver"); i >= 0 {
+		args = args[i:]
+	}
+	container := corev1.Container{
+		Name:    template.Name,
+		Image:   template.Image,
+		Command: template.Command,
+		Args: []string{
+			strings.Replace(args, "exec oauth-server osinserver", "exec oauth-server osinserver --validate-config", 1),
+		},
+		Env:                      template.Env,
+		Resources:                template.Resources,
+		VolumeMounts:             template.VolumeMounts,
+		ImagePullPolicy:          template.ImagePullPolicy,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:               pointer.Bool(false),
+			AllowPrivilegeEscalation: pointer.Bool(false),
+			RunAsUser:                runAsUser,
+			ReadOnlyRootFilesystem:   pointer.Bool(true),
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+	for i := range container.VolumeMounts {
+		container.VolumeMounts[i].ReadOnly = true
+	}
+	podSpec.Containers = []corev1.Container{container}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configValidationPodName,
+			Namespace: deployment.Namespace,
+			Labels: map[string]string{
+				"app": configValidationPodName,
+			},
+			Annotations: map[string]string{
+				configValidationHashAnnotation: rvsHash,
+			},
+		},
+		Spec: podSpec,
+	}
+}
+
+func podTerminationMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && len(terminated.Message) > 0 {
+			return strings.TrimSpace(terminated.Message)
+		}
+	}
+	return fmt.Sprintf("pod %s/%s failed", pod.Namespace, pod.Name)
+}
+
+// podWaitingReasons describes why the pod did not finish yet
+func podWaitingReasons(pod *corev1.Pod) string {
+	var reasons []string
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			reasons = append(reasons, strings.TrimSpace(fmt.Sprintf("%s %s", condition.Reason, condition.Message)))
+		}
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if waiting := status.State.Waiting; waiting != nil {
+			reasons = append(reasons, strings.TrimSpace(fmt.Sprintf("container %s is waiting: %s %s", status.Name, waiting.Reason, waiting.Message)))
+		}
+	}
+	if len(reasons) == 0 {
+		return fmt.Sprintf("pod %s/%s is %s", pod.Namespace, pod.Name, pod.Status.Phase)
+	}
+	return strings.Join(reasons, ", ")
+}
@@ -0,0 +1,217 @@
+package deployment
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var testValidationTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testValidationPod(rvsHash string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              configValidationPodName,
+			Namespace:         "openshift-authentication",
+			Annotations:       map[string]string{configValidationHashAnnotation: rvsHash},
+			CreationTimestamp: metav1.NewTime(testValidationTime),
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "bad identity provider\n"}},
+			}},
+		},
+	}
+}
+
+func testStuckValidationPod(status corev1.PodStatus) *corev1.Pod {
+	pod := testValidationPod("new", corev1.PodPending)
+	pod.CreationTimestamp = metav1.NewTime(testValidationTime.Add(-configValidationTimeout - time.Second))
+	pod.Status = status
+	return pod
+}
+
+func TestPodConfigValidator(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{deploymentVersionHashKey: "new"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "oauth-openshift", Args: []string{"exec oauth-server osinserver --config=config.yaml"}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		pod             *corev1.Pod
+		wantValidated   bool
+		wantErrContains string
+		wantActions     []string
+		// wantRecorded means the result is kept and the next validation
+		// returns it without touching the pods
+		wantRecorded bool
+	}{
+		{
+			name:        "no validation pod",
+			wantActions: []string{"create"},
+		},
+		{
+			name:        "stale validation pod",
+			pod:         testValidationPod("old", corev1.PodSucceeded),
+			wantActions: []string{"delete"},
+		},
+		{
+			name: "validation in progress",
+			pod:  testValidationPod("new", corev1.PodRunning),
+		},
+		{
+			name: "validation pod pending within the timeout",
+			pod:  testValidationPod("new", corev1.PodPending),
+		},
+		{
+			name: "validation pod unable to pull the image",
+			pod: testStuckValidationPod(corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "oauth-openshift",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+				}},
+			}),
+			wantErrContains: "oauth-server config validation did not finish in 5m0s: container oauth-openshift is waiting: ImagePullBackOff Back-off pulling image",
+		},
+		{
+			name: "validation pod unschedulable",
+			pod: testStuckValidationPod(corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available",
+				}},
+			}),
+			wantErrContains: "oauth-server config validation did not finish in 5m0s: Unschedulable 0/3 nodes are available",
+		},
+		{
+			name:          "validation succeeded",
+			pod:           testValidationPod("new", corev1.PodSucceeded),
+			wantValidated: true,
+			wantActions:   []string{"delete"},
+			wantRecorded:  true,
+		},
+		{
+			name:            "validation failed",
+			pod:             testValidationPod("new", corev1.PodFailed),
+			wantValidated:   true,
+			wantErrContains: "oauth-server config validation failed: bad identity provider",
+			wantActions:     []string{"delete"},
+			wantRecorded:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tt.pod != nil {
+				objects = append(objects, tt.pod)
+				if err := podIndexer.Add(tt.pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(objects...)
+			validator := &podConfigValidator{
+				pods:       kubeClient.CoreV1(),
+				podsLister: corev1listers.NewPodLister(podIndexer),
+				clock:      clocktesting.NewFakePassiveClock(testValidationTime),
+			}
+
+			checkResult := func(validated bool, err error) {
+				t.Helper()
+				if validated != tt.wantValidated {
+					t.Errorf("expected validated %v, got %v", tt.wantValidated, validated)
+				}
+				if len(tt.wantErrContains) > 0 {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+						t.Errorf("expected an error containing %q, got %v", tt.wantErrContains, err)
+					}
+				} else if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+
+			checkResult(validator.Validate(context.Background(), deployment))
+			var actions []string
+			for _, action := range kubeClient.Actions() {
+				actions = append(actions, action.GetVerb())
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.wantActions, ",") {
+				t.Errorf("expected the pod actions %v, got %v", tt.wantActions, actions)
+			}
+
+			if !tt.wantRecorded {
+				return
+			}
+			// the informer may not have observed the deletion yet
+			kubeClient.ClearActions()
+			checkResult(validator.Validate(context.Background(), deployment))
+			if actions := kubeClient.Actions(); len(actions) > 0 {
+				t.Errorf("expected the recorded result to be returned without any pod actions, got %v", actions)
+			}
+		})
+	}
+}
+
+func TestConfigValidationPodIsUnprivileged(t *testing.T) {
+	deployment, err := getOAuthServerDeployment(testOperatorConfig(""), &configv1.Proxy{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := configValidationPod(deployment, "new")
+
+	if len(pod.Spec.InitContainers) > 0 || len(pod.Spec.Containers) != 1 {
+		t.Fatalf("expected only the oauth-server container, got %d init containers and %d containers", len(pod.Spec.InitContainers), len(pod.Spec.Containers))
+	}
+	container := pod.Spec.Containers[0]
+	if securityContext := container.SecurityContext; securityContext == nil || securityContext.Privileged == nil || *securityContext.Privileged || securityContext.RunAsUser == nil || *securityContext.RunAsUser != 0 {
+		t.Errorf("expected an unprivileged container running as the user of the oauth-server pods, got the security context %v", securityContext)
+	}
+	if args := container.Args[0]; !strings.HasPrefix(args, "exec oauth-server osinserver --validate-config ") {
+		t.Errorf("expected only oauth-server to run in validation-only mode, got %q", args)
+	}
+	for _, mount := range container.VolumeMounts {
+		if !mount.ReadOnly {
+			t.Errorf("expected the volume %q to be mounted read-only", mount.Name)
+		}
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			t.Errorf("expected no host path volumes, got %q from %q", volume.Name, volume.HostPath.Path)
+		}
+	}
+	if len(pod.Spec.Volumes) != len(deployment.Spec.Template.Spec.Volumes) {
+		t.Errorf("expected the %d volumes of the deployment, got %d", len(deployment.Spec.Template.Spec.Volumes), len(pod.Spec.Volumes))
+	}
+	if pod.Spec.AutomountServiceAccountToken == nil || *pod.Spec.AutomountServiceAccountToken {
+		t.Errorf("expected the service account token not to be mounted")
+	}
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected the pod not to be restarted, got %q", pod.Spec.RestartPolicy)
+	}
+}
@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// dataHash returns a hash of the keys and the values of the data, the keys are
+// sorted and every value is prefixed with its length so that moving bytes
+// between the keys changes the hash
+func dataHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s:%d:", key, len(data[key]))
+		hash.Write(data[key])
+	}
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// configMapContentHash returns a hash of the files the configmap projects to
+// the oauth-server pods, its metadata does not affect the hash
+func configMapContentHash(cm *corev1.ConfigMap) string {
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	// the keys of the data and the binaryData do not overlap
+	for key, value := range cm.BinaryData {
+		data[key] = value
+	}
+	return dataHash(data)
+}
+
+// secretContentHash returns a hash of the files the secret projects to the
+// oauth-server pods, its metadata does not affect the hash
+func secretContentHash(secret *corev1.Secret) string {
+	return dataHash(secret.Data)
+}
+
+// proxyContentHash returns a hash of the observed proxy settings, the settings
+// oauth-server gets in its environment
+func proxyContentHash(proxy *configv1.Proxy) (string, error) {
+	status, err := json.Marshal(proxy.Status)
+	if err != nil {
+		return "", err
+	}
+	return dataHash(map[string][]byte{"status": status}), nil
+}
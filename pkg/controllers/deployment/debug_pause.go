@@ -0,0 +1,30 @@
+package deployment
+
+import (
+	"context"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// debugPauseConditionType ends with "Degraded" so that a paused oauth-server
+// does not go unnoticed on the clusteroperator
+const debugPauseConditionType = "OAuthServerDebugPauseDegraded"
+
+// syncDebugPauseCondition reports whether the oauth-server pods are paused for
+// debugging instead of serving logins
+func (c *oauthServerDeploymentSyncer) syncDebugPauseCondition(ctx context.Context, debugPause *debugPauseConfig) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   debugPauseConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if debugPause != nil {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "DebugPauseEnabled"
+		condition.Message = "oauth-server is paused for debugging and serves no logins, remove unsupportedConfigOverrides.oauthServer.debugPause to resume it"
+	}
+
+	_, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
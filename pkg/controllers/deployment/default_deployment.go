@@ -5,13 +5,15 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
 	"github.com/ghodss/yaml"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -26,70 +28,126 @@ import (
 	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
 )
 
+// deploymentVersionHashKey is the annotation with the hash of the content of
+// all the tracked resources that the deployment depends on, its name predates
+// the hashing of the content in place of the resourceVersions
+const deploymentVersionHashKey = "operator.openshift.io/rvs-hash"
+
 func getOAuthServerDeployment(
 	operatorConfig *operatorv1.Authentication,
 	proxyConfig *configv1.Proxy,
 	bootstrapUserExists bool,
-	resourceVersions ...string,
+	hashInputs ...string,
 ) (*appsv1.Deployment, error) {
 	// load deployment
 	deployment := resourceread.ReadDeploymentV1OrDie(assets.MustAsset("oauth-openshift/deployment.yaml"))
 
+	deploymentConfig, err := getDeploymentConfig(operatorConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentConfigHashInput, err := deploymentConfig.hashInput()
+	if err != nil {
+		return nil, err
+	}
+	if len(deploymentConfigHashInput) > 0 {
+		hashInputs = append(hashInputs, deploymentConfigHashInput)
+	}
+
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read the operatorconfig prefix %q: %w",
+			configobservation.OAuthServerConfigPrefix,
+			err,
+		)
+	}
+
+	identityProviderCount, err := getIdentityProviderCount(observedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the identity provider count: %w", err)
+	}
+	hashInputs = append(hashInputs, fmt.Sprintf("identityproviders:%d", identityProviderCount))
+
+	// the content of the synced template secrets is tracked along with the
+	// other v4-0-config- resources, the references roll out a template swap
+	// right away instead of waiting for the synced copy to change
+	templateReferences, err := getTemplateReferences(observedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the template references: %w", err)
+	}
+	if len(templateReferences) > 0 {
+		hashInputs = append(hashInputs, "templates:"+templateReferences)
+	}
+
+	// the edits of the identity providers only change the cliconfig, their
+	// observed config tells them apart from the other changes of the cliconfig
+	// without adding to the rollout hash
+	identityProvidersConfig, err := getIdentityProvidersConfig(observedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the identity providers config: %w", err)
+	}
+
 	// force redeploy when any associated resource changes
 	// we use a hash to prevent this value from growing indefinitely
 	// need to sort first in order to get a stable array
-	sort.Strings(resourceVersions)
-	rvs := strings.Join(resourceVersions, ",")
-	klog.V(4).Infof("tracked resource versions: %s", rvs)
+	sort.Strings(hashInputs)
+	rvs := strings.Join(hashInputs, ",")
+	klog.V(4).Infof("tracked resources: %s", rvs)
 	rvsHash := sha512.Sum512([]byte(rvs))
 	rvsHashStr := base64.RawURLEncoding.EncodeToString(rvsHash[:])
 	if deployment.Annotations == nil {
 		deployment.Annotations = map[string]string{}
 	}
-	deployment.Annotations["operator.openshift.io/rvs-hash"] = rvsHashStr
+	deployment.Annotations[deploymentVersionHashKey] = rvsHashStr
+	deployment.Annotations[rolloutTriggersKey] = rolloutTriggers(append(hashInputs, "identityprovidersconfig:"+identityProvidersConfig))
 
 	if deployment.Spec.Template.Annotations == nil {
 		deployment.Spec.Template.Annotations = map[string]string{}
 	}
-	deployment.Spec.Template.Annotations["operator.openshift.io/rvs-hash"] = rvsHashStr
+	deployment.Spec.Template.Annotations[deploymentVersionHashKey] = rvsHashStr
 
 	// Ensure a rollout when the bootstrap user goes away
 	if bootstrapUserExists {
-		deployment.Spec.Template.Annotations["operator.openshift.io/bootstrap-user-exists"] = "true"
+		deployment.Spec.Template.Annotations[bootstrapUserExistsKey] = "true"
 	}
 
 	templateSpec := &deployment.Spec.Template.Spec
 	container := &templateSpec.Containers[0]
 
+	setPodSpreading(templateSpec, deployment.Spec.Template.Labels)
+
 	// image spec
 	if container.Image == "${IMAGE}" {
-		container.Image = os.Getenv("IMAGE_OAUTH_SERVER")
+		container.Image = deploymentConfig.oauthServerImage()
 	}
 
 	// set proxy env vars
 	container.Env = append(container.Env, proxyConfigToEnvVars(proxyConfig)...)
 
+	// let oauth-server log the rvs-hash of the config it runs with, the pods
+	// of a new hash get the new value when they start
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: "OAUTH_SERVER_RVS_HASH",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", deploymentVersionHashKey)},
+		},
+	})
+
 	// set log level
 	container.Args[0] = strings.Replace(container.Args[0], "${LOG_LEVEL}", fmt.Sprintf("%d", getLogLevel(operatorConfig.Spec.LogLevel)), -1)
 
-	observedConfig, err := common.UnstructuredConfigFrom(
-		operatorConfig.Spec.ObservedConfig.Raw,
-		configobservation.OAuthServerConfigPrefix,
-	)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to read the operatorconfig prefix %q: %w",
-			configobservation.OAuthServerConfigPrefix,
-			err,
-		)
-	}
-
 	idpSyncData, err := getSyncDataFromOperatorConfig(observedConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get IDP sync data: %v", err)
 	}
 
 	// mount more secrets and config maps
+	deploymentConfig.applyVolumeModes(idpSyncData)
 	v, m, err := idpSyncData.ToVolumesAndMounts()
 	if err != nil {
 		return nil, fmt.Errorf("unable to transform observed IDP sync data to volumes and mounts: %v", err)
@@ -107,6 +165,25 @@ func getOAuthServerDeployment(
 		return nil, fmt.Errorf("unable to parse raw server arguments: %w", err)
 	}
 
+	// the proxy CA is still synced and validated so that it is ready once a
+	// proxy gets configured
+	if deploymentConfig.ProxyCA != nil && !proxyConfigured(proxyConfig) {
+		klog.V(4).Infof("no proxy is configured, skipping the proxy CA %q", deploymentConfig.ProxyCA.Name)
+		deploymentConfig.ProxyCA = nil
+	}
+
+	if err := deploymentConfig.apply(templateSpec, args); err != nil {
+		return nil, err
+	}
+
+	if deploymentConfig.IdentityProviderSelection != nil {
+		idpNames, err := getIdentityProviderNames(observedConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the identity providers: %w", err)
+		}
+		deploymentConfig.IdentityProviderSelection.apply(args, idpNames)
+	}
+
 	container.Args[0] = strings.Replace(
 		container.Args[0],
 		"${SERVER_ARGUMENTS}",
@@ -114,9 +191,67 @@ func getOAuthServerDeployment(
 		1,
 	)
 
+	if deploymentConfig.DebugPause != nil {
+		applyDebugPause(container)
+	}
+
 	return deployment, nil
 }
 
+// debugPauseCommandEnv keeps the oauth-server command of the paused container
+// so that it can be run by hand from within the pod
+const debugPauseCommandEnv = "OAUTH_SERVER_DEBUG_COMMAND"
+
+// applyDebugPause makes the container sleep instead of running oauth-server.
+// The probes are dropped as nothing would answer them and the kubelet would
+// keep restarting the container.
+func applyDebugPause(container *corev1.Container) {
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  debugPauseCommandEnv,
+		Value: container.Args[0],
+	})
+	container.Command = []string{"/bin/bash", "-c"}
+	container.Args = []string{"trap 'exit 0' TERM; sleep infinity & wait"}
+	container.ReadinessProbe = nil
+	container.LivenessProbe = nil
+	container.StartupProbe = nil
+}
+
+// podSpreadingTopologyKeys are the failure domains the oauth-server pods get
+// spread across so that a drained node or a lost zone does not take down all
+// of them
+var podSpreadingTopologyKeys = []string{corev1.LabelHostname, corev1.LabelTopologyZone}
+
+// setPodSpreading prefers to schedule the pods of the deployment to distinct
+// nodes and zones. The rules are soft so that the single-node and the
+// single-zone clusters still schedule all the replicas.
+func setPodSpreading(templateSpec *corev1.PodSpec, podLabels map[string]string) {
+	var antiAffinityTerms []corev1.WeightedPodAffinityTerm
+	var spreadConstraints []corev1.TopologySpreadConstraint
+	for _, topologyKey := range podSpreadingTopologyKeys {
+		antiAffinityTerms = append(antiAffinityTerms, corev1.WeightedPodAffinityTerm{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: podLabels},
+				TopologyKey:   topologyKey,
+			},
+		})
+		spreadConstraints = append(spreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: podLabels},
+		})
+	}
+
+	templateSpec.Affinity = &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: antiAffinityTerms,
+		},
+	}
+	templateSpec.TopologySpreadConstraints = spreadConstraints
+}
+
 func getSyncDataFromOperatorConfig(observedConfig []byte) (*datasync.ConfigSyncData, error) {
 	var configDeserialized map[string]interface{}
 	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
@@ -126,6 +261,97 @@ func getSyncDataFromOperatorConfig(observedConfig []byte) (*datasync.ConfigSyncD
 	return observeoauth.GetIDPConfigSyncData(configDeserialized)
 }
 
+func getIdentityProviderCount(observedConfig []byte) (int, error) {
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	return observeoauth.GetIdentityProviderCount(configDeserialized)
+}
+
+// getIdentityProviderNames returns the names of the observed identity
+// providers in the order oauth-server lists them in
+func getIdentityProviderNames(observedConfig []byte) ([]string, error) {
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	summaries, err := observeoauth.GetIdentityProviderSummaries(configDeserialized)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		names = append(names, summary.Name)
+	}
+	return names, nil
+}
+
+// getIdentityProvidersConfig returns the observed config of the identity
+// providers serialized
+func getIdentityProvidersConfig(observedConfig []byte) (string, error) {
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	identityProviders, _, err := unstructured.NestedFieldNoCopy(configDeserialized, "oauthConfig", "identityProviders")
+	if err != nil {
+		return "", err
+	}
+	identityProvidersBytes, err := json.Marshal(identityProviders)
+	if err != nil {
+		return "", err
+	}
+	return string(identityProvidersBytes), nil
+}
+
+// getTemplateReferences returns the observed template references in a stable
+// order, or an empty string when the default templates are used
+func getTemplateReferences(observedConfig []byte) (string, error) {
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	references, err := observeoauth.GetTemplateReferences(configDeserialized)
+	if err != nil {
+		return "", err
+	}
+
+	templates := make([]string, 0, len(references))
+	for template, secretName := range references {
+		templates = append(templates, template+"="+secretName)
+	}
+	sort.Strings(templates)
+	return strings.Join(templates, ";"), nil
+}
+
+// getReloadUnsupportedResources returns the names of the synced resources that
+// oauth-server only reads when it starts, their changes always roll out
+func getReloadUnsupportedResources(operatorConfig *operatorv1.Authentication) (sets.String, error) {
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	clientCAs, err := observeoauth.GetClientCAConfigMaps(configDeserialized)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the client CAs of the identity providers: %w", err)
+	}
+	return sets.NewString(clientCAs...), nil
+}
+
 // TODO: reuse the library-go helper for this
 func getLogLevel(logLevel operatorv1.LogLevel) int {
 	switch logLevel {
@@ -138,10 +364,17 @@ func getLogLevel(logLevel operatorv1.LogLevel) int {
 	case operatorv1.TraceAll:
 		return 100 // this is supposed to be 8 but I prefer "all" to really mean all
 	default:
-		return 0
+		// fall back to the default so that a typo does not silence the server
+		klog.Warningf("unknown log level %q, falling back to %q", logLevel, operatorv1.Normal)
+		return 2
 	}
 }
 
+// proxyConfigured tells whether oauth-server connects through a proxy
+func proxyConfigured(proxy *configv1.Proxy) bool {
+	return len(proxy.Status.HTTPProxy) > 0 || len(proxy.Status.HTTPSProxy) > 0
+}
+
 // TODO: move to library-go:w
 func proxyConfigToEnvVars(proxy *configv1.Proxy) []corev1.EnvVar {
 	var envVars []corev1.EnvVar
@@ -0,0 +1,2164 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common/arguments"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+const (
+	cliConfigDir  = "/var/config/system/configmaps/v4-0-config-system-cliconfig"
+	cliConfigFile = cliConfigDir + "/v4-0-config-system-cliconfig"
+)
+
+// deploymentConfig holds the knobs of the oauth-server deployment that can be
+// tuned in the "oauthServer" section of the operator's unsupportedConfigOverrides.
+// The oauth-server config itself is pruned to the osin schema so these fields
+// never leak into the CLI config. Leaving a field unset keeps the defaults
+// from the deployment asset.
+type deploymentConfig struct {
+	// HTTP2 tunes or disables HTTP/2 on the oauth-server serving endpoint
+	HTTP2 *http2Config `json:"http2,omitempty"`
+
+	// DNSSearchDomains are appended to the search list of the pod's resolver
+	// so that IdPs referenced by their short hostnames can be resolved
+	DNSSearchDomains []string `json:"dnsSearchDomains,omitempty"`
+
+	// GOGC sets the garbage collection target percentage of the oauth-server
+	// process, either a positive integer or "off"
+	GOGC string `json:"gogc,omitempty"`
+
+	// KubeClientCertificate references a secret in the openshift-config
+	// namespace with the tls.crt and tls.key that oauth-server uses to
+	// authenticate to the kube-apiserver instead of its service account token
+	KubeClientCertificate *configv1.SecretNameReference `json:"kubeClientCertificate,omitempty"`
+
+	// KubeAPIClient tunes the rate limits and the connection pool of the
+	// client oauth-server uses to talk to the kube-apiserver
+	KubeAPIClient *kubeAPIClientConfig `json:"kubeAPIClient,omitempty"`
+
+	// ServiceAccountToken mounts a projected service account token with a
+	// custom audience for federated token flows
+	ServiceAccountToken *serviceAccountTokenConfig `json:"serviceAccountToken,omitempty"`
+
+	// SNICertificates are additional serving certificates for the hostnames
+	// oauth-server is reachable at besides its route
+	SNICertificates []sniCertificateConfig `json:"sniCertificates,omitempty"`
+
+	// TLSHandshakeTimeout is the time a client has to complete the TLS
+	// handshake before the connection gets closed, as a duration string.
+	// Keeping it short protects the server from slowloris-like attacks.
+	// It defaults to 10s.
+	TLSHandshakeTimeout string `json:"tlsHandshakeTimeout,omitempty"`
+
+	// MaxRequestBodySize is the largest request body oauth-server accepts, as
+	// a byte quantity. The login and token requests are tiny so the default
+	// only keeps clients from exhausting the server's memory.
+	MaxRequestBodySize string `json:"maxRequestBodySize,omitempty"`
+
+	// RequestLogging adds request IDs and client IPs to the oauth-server logs
+	// so that they can be correlated with the audit logs
+	RequestLogging *requestLoggingConfig `json:"requestLogging,omitempty"`
+
+	// HonorForwardedHeaders makes oauth-server build its redirect URLs from
+	// the X-Forwarded-Proto and X-Forwarded-Host headers set by a
+	// TLS-terminating proxy in front of it. The headers are only honored for
+	// the requests from requestLogging.trustedProxyCIDRs as any client could
+	// set them otherwise.
+	HonorForwardedHeaders bool `json:"honorForwardedHeaders,omitempty"`
+
+	// RequirePKCEForPublicClients makes oauth-server reject authorization
+	// requests of public OAuth clients (the ones without a secret) that do
+	// not use PKCE. It is off by default as it breaks clients that were
+	// written before PKCE was supported.
+	RequirePKCEForPublicClients bool `json:"requirePKCEForPublicClients,omitempty"`
+
+	// SigningAlgorithms restricts the JWT signing algorithms oauth-server
+	// accepts and issues, all the supported ones are allowed when empty
+	SigningAlgorithms []string `json:"signingAlgorithms,omitempty"`
+
+	// AllowedGrantTypes restricts the OAuth grant types oauth-server serves,
+	// all the supported ones are allowed when empty
+	AllowedGrantTypes []string `json:"allowedGrantTypes,omitempty"`
+
+	// AllowedCORSOrigins lists the origins, such as
+	// https://console.example.com, oauth-server allows the cross-origin
+	// requests from on top of the ones allowed cluster-wide. Only the
+	// same-origin requests are allowed when it is empty. It is not named
+	// corsAllowedOrigins as that is the key of the regular expressions of
+	// the oauth-server config, which the overrides are merged into as is.
+	AllowedCORSOrigins []string `json:"allowedCORSOrigins,omitempty"`
+
+	// RedirectURIAllowlist makes oauth-server only redirect to the listed
+	// URIs on top of matching the redirect URIs of the OAuth clients, which
+	// keeps a misconfigured client from being used as an open redirect
+	RedirectURIAllowlist *redirectURIAllowlistConfig `json:"redirectURIAllowlist,omitempty"`
+
+	// StepUpScopes maps the sensitive scopes to the step-up requirements the
+	// users must meet again before they are granted a token with the scope
+	StepUpScopes map[string]stepUpConfig `json:"stepUpScopes,omitempty"`
+
+	// HTPasswdPasswordPolicy makes oauth-server reject the htpasswd logins
+	// with passwords that do not meet the policy, the users with weak
+	// passwords have to get them changed by the admin to log in again
+	HTPasswdPasswordPolicy *passwordPolicyConfig `json:"htpasswdPasswordPolicy,omitempty"`
+
+	// RedirectSchemes restricts the schemes of the URIs oauth-server redirects
+	// to. Only https is allowed when it is set without any schemes, the native
+	// clients with custom schemes have to be opted in to.
+	RedirectSchemes *redirectSchemesConfig `json:"redirectSchemes,omitempty"`
+
+	// LocaleBundle references a configmap in the openshift-config namespace
+	// with the translations of the login pages under the "locales.json" key
+	LocaleBundle *configv1.ConfigMapNameReference `json:"localeBundle,omitempty"`
+
+	// ClientCertificateAuthentication makes oauth-server request the client
+	// certificates during the TLS handshake and verify the presented ones
+	ClientCertificateAuthentication *clientCertificateAuthenticationConfig `json:"clientCertificateAuthentication,omitempty"`
+
+	// CertificateBoundTokens binds the access tokens oauth-server issues to
+	// the client certificate they were requested with so that a stolen token
+	// cannot be used without the certificate's key. It requires the client
+	// certificate authentication to be configured.
+	CertificateBoundTokens bool `json:"certificateBoundTokens,omitempty"`
+
+	// ProxyCA references a configmap in the openshift-config namespace with
+	// the "ca.crt" bundle oauth-server trusts when it connects to the HTTPS
+	// proxy of the cluster, it is only used when a proxy is configured
+	ProxyCA *configv1.ConfigMapNameReference `json:"proxyCA,omitempty"`
+
+	// TimeZoneData references a configmap in the openshift-config namespace
+	// with the compiled time zone oauth-server uses for its local time under
+	// the "localtime" binary key, e.g. a file of /usr/share/zoneinfo
+	TimeZoneData *configv1.ConfigMapNameReference `json:"timeZoneData,omitempty"`
+
+	// LoginPageSnippets reference configmaps in the openshift-config
+	// namespace with the HTML snippets oauth-server shows above and below the
+	// login pages, lighter than replacing the whole templates
+	LoginPageSnippets *loginPageSnippetsConfig `json:"loginPageSnippets,omitempty"`
+
+	// IdentityProviderSelection features some of the identity providers on
+	// the login page, the others are only offered through the search
+	IdentityProviderSelection *identityProviderSelectionConfig `json:"identityProviderSelection,omitempty"`
+
+	// IdentityProviderBranding maps the names of identity providers to the
+	// display name and the icon the login page shows for them
+	IdentityProviderBranding map[string]identityProviderBrandingConfig `json:"identityProviderBranding,omitempty"`
+
+	// IdentityProviderRateLimits maps the names of identity providers to the
+	// rate limits of the requests oauth-server sends them, for the providers
+	// that block the clients going over their own limits
+	IdentityProviderRateLimits map[string]rateLimitConfig `json:"identityProviderRateLimits,omitempty"`
+
+	// TokenEncryption makes oauth-server encrypt the tokens it issues with
+	// the key of an admin-provided secret, oauth-server refuses to issue
+	// tokens when the key cannot be read instead of storing them in plaintext
+	TokenEncryption *tokenEncryptionConfig `json:"tokenEncryption,omitempty"`
+
+	// SessionStore makes oauth-server keep its session state in an external
+	// store so that the login flows work across replicas without sticky sessions
+	SessionStore *sessionStoreConfig `json:"sessionStore,omitempty"`
+
+	// MaxSessionsPerUser caps the number of concurrent login sessions of a
+	// user, the oldest session ends when a new one exceeds it. The number of
+	// sessions is not limited when unset.
+	MaxSessionsPerUser *int32 `json:"maxSessionsPerUser,omitempty"`
+
+	// SessionCookie scopes the session cookie of oauth-server, the cookie is
+	// only sent to the oauth-server host on any path when unset
+	SessionCookie *sessionCookieConfig `json:"sessionCookie,omitempty"`
+
+	// AuditSink makes oauth-server push its login audit events to an
+	// external endpoint in addition to logging them
+	AuditSink *auditSinkConfig `json:"auditSink,omitempty"`
+
+	// VolumeModes sets the permission bits of the files of the mounted
+	// admin-provided resources, keyed by the names of their volumes such as
+	// "v4-0-config-user-idp-0-file-data"
+	VolumeModes map[string]volumeModeConfig `json:"volumeModes,omitempty"`
+
+	// EphemeralStorage bounds the node's local storage the oauth-server
+	// container may use for its logs and temporary files
+	EphemeralStorage *ephemeralStorageConfig `json:"ephemeralStorage,omitempty"`
+
+	// PodOverhead runs the oauth-server pods with a runtime class and declares
+	// the resources its sandbox takes on top of the containers
+	PodOverhead *podOverheadConfig `json:"podOverhead,omitempty"`
+
+	// SeccompProfile runs the oauth-server pods with a seccomp profile from
+	// the nodes instead of the RuntimeDefault one
+	SeccompProfile *seccompProfileConfig `json:"seccompProfile,omitempty"`
+
+	// LameDuck makes the oauth-server pods report they are not ready for the
+	// given time before they stop accepting connections so that the load
+	// balancers stop sending them new requests first
+	LameDuck *lameDuckConfig `json:"lameDuck,omitempty"`
+
+	// Scheduling pins the oauth-server pods to nodes other than the control
+	// plane ones, such as dedicated infra nodes
+	Scheduling *schedulingConfig `json:"scheduling,omitempty"`
+
+	// Replicas overrides the number of the oauth-server pods, which defaults
+	// to the number of the nodes the pods may be scheduled to. At most one pod
+	// runs per node so it cannot exceed the number of those nodes.
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources override the resource requests and limits of the oauth-server
+	// container, the ones that are not listed keep their defaults
+	Resources *resourceRequirementsConfig `json:"resources,omitempty"`
+	// InitContainerResources are the resource requirements of every init
+	// container of the oauth-server pods
+	InitContainerResources *resourceRequirementsConfig `json:"initContainerResources,omitempty"`
+	// SidecarResources are the resource requirements of every container of
+	// the oauth-server pods other than oauth-server itself
+	SidecarResources *resourceRequirementsConfig `json:"sidecarResources,omitempty"`
+
+	// Profiling enables the pprof endpoint of oauth-server for debugging its
+	// CPU and memory usage. The endpoint only listens on the loopback
+	// interface so it is reachable through `oc port-forward` or `oc exec`.
+	Profiling *profilingConfig `json:"profiling,omitempty"`
+
+	// Image overrides the oauth-server image of the payload. The payload has
+	// a single oauth-server image for both the FIPS and the non-FIPS clusters,
+	// pinning the image is the only override supported.
+	Image *imageConfig `json:"image,omitempty"`
+
+	// SchedulerName is the scheduler that schedules the oauth-server pods,
+	// the pods are scheduled by the default scheduler of the cluster if unset
+	SchedulerName *string `json:"schedulerName,omitempty"`
+
+	// TerminationMessage controls how the oauth-server container reports the
+	// reason of its termination in the pod status
+	TerminationMessage *terminationMessageConfig `json:"terminationMessage,omitempty"`
+
+	// SplitConfig points oauth-server to the directory of the CLI config
+	// configmap which the payload controller renders as separate files
+	SplitConfig bool `json:"splitConfig,omitempty"`
+
+	// RolloutCooldown is how long the config has to stay unchanged before it
+	// gets rolled out, every change starts it over so that a burst of changes
+	// gets rolled out together once it passes. It is a duration string, "0s"
+	// disables it.
+	RolloutCooldown string `json:"rolloutCooldown,omitempty"`
+
+	// MinRolloutInterval is a hard limit on how often the deployment gets
+	// rolled out. Unlike the cooldown, which is tracked by the operator
+	// process and starts over when the operator restarts, the interval is
+	// measured from the last rollout recorded on the deployment itself. The
+	// changes that come in the meantime are rolled out together once it
+	// passes. It is a duration string, there is no limit when it is not set.
+	MinRolloutInterval string `json:"minRolloutInterval,omitempty"`
+
+	// BootstrapUserRemoval defers the rollout that follows the removal of
+	// the kubeadmin user to a maintenance window
+	BootstrapUserRemoval *bootstrapUserRemovalConfig `json:"bootstrapUserRemoval,omitempty"`
+
+	// CARotationGracePeriod makes oauth-server trust both the previous and
+	// the new CA of an identity provider for the given duration after the CA
+	// changes so that the connections keep working while the provider moves
+	// to a certificate issued by the new CA
+	CARotationGracePeriod string `json:"caRotationGracePeriod,omitempty"`
+
+	// NetworkPolicy makes the operator restrict the traffic of the
+	// oauth-server pods to what oauth-server needs with a NetworkPolicy
+	NetworkPolicy bool `json:"networkPolicy,omitempty"`
+
+	// ValidateConfig makes the operator validate the oauth-server config in
+	// a short-lived pod before rolling it out
+	ValidateConfig bool `json:"validateConfig,omitempty"`
+
+	// InvalidIdentityProvidersPolicy is what the operator does when none of
+	// the configured identity providers is valid, either "FailClosed" to keep
+	// the current deployment or "BootstrapOnly" to roll out a deployment that
+	// only serves the bootstrap user as long as it exists. The deployment
+	// without any identity providers rolls out when it is not set.
+	InvalidIdentityProvidersPolicy invalidIdentityProvidersPolicy `json:"invalidIdentityProvidersPolicy,omitempty"`
+
+	// ConfigChecksumCheck adds an init container that fails the startup of
+	// the oauth-server pods whose mounted config does not match the config
+	// the operator rolled out, such as partial or stale mounts. While the
+	// rollout of a newer config is held back, the pods only warn about it.
+	ConfigChecksumCheck bool `json:"configChecksumCheck,omitempty"`
+
+	// DebugPause replaces the oauth-server process with a sleep so that the
+	// pods can be exec'd into with all the mounts and environment of a real
+	// oauth-server. The paused pods serve no logins, the operator reports
+	// itself Degraded for as long as it is set.
+	DebugPause *debugPauseConfig `json:"debugPause,omitempty"`
+}
+
+// debugPauseConfig requires an explicit acknowledgement so that the pause
+// does not get enabled by accident
+type debugPauseConfig struct {
+	// AcknowledgeLoginOutage must be true for the pause to apply
+	AcknowledgeLoginOutage bool `json:"acknowledgeLoginOutage,omitempty"`
+}
+
+type http2Config struct {
+	// Disabled makes the server only serve HTTP/1.1, this is sometimes
+	// required by load balancers in front of the route
+	Disabled bool `json:"disabled,omitempty"`
+	// MaxStreamsPerConnection limits the number of concurrent streams the
+	// server allows per HTTP/2 connection
+	MaxStreamsPerConnection *int32 `json:"maxStreamsPerConnection,omitempty"`
+}
+
+type kubeAPIClientConfig struct {
+	// QPS is the sustained rate of requests per second to the kube-apiserver
+	QPS *int32 `json:"qps,omitempty"`
+	// Burst is the number of requests allowed above QPS for short periods
+	Burst *int32 `json:"burst,omitempty"`
+	// MaxIdleConnections is the size of the connection pool to the
+	// kube-apiserver, the number of idle connections kept open for reuse
+	MaxIdleConnections *int32 `json:"maxIdleConnections,omitempty"`
+}
+
+type serviceAccountTokenConfig struct {
+	// Audience is the intended audience of the token
+	Audience string `json:"audience"`
+	// ExpirationSeconds is the requested validity of the token, the kubelet
+	// rotates the token before it expires
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+type invalidIdentityProvidersPolicy string
+
+const (
+	invalidIdentityProvidersFailClosed    invalidIdentityProvidersPolicy = "FailClosed"
+	invalidIdentityProvidersBootstrapOnly invalidIdentityProvidersPolicy = "BootstrapOnly"
+)
+
+// defaultRolloutCooldown is short enough not to delay config changes
+// noticeably while still batching the changes to several tracked resources
+// that come together
+const defaultRolloutCooldown = 10 * time.Second
+
+// defaultTLSHandshakeTimeout matches the handshake timeout of the default
+// transport of net/http
+const defaultTLSHandshakeTimeout = "10s"
+
+// defaultMaxRequestBodySize is well above the size of any request oauth-server
+// serves
+const defaultMaxRequestBodySize = "1Mi"
+
+// The default kube-apiserver client limits leave room for the login bursts
+// of large clusters, the client-go defaults of 5 QPS and a burst of 10 are
+// easily exhausted by the user and identity lookups of a single login wave
+const (
+	defaultKubeAPIQPS                = 50
+	defaultKubeAPIBurst              = 100
+	defaultKubeAPIMaxIdleConnections = 100
+)
+
+// supportedSigningAlgorithms are the asymmetric JWS algorithms, "none" and the
+// HMAC ones are never allowed as they make the tokens forgeable by anyone who
+// knows the client secret
+var supportedSigningAlgorithms = sets.NewString(
+	"RS256", "RS384", "RS512",
+	"ES256", "ES384", "ES512",
+	"PS256", "PS384", "PS512",
+)
+
+// supportedGrantTypes are the grant types oauth-server serves, "implicit"
+// covers the token requests of the challenging clients such as oc
+var supportedGrantTypes = sets.NewString(
+	"authorization_code",
+	"implicit",
+	"refresh_token",
+)
+
+// passwordCharacterClasses are the character classes a password policy may
+// require, "symbol" stands for any character outside of the other classes
+var passwordCharacterClasses = sets.NewString(
+	"lowercase",
+	"uppercase",
+	"digit",
+	"symbol",
+)
+
+// knownRedirectSchemes are the schemes the redirect URIs may be restricted
+// to, "custom" stands for the private-use schemes of the native clients
+var knownRedirectSchemes = sets.NewString(
+	"https",
+	"http",
+	"custom",
+)
+
+// defaultRedirectScheme is the only scheme allowed unless others are listed
+const defaultRedirectScheme = "https"
+
+// minServiceAccountTokenExpirationSeconds is the shortest validity the
+// kube-apiserver allows for projected service account tokens
+const minServiceAccountTokenExpirationSeconds = 600
+
+const (
+	serviceAccountTokenVolumeName = "oauth-server-token"
+	serviceAccountTokenMountPath  = "/var/run/secrets/openshift/serviceaccount"
+	serviceAccountTokenPath       = "token"
+)
+
+type sniCertificateConfig struct {
+	// Secret references a kubernetes.io/tls secret in the openshift-config
+	// namespace
+	Secret configv1.SecretNameReference `json:"secret"`
+	// Names are the hostnames the certificate is served for, the names from
+	// the certificate are used when empty. Wildcards are allowed. When several
+	// certificates match a hostname, the first one listed wins.
+	Names []string `json:"names,omitempty"`
+}
+
+type loginPageSnippetsConfig struct {
+	// Header is the configmap with the "snippet.html" shown above the forms
+	Header *configv1.ConfigMapNameReference `json:"header,omitempty"`
+	// Footer is the configmap with the "snippet.html" shown below the forms
+	Footer *configv1.ConfigMapNameReference `json:"footer,omitempty"`
+}
+
+type identityProviderSelectionConfig struct {
+	// Featured are the names of the identity providers listed on the login
+	// page, in the order they are listed in
+	Featured []string `json:"featured"`
+}
+
+type identityProviderBrandingConfig struct {
+	// DisplayName replaces the name of the identity provider on the login page
+	DisplayName string `json:"displayName,omitempty"`
+	// Icon is the configmap with the "icon" shown next to the identity provider
+	Icon *configv1.ConfigMapNameReference `json:"icon,omitempty"`
+}
+
+type rateLimitConfig struct {
+	// QPS is the sustained rate of requests per second
+	QPS int32 `json:"qps"`
+	// Burst is the number of requests allowed above QPS for short periods,
+	// QPS when not set
+	Burst *int32 `json:"burst,omitempty"`
+}
+
+type tokenEncryptionConfig struct {
+	// KeySecret references a secret in the openshift-config namespace with
+	// the AES-256 key in its "key" item
+	KeySecret configv1.SecretNameReference `json:"keySecret"`
+}
+
+type sessionStoreConfig struct {
+	// Type is the kind of the store, only "redis" is supported
+	Type string `json:"type"`
+	// ConnectionSecret references a secret in the openshift-config namespace
+	// with the "url" of the store and the "password" to authenticate with
+	ConnectionSecret configv1.SecretNameReference `json:"connectionSecret"`
+}
+
+var supportedSessionStoreTypes = sets.NewString("redis")
+
+type volumeModeConfig struct {
+	// DefaultMode applies to all the files of the volume
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+	// ItemMode applies to the file of the mounted key, it takes precedence
+	// over DefaultMode
+	ItemMode *int32 `json:"itemMode,omitempty"`
+}
+
+type auditSinkConfig struct {
+	// Endpoint is the http(s) URL the login audit events get posted to
+	Endpoint string `json:"endpoint"`
+	// CredentialsSecret references a secret in the openshift-config namespace
+	// with the bearer "token" oauth-server authenticates to the sink with
+	CredentialsSecret *configv1.SecretNameReference `json:"credentialsSecret,omitempty"`
+	// CA references a configmap in the openshift-config namespace with the
+	// "ca.crt" bundle that verifies an https endpoint, the system trust is
+	// used when unset
+	CA *configv1.ConfigMapNameReference `json:"ca,omitempty"`
+}
+
+type redirectURIAllowlistConfig struct {
+	// Global are the redirect URIs allowed for all the OAuth clients
+	Global []string `json:"global,omitempty"`
+	// Clients maps the names of OAuth clients to the redirect URIs allowed
+	// for them in addition to the global ones
+	Clients map[string][]string `json:"clients,omitempty"`
+}
+
+type profilingConfig struct {
+	// Port is the loopback port of the pprof endpoint, defaults to 6060
+	Port int32 `json:"port,omitempty"`
+}
+
+// defaultProfilingPort is the port the pprof examples of Go use
+const defaultProfilingPort = 6060
+
+type sessionCookieConfig struct {
+	// Domain makes the browsers send the cookie to the subdomains of the
+	// domain as well, e.g. to the console of a multi-subdomain setup
+	Domain string `json:"domain,omitempty"`
+	// Path restricts the cookie to the requests under the path
+	Path string `json:"path,omitempty"`
+}
+
+type requestLoggingConfig struct {
+	// RequestID makes oauth-server generate an ID for each of the requests
+	// that do not carry one already, and log it
+	RequestID bool `json:"requestID,omitempty"`
+	// ClientIP makes oauth-server log the IP of the client of each request
+	ClientIP bool `json:"clientIP,omitempty"`
+	// TrustedProxyCIDRs are the networks of the proxies whose X-Forwarded-For
+	// header is used for the client IP, the peer address is logged for the
+	// requests from any other address
+	TrustedProxyCIDRs []string `json:"trustedProxyCIDRs,omitempty"`
+}
+
+type bootstrapUserRemovalConfig struct {
+	// MaintenanceWindow is the daily window in which the rollout happens
+	MaintenanceWindow maintenanceWindowConfig `json:"maintenanceWindow"`
+	// MaxDelay is the longest the rollout gets deferred for after the removal
+	// of the user was observed, as a duration string. Defaults to 24h so that
+	// the rollout completes even if the window is missed.
+	MaxDelay string `json:"maxDelay,omitempty"`
+}
+
+type maintenanceWindowConfig struct {
+	// Start is the UTC time of the day the window starts at, in the HH:MM format
+	Start string `json:"start"`
+	// Duration is the length of the window as a duration string, at most 24h
+	Duration string `json:"duration"`
+}
+
+// defaultBootstrapUserRemovalMaxDelay makes sure the rollout happens within a
+// day even with a window that is never hit, e.g. when the clock is off
+const defaultBootstrapUserRemovalMaxDelay = 24 * time.Hour
+
+// resourceRequirementsConfig keeps the quantities as strings so that we can
+// report the malformed ones instead of failing to decode the whole config
+type resourceRequirementsConfig struct {
+	Requests map[corev1.ResourceName]string `json:"requests,omitempty"`
+	Limits   map[corev1.ResourceName]string `json:"limits,omitempty"`
+}
+
+type imageConfig struct {
+	// Reference is the pull spec of the image the oauth-server pods run
+	Reference string `json:"reference,omitempty"`
+}
+
+type stepUpConfig struct {
+	// MaxAge is the longest time since the user last logged in for the scope
+	// to be granted without logging in again, as a duration string. "0s" makes
+	// the users log in for every grant of the scope.
+	MaxAge string `json:"maxAge"`
+}
+
+type passwordPolicyConfig struct {
+	// MinLength is the lowest number of characters of the passwords
+	MinLength *int32 `json:"minLength,omitempty"`
+	// MaxLength is the highest number of characters of the passwords
+	MaxLength *int32 `json:"maxLength,omitempty"`
+	// RequiredCharacterClasses are the character classes every password has
+	// to contain a character of
+	RequiredCharacterClasses []string `json:"requiredCharacterClasses,omitempty"`
+	// MinCharacterClasses is the lowest number of distinct character classes
+	// every password has to contain characters of
+	MinCharacterClasses *int32 `json:"minCharacterClasses,omitempty"`
+}
+
+type redirectSchemesConfig struct {
+	// Allowed are the schemes of the redirect URIs oauth-server accepts,
+	// https only when empty
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+type lameDuckConfig struct {
+	// Duration is how long oauth-server keeps serving after it starts to
+	// report it is not ready, as a duration string
+	Duration string `json:"duration"`
+}
+
+const (
+	lameDuckDir  = "/var/run/oauth-server"
+	lameDuckFile = lameDuckDir + "/lame-duck"
+)
+
+type ephemeralStorageConfig struct {
+	// Request is the ephemeral-storage quantity the pods get scheduled with
+	Request string `json:"request,omitempty"`
+	// Limit is the ephemeral-storage quantity over which the pods get evicted
+	Limit string `json:"limit,omitempty"`
+}
+
+type podOverheadConfig struct {
+	// RuntimeClassName is the runtime class the pods run with, the admission
+	// rejects pods that declare an overhead without one
+	RuntimeClassName string `json:"runtimeClassName"`
+	// Overhead are the resources of the sandbox, they have to match the
+	// overhead of the runtime class for the pods to be admitted
+	Overhead map[corev1.ResourceName]string `json:"overhead"`
+}
+
+type clientCertificateAuthenticationConfig struct {
+	// ClientCA references a configmap in the openshift-config namespace with
+	// the "ca.crt" bundle the client certificates are verified with
+	ClientCA configv1.ConfigMapNameReference `json:"clientCA"`
+}
+
+type schedulingConfig struct {
+	// NodeSelector replaces the control plane node selector of the pods
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are added to the default ones so that the pods can be
+	// scheduled to the tainted nodes
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+type seccompProfileConfig struct {
+	// LocalhostProfile is the path of the profile relative to the seccomp
+	// profile root of the kubelet, the profile has to be provisioned on all
+	// the nodes the pods may run on
+	LocalhostProfile string `json:"localhostProfile"`
+}
+
+type terminationMessageConfig struct {
+	// Policy is either "File" or "FallbackToLogsOnError", the latter being the
+	// default of the deployment
+	Policy corev1.TerminationMessagePolicy `json:"policy,omitempty"`
+	// Path is the absolute path of the file the termination message is read from
+	Path string `json:"path,omitempty"`
+}
+
+func getDeploymentConfig(operatorConfig *operatorv1.Authentication) (*deploymentConfig, error) {
+	unsupportedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.UnsupportedConfigOverrides.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read the unsupportedConfigOverrides prefix %q: %w",
+			configobservation.OAuthServerConfigPrefix,
+			err,
+		)
+	}
+
+	config := &deploymentConfig{}
+	if err := json.Unmarshal(unsupportedConfig, config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the oauth-server deployment config: %w", err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid oauth-server deployment config: %w", err)
+	}
+
+	return config, nil
+}
+
+func (c *deploymentConfig) validate() error {
+	var errs []error
+
+	if c.HTTP2 != nil {
+		errs = append(errs, c.HTTP2.validate()...)
+	}
+
+	for _, domain := range c.DNSSearchDomains {
+		if validationErrs := validation.IsDNS1123Subdomain(domain); len(validationErrs) > 0 {
+			errs = append(errs, fmt.Errorf("dnsSearchDomains: %q is not a valid domain: %s", domain, strings.Join(validationErrs, ", ")))
+		}
+	}
+
+	if c.KubeClientCertificate != nil && len(c.KubeClientCertificate.Name) == 0 {
+		errs = append(errs, fmt.Errorf("kubeClientCertificate.name must be set"))
+	}
+
+	if c.KubeAPIClient != nil {
+		errs = append(errs, c.KubeAPIClient.validate()...)
+	}
+
+	if c.ServiceAccountToken != nil {
+		errs = append(errs, c.ServiceAccountToken.validate()...)
+	}
+
+	for i, cert := range c.SNICertificates {
+		errs = append(errs, cert.validate(fmt.Sprintf("sniCertificates[%d]", i))...)
+	}
+
+	if len(c.TLSHandshakeTimeout) > 0 {
+		if timeout, err := time.ParseDuration(c.TLSHandshakeTimeout); err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("tlsHandshakeTimeout must be a positive duration, got %q", c.TLSHandshakeTimeout))
+		}
+	}
+
+	if len(c.MaxRequestBodySize) > 0 {
+		if size, err := resource.ParseQuantity(c.MaxRequestBodySize); err != nil || size.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("maxRequestBodySize must be a positive byte quantity, got %q", c.MaxRequestBodySize))
+		}
+	}
+
+	for _, grantType := range c.AllowedGrantTypes {
+		if !supportedGrantTypes.Has(grantType) {
+			errs = append(errs, fmt.Errorf("allowedGrantTypes: %q is not one of the supported grant types %v", grantType, supportedGrantTypes.List()))
+		}
+	}
+
+	for _, origin := range c.AllowedCORSOrigins {
+		if !isOrigin(origin) {
+			errs = append(errs, fmt.Errorf("allowedCORSOrigins: %q must be a scheme and a host such as \"https://console.example.com\"", origin))
+		}
+	}
+
+	for _, algorithm := range c.SigningAlgorithms {
+		if !supportedSigningAlgorithms.Has(algorithm) {
+			errs = append(errs, fmt.Errorf("signingAlgorithms: %q is not one of the supported algorithms %v", algorithm, supportedSigningAlgorithms.List()))
+		}
+	}
+
+	if c.TokenEncryption != nil && len(c.TokenEncryption.KeySecret.Name) == 0 {
+		errs = append(errs, fmt.Errorf("tokenEncryption.keySecret.name must be set"))
+	}
+
+	if c.SessionStore != nil {
+		errs = append(errs, c.SessionStore.validate()...)
+	}
+
+	if c.MaxSessionsPerUser != nil && *c.MaxSessionsPerUser <= 0 {
+		errs = append(errs, fmt.Errorf("maxSessionsPerUser must be a positive number, got %d", *c.MaxSessionsPerUser))
+	}
+
+	if c.SessionCookie != nil {
+		errs = append(errs, c.SessionCookie.validate()...)
+	}
+
+	if c.Profiling != nil {
+		errs = append(errs, c.Profiling.validate()...)
+	}
+
+	if c.RedirectURIAllowlist != nil {
+		errs = append(errs, c.RedirectURIAllowlist.validate()...)
+	}
+
+	for _, scope := range sets.StringKeySet(c.StepUpScopes).List() {
+		if !isValidScopeName(scope) {
+			errs = append(errs, fmt.Errorf("stepUpScopes: %q is not a valid scope name", scope))
+			continue
+		}
+		if maxAge, err := time.ParseDuration(c.StepUpScopes[scope].MaxAge); err != nil || maxAge < 0 || maxAge%time.Second != 0 {
+			errs = append(errs, fmt.Errorf("stepUpScopes[%s].maxAge must be a non-negative duration in whole seconds, got %q", scope, c.StepUpScopes[scope].MaxAge))
+		}
+	}
+
+	if c.HTPasswdPasswordPolicy != nil {
+		errs = append(errs, c.HTPasswdPasswordPolicy.validate()...)
+	}
+
+	if c.RedirectSchemes != nil {
+		for _, scheme := range c.RedirectSchemes.Allowed {
+			if !knownRedirectSchemes.Has(scheme) {
+				errs = append(errs, fmt.Errorf("redirectSchemes.allowed: %q is not one of the known schemes %v", scheme, knownRedirectSchemes.List()))
+			}
+		}
+	}
+
+	if c.LocaleBundle != nil && len(c.LocaleBundle.Name) == 0 {
+		errs = append(errs, fmt.Errorf("localeBundle.name must be set"))
+	}
+
+	if c.IdentityProviderSelection != nil {
+		if len(c.IdentityProviderSelection.Featured) == 0 {
+			errs = append(errs, fmt.Errorf("identityProviderSelection.featured must list at least one identity provider"))
+		}
+		for _, idpName := range c.IdentityProviderSelection.Featured {
+			if len(idpName) == 0 {
+				errs = append(errs, fmt.Errorf("identityProviderSelection.featured must not contain empty names"))
+			}
+		}
+	}
+
+	for _, idpName := range sets.StringKeySet(c.IdentityProviderBranding).List() {
+		branding := c.IdentityProviderBranding[idpName]
+		switch {
+		case len(idpName) == 0 || strings.Contains(idpName, "="):
+			errs = append(errs, fmt.Errorf("identityProviderBranding: %q is not a valid identity provider name", idpName))
+		case len(branding.DisplayName) == 0 && branding.Icon == nil:
+			errs = append(errs, fmt.Errorf("identityProviderBranding[%s] must set a display name or an icon", idpName))
+		case strings.ContainsAny(branding.DisplayName, "\n\r"):
+			errs = append(errs, fmt.Errorf("identityProviderBranding[%s].displayName must be a single line", idpName))
+		case branding.Icon != nil && len(branding.Icon.Name) == 0:
+			errs = append(errs, fmt.Errorf("identityProviderBranding[%s].icon.name must be set", idpName))
+		}
+	}
+
+	for _, idpName := range sets.StringKeySet(c.IdentityProviderRateLimits).List() {
+		if len(idpName) == 0 || strings.Contains(idpName, "=") {
+			errs = append(errs, fmt.Errorf("identityProviderRateLimits: %q is not a valid identity provider name", idpName))
+			continue
+		}
+		errs = append(errs, c.IdentityProviderRateLimits[idpName].validate(fmt.Sprintf("identityProviderRateLimits[%s]", idpName))...)
+	}
+
+	if c.ClientCertificateAuthentication != nil && len(c.ClientCertificateAuthentication.ClientCA.Name) == 0 {
+		errs = append(errs, fmt.Errorf("clientCertificateAuthentication.clientCA.name must be set"))
+	}
+	if c.CertificateBoundTokens && c.ClientCertificateAuthentication == nil {
+		errs = append(errs, fmt.Errorf("certificateBoundTokens requires clientCertificateAuthentication to be configured"))
+	}
+
+	if c.ProxyCA != nil && len(c.ProxyCA.Name) == 0 {
+		errs = append(errs, fmt.Errorf("proxyCA.name must be set"))
+	}
+
+	if c.TimeZoneData != nil && len(c.TimeZoneData.Name) == 0 {
+		errs = append(errs, fmt.Errorf("timeZoneData.name must be set"))
+	}
+
+	if c.RequestLogging != nil {
+		errs = append(errs, c.RequestLogging.validate()...)
+	}
+	if c.HonorForwardedHeaders && (c.RequestLogging == nil || len(c.RequestLogging.TrustedProxyCIDRs) == 0) {
+		errs = append(errs, fmt.Errorf("honorForwardedHeaders requires requestLogging.trustedProxyCIDRs to be set"))
+	}
+
+	if c.BootstrapUserRemoval != nil {
+		errs = append(errs, c.BootstrapUserRemoval.validate()...)
+	}
+
+	if len(c.RolloutCooldown) > 0 {
+		if cooldown, err := time.ParseDuration(c.RolloutCooldown); err != nil || cooldown < 0 {
+			errs = append(errs, fmt.Errorf("rolloutCooldown must be a non-negative duration, got %q", c.RolloutCooldown))
+		}
+	}
+
+	if len(c.MinRolloutInterval) > 0 {
+		if interval, err := time.ParseDuration(c.MinRolloutInterval); err != nil || interval < 0 {
+			errs = append(errs, fmt.Errorf("minRolloutInterval must be a non-negative duration, got %q", c.MinRolloutInterval))
+		}
+	}
+
+	if len(c.CARotationGracePeriod) > 0 {
+		if grace, err := time.ParseDuration(c.CARotationGracePeriod); err != nil || grace <= 0 {
+			errs = append(errs, fmt.Errorf("caRotationGracePeriod must be a positive duration, got %q", c.CARotationGracePeriod))
+		}
+	}
+
+	if c.AuditSink != nil {
+		errs = append(errs, c.AuditSink.validate()...)
+	}
+
+	if c.LoginPageSnippets != nil {
+		errs = append(errs, c.LoginPageSnippets.validate()...)
+	}
+
+	for _, volumeName := range sets.StringKeySet(c.VolumeModes).List() {
+		errs = append(errs, c.VolumeModes[volumeName].validate(volumeName)...)
+	}
+
+	if c.EphemeralStorage != nil {
+		errs = append(errs, c.EphemeralStorage.validate()...)
+	}
+
+	if c.PodOverhead != nil {
+		errs = append(errs, c.PodOverhead.validate()...)
+	}
+
+	if c.SeccompProfile != nil {
+		if profile := c.SeccompProfile.LocalhostProfile; len(profile) == 0 || path.IsAbs(profile) || path.Clean(profile) != profile || strings.HasPrefix(profile, "../") || profile == ".." {
+			errs = append(errs, fmt.Errorf("seccompProfile.localhostProfile must be a path within the seccomp profile root of the kubelet, got %q", profile))
+		}
+	}
+
+	if c.LameDuck != nil {
+		if duration, err := time.ParseDuration(c.LameDuck.Duration); err != nil || duration <= 0 {
+			errs = append(errs, fmt.Errorf("lameDuck.duration must be a positive duration, got %q", c.LameDuck.Duration))
+		}
+	}
+
+	if c.Scheduling != nil {
+		errs = append(errs, c.Scheduling.validate()...)
+	}
+
+	if c.Replicas != nil && *c.Replicas <= 0 {
+		errs = append(errs, fmt.Errorf("replicas must be a positive number, got %d", *c.Replicas))
+	}
+
+	if c.Resources != nil {
+		if _, err := c.Resources.toResourceRequirements("resources"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.InitContainerResources != nil {
+		if _, err := c.InitContainerResources.toResourceRequirements("initContainerResources"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.SidecarResources != nil {
+		if _, err := c.SidecarResources.toResourceRequirements("sidecarResources"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Image != nil {
+		if reference := c.Image.Reference; len(reference) > 0 && (strings.TrimSpace(reference) != reference || strings.ContainsAny(reference, " \t\n") || strings.HasPrefix(reference, "-")) {
+			errs = append(errs, fmt.Errorf("image.reference must be an image pull spec, got %q", reference))
+		}
+	}
+
+	if c.SchedulerName != nil && len(*c.SchedulerName) == 0 {
+		errs = append(errs, fmt.Errorf("schedulerName must not be empty"))
+	}
+
+	if c.TerminationMessage != nil {
+		errs = append(errs, c.TerminationMessage.validate()...)
+	}
+
+	if c.DebugPause != nil && !c.DebugPause.AcknowledgeLoginOutage {
+		errs = append(errs, fmt.Errorf("debugPause.acknowledgeLoginOutage must be true, the paused oauth-server pods serve no logins"))
+	}
+
+	switch c.InvalidIdentityProvidersPolicy {
+	case "", invalidIdentityProvidersFailClosed, invalidIdentityProvidersBootstrapOnly:
+	default:
+		errs = append(errs, fmt.Errorf("invalidIdentityProvidersPolicy must be either %q or %q, got %q", invalidIdentityProvidersFailClosed, invalidIdentityProvidersBootstrapOnly, c.InvalidIdentityProvidersPolicy))
+	}
+
+	if len(c.GOGC) > 0 && c.GOGC != "off" {
+		if gogc, err := strconv.Atoi(c.GOGC); err != nil || gogc <= 0 {
+			errs = append(errs, fmt.Errorf("gogc must be a positive integer or \"off\", got %q", c.GOGC))
+		}
+	}
+
+	return errors.NewAggregate(errs)
+}
+
+// isOrigin checks that the value is an http(s) origin, i.e. it only consists
+// of the scheme, the host and optionally the port
+func isOrigin(value string) bool {
+	origin, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (origin.Scheme == "http" || origin.Scheme == "https") &&
+		len(origin.Hostname()) > 0 &&
+		origin.User == nil &&
+		len(origin.Path) == 0 &&
+		len(origin.RawQuery) == 0 &&
+		len(origin.Fragment) == 0 &&
+		!origin.ForceQuery
+}
+
+// corsOriginPatterns turns the origins into the anchored regular expressions
+// the CORS allowed origins of oauth-server are matched with
+func corsOriginPatterns(origins []string) []string {
+	var patterns []string
+	for _, origin := range appendUniqueStrings(nil, origins...) {
+		patterns = append(patterns, "^"+regexp.QuoteMeta(origin)+"$")
+	}
+	return patterns
+}
+
+// hashInput returns a stable representation of the config so that any change
+// to it triggers a rollout of the deployment
+func (c *deploymentConfig) hashInput() (string, error) {
+	hashedConfig := *c
+	// operator-side behavior that does not need to roll the pods
+	hashedConfig.ValidateConfig = false
+	hashedConfig.InvalidIdentityProvidersPolicy = ""
+	hashedConfig.RolloutCooldown = ""
+	hashedConfig.MinRolloutInterval = ""
+	// scaling does not need to roll the existing pods
+	hashedConfig.Replicas = nil
+	hashedConfig.BootstrapUserRemoval = nil
+	hashedConfig.NetworkPolicy = false
+	// the CA bundles are tracked as any other v4-0-config- resource
+	hashedConfig.CARotationGracePeriod = ""
+
+	configBytes, err := json.Marshal(hashedConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the oauth-server deployment config: %w", err)
+	}
+	if string(configBytes) == "{}" {
+		return "", nil
+	}
+	return "deploymentconfig:" + string(configBytes), nil
+}
+
+// rolloutCooldown returns the configured rollout cooldown, the config is
+// expected to be validated
+func (c *deploymentConfig) rolloutCooldown() time.Duration {
+	if len(c.RolloutCooldown) == 0 {
+		return defaultRolloutCooldown
+	}
+	cooldown, _ := time.ParseDuration(c.RolloutCooldown)
+	return cooldown
+}
+
+// minRolloutInterval returns the configured minimum interval between the
+// rollouts, zero when there is none. The config is expected to be validated.
+func (c *deploymentConfig) minRolloutInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.MinRolloutInterval)
+	return interval
+}
+
+// caRotationGracePeriod returns the configured CA rotation grace period, zero
+// when the CAs are mounted as they are synced, the config is expected to be
+// validated
+func (c *deploymentConfig) caRotationGracePeriod() time.Duration {
+	grace, _ := time.ParseDuration(c.CARotationGracePeriod)
+	return grace
+}
+
+// oauthServerImage returns the image the oauth-server pods run, the one of the
+// payload unless the admin pins another one
+func (c *deploymentConfig) oauthServerImage() string {
+	if c.Image != nil && len(c.Image.Reference) > 0 {
+		return c.Image.Reference
+	}
+	return os.Getenv("IMAGE_OAUTH_SERVER")
+}
+
+// bootstrapUserRemovalDelay returns how long the rollout that follows the
+// removal of the bootstrap user, observed at removedAt, should still be
+// deferred, the config is expected to be validated
+func (c *deploymentConfig) bootstrapUserRemovalDelay(removedAt, now time.Time) time.Duration {
+	if c.BootstrapUserRemoval == nil {
+		return 0
+	}
+	return c.BootstrapUserRemoval.delay(removedAt, now)
+}
+
+// userSyncData returns the admin-provided resources from the openshift-config
+// namespace that need to be synchronized to and mounted in the oauth-server
+// pods, along with the server arguments that point to the mounted files
+func (c *deploymentConfig) userSyncData() (*datasync.ConfigSyncData, arguments.ServerArguments) {
+	syncData := datasync.NewConfigSyncData()
+	args := arguments.ServerArguments{}
+
+	if c.KubeClientCertificate != nil {
+		args["kube-client-cert-file"] = []string{syncData.AddUserSecret(*c.KubeClientCertificate, "kube-client-cert", corev1.TLSCertKey)}
+		args["kube-client-key-file"] = []string{syncData.AddUserSecret(*c.KubeClientCertificate, "kube-client-key", corev1.TLSPrivateKeyKey)}
+	}
+
+	// the certificates keep their order as it decides which one gets served
+	for i, cert := range c.SNICertificates {
+		certFile, keyFile := syncData.AddUserServingCertificate(cert.Secret, fmt.Sprintf("sni-%d", i))
+		sniCertKey := certFile + "," + keyFile
+		if len(cert.Names) > 0 {
+			sniCertKey += ":" + strings.Join(cert.Names, ",")
+		}
+		args["tls-sni-cert-key"] = append(args["tls-sni-cert-key"], sniCertKey)
+	}
+
+	if c.ClientCertificateAuthentication != nil {
+		args["client-ca-file"] = []string{syncData.AddUserConfigMap(c.ClientCertificateAuthentication.ClientCA, "client-ca", corev1.ServiceAccountRootCAKey)}
+	}
+	if c.CertificateBoundTokens {
+		args["certificate-bound-access-tokens"] = []string{"true"}
+	}
+
+	if c.TokenEncryption != nil {
+		args["token-encryption-key-file"] = []string{syncData.AddUserSecret(c.TokenEncryption.KeySecret, "token-encryption-key", datasync.TokenEncryptionKeyKey)}
+		// never fall back to plaintext tokens when the key goes missing
+		args["token-encryption-fail-closed"] = []string{"true"}
+	}
+	if c.SessionStore != nil {
+		args["session-store-type"] = []string{c.SessionStore.Type}
+		args["session-store-url-file"] = []string{syncData.AddUserSecret(c.SessionStore.ConnectionSecret, "session-store-url", datasync.SessionStoreURLKey)}
+		args["session-store-password-file"] = []string{syncData.AddUserSecret(c.SessionStore.ConnectionSecret, "session-store-password", datasync.SessionStorePasswordKey)}
+	}
+
+	if c.AuditSink != nil {
+		args["audit-sink-endpoint"] = []string{c.AuditSink.Endpoint}
+		if c.AuditSink.CredentialsSecret != nil {
+			args["audit-sink-token-file"] = []string{syncData.AddUserSecret(*c.AuditSink.CredentialsSecret, "audit-sink-token", datasync.AuditSinkTokenKey)}
+		}
+		if c.AuditSink.CA != nil {
+			args["audit-sink-ca-file"] = []string{syncData.AddUserConfigMap(*c.AuditSink.CA, "audit-sink-ca", corev1.ServiceAccountRootCAKey)}
+		}
+	}
+
+	if snippets := c.LoginPageSnippets; snippets != nil {
+		if snippets.Header != nil {
+			args["login-page-header-file"] = []string{syncData.AddUserConfigMap(*snippets.Header, "login-page-header", datasync.LoginPageSnippetKey)}
+		}
+		if snippets.Footer != nil {
+			args["login-page-footer-file"] = []string{syncData.AddUserConfigMap(*snippets.Footer, "login-page-footer", datasync.LoginPageSnippetKey)}
+		}
+	}
+
+	if c.LocaleBundle != nil {
+		args["locale-bundle-file"] = []string{syncData.AddUserConfigMap(*c.LocaleBundle, "locale-bundle", datasync.LocaleBundleKey)}
+	}
+
+	// the providers are numbered in the order of their names as the names do
+	// not have to be valid volume names
+	var displayNames, iconFiles []string
+	for i, idpName := range sets.StringKeySet(c.IdentityProviderBranding).List() {
+		branding := c.IdentityProviderBranding[idpName]
+		if len(branding.DisplayName) > 0 {
+			displayNames = append(displayNames, idpName+"="+branding.DisplayName)
+		}
+		if branding.Icon != nil {
+			iconFiles = append(iconFiles, idpName+"="+syncData.AddUserConfigMap(*branding.Icon, fmt.Sprintf("idp-icon-%d", i), datasync.IdentityProviderIconKey))
+		}
+	}
+	if len(displayNames) > 0 {
+		args["identity-provider-display-name"] = displayNames
+	}
+	if len(iconFiles) > 0 {
+		args["identity-provider-icon-file"] = iconFiles
+	}
+
+	if len(c.IdentityProviderRateLimits) > 0 {
+		var qps, burst []string
+		for _, idpName := range sets.StringKeySet(c.IdentityProviderRateLimits).List() {
+			rateLimit := c.IdentityProviderRateLimits[idpName]
+			qps = append(qps, fmt.Sprintf("%s=%d", idpName, rateLimit.QPS))
+			burst = append(burst, fmt.Sprintf("%s=%d", idpName, rateLimit.burst()))
+		}
+		args["identity-provider-qps"] = qps
+		args["identity-provider-burst"] = burst
+	}
+
+	if c.ProxyCA != nil {
+		args["proxy-ca-file"] = []string{syncData.AddUserConfigMap(*c.ProxyCA, "proxy-ca", corev1.ServiceAccountRootCAKey)}
+	}
+
+	// the time zone is set in the environment of oauth-server by apply
+	if c.TimeZoneData != nil {
+		syncData.AddUserConfigMap(*c.TimeZoneData, "time-zone-data", datasync.TimeZoneDataKey)
+	}
+
+	return syncData, args
+}
+
+// applyVolumeModes sets the configured permission bits to the volumes of the
+// sync data, the volumes of other sync data are skipped
+func (c *deploymentConfig) applyVolumeModes(syncData *datasync.ConfigSyncData) {
+	for volumeName, modes := range c.VolumeModes {
+		syncData.SetModes(volumeName, modes.DefaultMode, modes.ItemMode)
+	}
+}
+
+// apply sets the configured values to the oauth-server pod and its server
+// arguments
+func (c *deploymentConfig) apply(templateSpec *corev1.PodSpec, args arguments.ServerArguments) error {
+	container := &templateSpec.Containers[0]
+
+	syncData, syncDataArgs := c.userSyncData()
+	c.applyVolumeModes(syncData)
+	volumes, volumeMounts, err := syncData.ToVolumesAndMounts()
+	if err != nil {
+		return fmt.Errorf("unable to transform the user sync data to volumes and mounts: %w", err)
+	}
+	templateSpec.Volumes = append(templateSpec.Volumes, volumes...)
+	container.VolumeMounts = append(container.VolumeMounts, volumeMounts...)
+	for name, values := range syncDataArgs {
+		args[name] = values
+	}
+
+	if c.TimeZoneData != nil {
+		// the data is already among the sync data, adding it again only
+		// returns the path it is mounted at
+		container.Env = appendEnvVar(container.Env, "TZ", syncData.AddUserConfigMap(*c.TimeZoneData, "time-zone-data", datasync.TimeZoneDataKey))
+	}
+
+	if c.HTTP2 != nil {
+		c.HTTP2.apply(container, args)
+	}
+
+	if c.ServiceAccountToken != nil {
+		c.ServiceAccountToken.apply(templateSpec, container)
+	}
+
+	c.KubeAPIClient.apply(args)
+
+	tlsHandshakeTimeout := defaultTLSHandshakeTimeout
+	if len(c.TLSHandshakeTimeout) > 0 {
+		tlsHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+	args["tls-handshake-timeout"] = []string{tlsHandshakeTimeout}
+
+	maxRequestBodySize := resource.MustParse(defaultMaxRequestBodySize)
+	if len(c.MaxRequestBodySize) > 0 {
+		maxRequestBodySize = resource.MustParse(c.MaxRequestBodySize)
+	}
+	args["max-request-body-bytes"] = []string{strconv.FormatInt(maxRequestBodySize.Value(), 10)}
+
+	if c.RequestLogging != nil {
+		c.RequestLogging.apply(args)
+	}
+	if c.HonorForwardedHeaders {
+		args["honor-forwarded-headers"] = []string{"true"}
+	}
+
+	if len(c.StepUpScopes) > 0 {
+		var stepUpScopes []string
+		for _, scope := range sets.StringKeySet(c.StepUpScopes).List() {
+			maxAge, _ := time.ParseDuration(c.StepUpScopes[scope].MaxAge)
+			stepUpScopes = append(stepUpScopes, fmt.Sprintf("%s=%d", scope, int64(maxAge.Seconds())))
+		}
+		args["step-up-scope-max-age"] = stepUpScopes
+	}
+
+	if c.HTPasswdPasswordPolicy != nil {
+		c.HTPasswdPasswordPolicy.apply(args)
+	}
+
+	if c.RedirectSchemes != nil {
+		args["allowed-redirect-schemes"] = []string{strings.Join(c.RedirectSchemes.allowed(), ",")}
+	}
+
+	if len(c.SigningAlgorithms) > 0 {
+		args["allowed-signing-algorithms"] = []string{strings.Join(appendUniqueStrings(nil, c.SigningAlgorithms...), ",")}
+	}
+
+	if len(c.AllowedGrantTypes) > 0 {
+		args["allowed-grant-types"] = []string{strings.Join(appendUniqueStrings(nil, c.AllowedGrantTypes...), ",")}
+	}
+
+	if len(c.AllowedCORSOrigins) > 0 {
+		args["cors-allowed-origins"] = []string{strings.Join(corsOriginPatterns(c.AllowedCORSOrigins), ",")}
+	}
+
+	if c.RedirectURIAllowlist != nil {
+		c.RedirectURIAllowlist.apply(args)
+	}
+
+	if c.RequirePKCEForPublicClients {
+		args["require-pkce-for-public-clients"] = []string{"true"}
+	}
+
+	if c.MaxSessionsPerUser != nil {
+		args["max-sessions-per-user"] = []string{strconv.Itoa(int(*c.MaxSessionsPerUser))}
+	}
+
+	if c.SessionCookie != nil {
+		if len(c.SessionCookie.Domain) > 0 {
+			args["session-cookie-domain"] = []string{c.SessionCookie.Domain}
+		}
+		if len(c.SessionCookie.Path) > 0 {
+			args["session-cookie-path"] = []string{c.SessionCookie.Path}
+		}
+	}
+
+	if len(c.GOGC) > 0 {
+		container.Env = appendEnvVar(container.Env, "GOGC", c.GOGC)
+	}
+
+	if c.Profiling != nil {
+		args["profiling-bind-address"] = []string{net.JoinHostPort("127.0.0.1", strconv.Itoa(int(c.Profiling.port())))}
+	}
+
+	if c.SplitConfig {
+		container.Args[0] = strings.Replace(container.Args[0], "--config="+cliConfigFile, "--config="+cliConfigDir, 1)
+	}
+
+	if c.TerminationMessage != nil {
+		if len(c.TerminationMessage.Policy) > 0 {
+			container.TerminationMessagePolicy = c.TerminationMessage.Policy
+		}
+		if len(c.TerminationMessage.Path) > 0 {
+			container.TerminationMessagePath = c.TerminationMessage.Path
+		}
+	}
+
+	if c.Scheduling != nil {
+		c.Scheduling.apply(templateSpec)
+	}
+
+	if c.Resources != nil {
+		if err := c.Resources.merge(&container.Resources, "resources"); err != nil {
+			return err
+		}
+	}
+
+	if c.EphemeralStorage != nil {
+		c.EphemeralStorage.apply(container)
+	}
+
+	if c.PodOverhead != nil {
+		c.PodOverhead.apply(templateSpec)
+	}
+
+	c.SeccompProfile.apply(templateSpec)
+
+	if c.LameDuck != nil {
+		if err := c.LameDuck.apply(templateSpec, args); err != nil {
+			return err
+		}
+	}
+
+	if err := c.applyInitContainerResources(templateSpec); err != nil {
+		return err
+	}
+	if c.SidecarResources != nil {
+		resources, err := c.SidecarResources.toResourceRequirements("sidecarResources")
+		if err != nil {
+			return err
+		}
+		for i := range templateSpec.Containers[1:] {
+			templateSpec.Containers[i+1].Resources = *resources.DeepCopy()
+		}
+	}
+
+	if c.SchedulerName != nil {
+		templateSpec.SchedulerName = *c.SchedulerName
+	}
+
+	if len(c.DNSSearchDomains) > 0 {
+		if templateSpec.DNSConfig == nil {
+			templateSpec.DNSConfig = &corev1.PodDNSConfig{}
+		}
+		templateSpec.DNSConfig.Searches = appendUniqueStrings(templateSpec.DNSConfig.Searches, c.DNSSearchDomains...)
+	}
+
+	return nil
+}
+
+// applyInitContainerResources sets the resource requirements of every init
+// container of the pod spec, init containers added after apply need it again
+func (c *deploymentConfig) applyInitContainerResources(templateSpec *corev1.PodSpec) error {
+	if c.InitContainerResources == nil {
+		return nil
+	}
+	resources, err := c.InitContainerResources.toResourceRequirements("initContainerResources")
+	if err != nil {
+		return err
+	}
+	for i := range templateSpec.InitContainers {
+		templateSpec.InitContainers[i].Resources = *resources.DeepCopy()
+	}
+	return nil
+}
+
+// appendUniqueStrings appends the values that are not yet in the slice while
+// keeping their order
+func appendUniqueStrings(slice []string, values ...string) []string {
+	present := sets.NewString(slice...)
+	for _, value := range values {
+		if !present.Has(value) {
+			slice = append(slice, value)
+			present.Insert(value)
+		}
+	}
+	return slice
+}
+
+func (b *bootstrapUserRemovalConfig) validate() []error {
+	var errs []error
+
+	if _, err := time.Parse("15:04", b.MaintenanceWindow.Start); err != nil {
+		errs = append(errs, fmt.Errorf("bootstrapUserRemoval.maintenanceWindow.start must be a time of the day in the HH:MM format, got %q", b.MaintenanceWindow.Start))
+	}
+	if duration, err := time.ParseDuration(b.MaintenanceWindow.Duration); err != nil || duration <= 0 || duration > 24*time.Hour {
+		errs = append(errs, fmt.Errorf("bootstrapUserRemoval.maintenanceWindow.duration must be a positive duration of at most 24h, got %q", b.MaintenanceWindow.Duration))
+	}
+	if len(b.MaxDelay) > 0 {
+		if maxDelay, err := time.ParseDuration(b.MaxDelay); err != nil || maxDelay <= 0 {
+			errs = append(errs, fmt.Errorf("bootstrapUserRemoval.maxDelay must be a positive duration, got %q", b.MaxDelay))
+		}
+	}
+
+	return errs
+}
+
+func (b *bootstrapUserRemovalConfig) delay(removedAt, now time.Time) time.Duration {
+	maxDelay := defaultBootstrapUserRemovalMaxDelay
+	if len(b.MaxDelay) > 0 {
+		maxDelay, _ = time.ParseDuration(b.MaxDelay)
+	}
+	untilDeadline := removedAt.Add(maxDelay).Sub(now)
+	if untilDeadline <= 0 {
+		return 0
+	}
+
+	start, _ := time.Parse("15:04", b.MaintenanceWindow.Start)
+	duration, _ := time.ParseDuration(b.MaintenanceWindow.Duration)
+
+	now = now.UTC()
+	windowStart := time.Date(now.Year(), now.Month(), now.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+	// the window of the previous day may still be open past midnight
+	if now.Before(windowStart) {
+		windowStart = windowStart.AddDate(0, 0, -1)
+	}
+	if now.Before(windowStart.Add(duration)) {
+		return 0
+	}
+
+	if untilWindow := windowStart.AddDate(0, 0, 1).Sub(now); untilWindow < untilDeadline {
+		return untilWindow
+	}
+	return untilDeadline
+}
+
+func (s *sniCertificateConfig) validate(field string) []error {
+	var errs []error
+
+	if len(s.Secret.Name) == 0 {
+		errs = append(errs, fmt.Errorf("%s.secret.name must be set", field))
+	}
+	for _, name := range s.Names {
+		if validationErrs := validation.IsDNS1123Subdomain(strings.TrimPrefix(name, "*.")); len(validationErrs) > 0 {
+			errs = append(errs, fmt.Errorf("%s.names: %q is not a valid hostname: %s", field, name, strings.Join(validationErrs, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func (l *loginPageSnippetsConfig) validate() []error {
+	var errs []error
+
+	if l.Header == nil && l.Footer == nil {
+		errs = append(errs, fmt.Errorf("loginPageSnippets must set a header or a footer"))
+	}
+	if l.Header != nil && len(l.Header.Name) == 0 {
+		errs = append(errs, fmt.Errorf("loginPageSnippets.header.name must be set"))
+	}
+	if l.Footer != nil && len(l.Footer.Name) == 0 {
+		errs = append(errs, fmt.Errorf("loginPageSnippets.footer.name must be set"))
+	}
+
+	return errs
+}
+
+func (s *sessionStoreConfig) validate() []error {
+	var errs []error
+
+	if !supportedSessionStoreTypes.Has(s.Type) {
+		errs = append(errs, fmt.Errorf("sessionStore.type must be one of %v, got %q", supportedSessionStoreTypes.List(), s.Type))
+	}
+	if len(s.ConnectionSecret.Name) == 0 {
+		errs = append(errs, fmt.Errorf("sessionStore.connectionSecret.name must be set"))
+	}
+
+	return errs
+}
+
+func (v volumeModeConfig) validate(volumeName string) []error {
+	var errs []error
+
+	// only the admin-provided resources are synchronized
+	if !strings.HasPrefix(volumeName, "v4-0-config-user-") {
+		errs = append(errs, fmt.Errorf("volumeModes: %q is not the volume of an admin-provided resource", volumeName))
+	}
+	validateMode := func(field string, mode *int32) {
+		if mode != nil && (*mode < 0 || *mode > 0777) {
+			errs = append(errs, fmt.Errorf("volumeModes[%s].%s must be within 0000 and 0777, got %#o", volumeName, field, *mode))
+		}
+	}
+	validateMode("defaultMode", v.DefaultMode)
+	validateMode("itemMode", v.ItemMode)
+
+	return errs
+}
+
+func (a *auditSinkConfig) validate() []error {
+	var errs []error
+
+	endpoint, err := url.Parse(a.Endpoint)
+	switch {
+	case len(a.Endpoint) == 0:
+		errs = append(errs, fmt.Errorf("auditSink.endpoint must be set"))
+	case err != nil:
+		errs = append(errs, fmt.Errorf("auditSink.endpoint %q is not a valid URL: %v", a.Endpoint, err))
+	case (endpoint.Scheme != "https" && endpoint.Scheme != "http") || len(endpoint.Host) == 0:
+		errs = append(errs, fmt.Errorf("auditSink.endpoint %q must be an absolute http or https URL", a.Endpoint))
+	case endpoint.User != nil:
+		errs = append(errs, fmt.Errorf("auditSink.endpoint must not contain credentials, use auditSink.credentialsSecret instead"))
+	case a.CA != nil && endpoint.Scheme != "https":
+		errs = append(errs, fmt.Errorf("auditSink.ca can only be set for an https endpoint"))
+	}
+
+	if a.CredentialsSecret != nil && len(a.CredentialsSecret.Name) == 0 {
+		errs = append(errs, fmt.Errorf("auditSink.credentialsSecret.name must be set"))
+	}
+	if a.CA != nil && len(a.CA.Name) == 0 {
+		errs = append(errs, fmt.Errorf("auditSink.ca.name must be set"))
+	}
+
+	return errs
+}
+
+func (r *redirectURIAllowlistConfig) validate() []error {
+	var errs []error
+
+	if len(r.Global) == 0 && len(r.Clients) == 0 {
+		errs = append(errs, fmt.Errorf("redirectURIAllowlist must list at least one global or client redirect URI"))
+	}
+
+	validateURIs := func(field string, uris []string) {
+		for _, uri := range uris {
+			if err := validateRedirectURI(uri); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", field, err))
+			}
+		}
+	}
+	validateURIs("redirectURIAllowlist.global", r.Global)
+	for _, client := range sets.StringKeySet(r.Clients).List() {
+		if len(client) == 0 || strings.Contains(client, "=") {
+			errs = append(errs, fmt.Errorf("redirectURIAllowlist.clients: %q is not a valid OAuth client name", client))
+			continue
+		}
+		if len(r.Clients[client]) == 0 {
+			errs = append(errs, fmt.Errorf("redirectURIAllowlist.clients[%s] must list at least one redirect URI", client))
+		}
+		validateURIs(fmt.Sprintf("redirectURIAllowlist.clients[%s]", client), r.Clients[client])
+	}
+
+	return errs
+}
+
+// apply partitions the identity providers into the featured ones, in the
+// configured order, and the searchable rest, in the order oauth-server lists
+// them in. The featured names that match no provider are left out so that
+// removing a provider does not need the config to change first.
+func (s *identityProviderSelectionConfig) apply(args arguments.ServerArguments, idpNames []string) {
+	existing := sets.NewString(idpNames...)
+
+	var featured []string
+	for _, idpName := range appendUniqueStrings(nil, s.Featured...) {
+		if existing.Has(idpName) {
+			featured = append(featured, idpName)
+		}
+	}
+
+	featuredSet := sets.NewString(featured...)
+	var searchable []string
+	for _, idpName := range idpNames {
+		if !featuredSet.Has(idpName) {
+			searchable = append(searchable, idpName)
+		}
+	}
+
+	if len(featured) > 0 {
+		args["featured-identity-provider"] = featured
+	}
+	if len(searchable) > 0 {
+		args["searchable-identity-provider"] = searchable
+	}
+}
+
+// isValidScopeName checks the scope is a scope-token of RFC 6749 that can be
+// passed to oauth-server along with its step-up requirements, i.e. printable
+// ASCII without spaces, quotes, backslashes and "="
+func isValidScopeName(scope string) bool {
+	if len(scope) == 0 {
+		return false
+	}
+	for _, r := range scope {
+		if r <= ' ' || r > '~' || r == '"' || r == '\\' || r == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRedirectURI checks the URI is an absolute http or https URL without
+// a fragment as RFC 6749 requires
+func validateRedirectURI(uri string) error {
+	parsed, err := url.Parse(uri)
+	switch {
+	case err != nil:
+		return fmt.Errorf("%q is not a valid URL: %v", uri, err)
+	case (parsed.Scheme != "https" && parsed.Scheme != "http") || len(parsed.Host) == 0:
+		return fmt.Errorf("%q must be an absolute http or https URL", uri)
+	case len(parsed.Fragment) > 0 || strings.Contains(uri, "#"):
+		return fmt.Errorf("%q must not contain a fragment", uri)
+	case parsed.User != nil:
+		return fmt.Errorf("%q must not contain credentials", uri)
+	}
+	return nil
+}
+
+// validate checks the rate is positive and the burst does not go below it,
+// the errors are reported under the given field
+func (r rateLimitConfig) validate(field string) []error {
+	var errs []error
+
+	if r.QPS <= 0 {
+		errs = append(errs, fmt.Errorf("%s.qps must be a positive number, got %d", field, r.QPS))
+	}
+	if r.Burst != nil {
+		if *r.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("%s.burst must be a positive number, got %d", field, *r.Burst))
+		} else if *r.Burst < r.QPS {
+			errs = append(errs, fmt.Errorf("%s.burst %d must not be below %s.qps %d", field, *r.Burst, field, r.QPS))
+		}
+	}
+
+	return errs
+}
+
+func (r rateLimitConfig) burst() int32 {
+	if r.Burst == nil {
+		return r.QPS
+	}
+	return *r.Burst
+}
+
+func (p *passwordPolicyConfig) validate() []error {
+	var errs []error
+
+	if p.MinLength == nil && p.MaxLength == nil && len(p.RequiredCharacterClasses) == 0 && p.MinCharacterClasses == nil {
+		return []error{fmt.Errorf("htpasswdPasswordPolicy must set at least one rule")}
+	}
+
+	if p.MinLength != nil && *p.MinLength <= 0 {
+		errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.minLength must be a positive number, got %d", *p.MinLength))
+	}
+	if p.MaxLength != nil && *p.MaxLength <= 0 {
+		errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.maxLength must be a positive number, got %d", *p.MaxLength))
+	}
+	if p.MinLength != nil && p.MaxLength != nil && *p.MaxLength < *p.MinLength {
+		errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.maxLength %d must not be below htpasswdPasswordPolicy.minLength %d", *p.MaxLength, *p.MinLength))
+	}
+
+	for _, class := range p.RequiredCharacterClasses {
+		if !passwordCharacterClasses.Has(class) {
+			errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.requiredCharacterClasses: %q is not one of the character classes %v", class, passwordCharacterClasses.List()))
+		}
+	}
+	if p.MinCharacterClasses != nil && (*p.MinCharacterClasses <= 0 || *p.MinCharacterClasses > int32(passwordCharacterClasses.Len())) {
+		errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.minCharacterClasses must be between 1 and %d, got %d", passwordCharacterClasses.Len(), *p.MinCharacterClasses))
+	}
+
+	// no password could meet the policy otherwise
+	if p.MaxLength != nil {
+		if required := len(sets.NewString(p.RequiredCharacterClasses...)); int32(required) > *p.MaxLength {
+			errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.maxLength %d is too short to contain the %d required character classes", *p.MaxLength, required))
+		}
+		if p.MinCharacterClasses != nil && *p.MinCharacterClasses > *p.MaxLength {
+			errs = append(errs, fmt.Errorf("htpasswdPasswordPolicy.maxLength %d is too short to contain htpasswdPasswordPolicy.minCharacterClasses %d", *p.MaxLength, *p.MinCharacterClasses))
+		}
+	}
+
+	return errs
+}
+
+// apply renders the rules of the policy, the config is expected to be
+// validated
+func (p *passwordPolicyConfig) apply(args arguments.ServerArguments) {
+	if p.MinLength != nil {
+		args["htpasswd-password-min-length"] = []string{strconv.Itoa(int(*p.MinLength))}
+	}
+	if p.MaxLength != nil {
+		args["htpasswd-password-max-length"] = []string{strconv.Itoa(int(*p.MaxLength))}
+	}
+	if len(p.RequiredCharacterClasses) > 0 {
+		args["htpasswd-password-required-character-classes"] = []string{strings.Join(sets.NewString(p.RequiredCharacterClasses...).List(), ",")}
+	}
+	if p.MinCharacterClasses != nil {
+		args["htpasswd-password-min-character-classes"] = []string{strconv.Itoa(int(*p.MinCharacterClasses))}
+	}
+}
+
+// allowed returns the allowed schemes without duplicates, the config is
+// expected to be validated
+func (r *redirectSchemesConfig) allowed() []string {
+	if len(r.Allowed) == 0 {
+		return []string{defaultRedirectScheme}
+	}
+	return appendUniqueStrings(nil, r.Allowed...)
+}
+
+func (r *redirectURIAllowlistConfig) apply(args arguments.ServerArguments) {
+	if len(r.Global) > 0 {
+		args["redirect-uri-allowlist"] = appendUniqueStrings(nil, r.Global...)
+	}
+
+	var clientURIs []string
+	for _, client := range sets.StringKeySet(r.Clients).List() {
+		for _, uri := range appendUniqueStrings(nil, r.Clients[client]...) {
+			clientURIs = append(clientURIs, client+"="+uri)
+		}
+	}
+	if len(clientURIs) > 0 {
+		args["client-redirect-uri-allowlist"] = clientURIs
+	}
+}
+
+func (p *profilingConfig) port() int32 {
+	if p.Port == 0 {
+		return defaultProfilingPort
+	}
+	return p.Port
+}
+
+func (p *profilingConfig) validate() []error {
+	var errs []error
+
+	port := p.port()
+	if port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("profiling.port must be between 1 and 65535, got %d", port))
+	} else if int(port) == oauthServerPort.IntValue() {
+		// the serving port exposes the metrics as well
+		errs = append(errs, fmt.Errorf("profiling.port %d conflicts with the serving and metrics port of oauth-server", port))
+	}
+
+	return errs
+}
+
+func (s *sessionCookieConfig) validate() []error {
+	var errs []error
+
+	if len(s.Domain) > 0 {
+		if validationErrs := validation.IsDNS1123Subdomain(s.Domain); len(validationErrs) > 0 {
+			errs = append(errs, fmt.Errorf("sessionCookie.domain: %q is not a valid domain: %s", s.Domain, strings.Join(validationErrs, ", ")))
+		} else if !strings.Contains(s.Domain, ".") {
+			// the browsers would send the cookie to every host of the top-level domain
+			errs = append(errs, fmt.Errorf("sessionCookie.domain: %q must not be a top-level domain", s.Domain))
+		}
+	}
+
+	if len(s.Path) > 0 && (!path.IsAbs(s.Path) || path.Clean(s.Path) != s.Path || strings.ContainsAny(s.Path, "; \t")) {
+		errs = append(errs, fmt.Errorf("sessionCookie.path must be a clean absolute path, got %q", s.Path))
+	}
+
+	return errs
+}
+
+func (l *requestLoggingConfig) validate() []error {
+	var errs []error
+
+	if len(l.TrustedProxyCIDRs) > 0 && !l.ClientIP {
+		errs = append(errs, fmt.Errorf("requestLogging.trustedProxyCIDRs can only be set when the client IPs are logged"))
+	}
+	for _, cidr := range l.TrustedProxyCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("requestLogging.trustedProxyCIDRs: %q is not a valid CIDR: %v", cidr, err))
+		}
+	}
+
+	return errs
+}
+
+func (l *requestLoggingConfig) apply(args arguments.ServerArguments) {
+	if l.RequestID {
+		args["log-request-id"] = []string{"true"}
+	}
+	if l.ClientIP {
+		args["log-client-ip"] = []string{"true"}
+		if len(l.TrustedProxyCIDRs) > 0 {
+			args["trusted-proxy-cidrs"] = []string{strings.Join(l.TrustedProxyCIDRs, ",")}
+		}
+	}
+}
+
+// merge sets the configured requests and limits over the given ones, a
+// request may not end up above the limit of the same resource
+func (r *resourceRequirementsConfig) merge(resources *corev1.ResourceRequirements, field string) error {
+	overrides, err := r.toResourceRequirements(field)
+	if err != nil {
+		return err
+	}
+
+	for name, quantity := range overrides.Requests {
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = quantity
+	}
+	for name, quantity := range overrides.Limits {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[name] = quantity
+	}
+
+	// sort the names to report the errors in a stable order
+	names := make([]string, 0, len(resources.Requests))
+	for name := range resources.Requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		request := resources.Requests[corev1.ResourceName(name)]
+		if limit, ok := resources.Limits[corev1.ResourceName(name)]; ok && limit.Cmp(request) < 0 {
+			errs = append(errs, fmt.Errorf("%s: the %s request %s must not be above its limit %s", field, name, request.String(), limit.String()))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (r *resourceRequirementsConfig) toResourceRequirements(field string) (*corev1.ResourceRequirements, error) {
+	var errs []error
+
+	parse := func(quantities map[corev1.ResourceName]string, kind string) corev1.ResourceList {
+		if len(quantities) == 0 {
+			return nil
+		}
+		// sort the names to report the errors in a stable order
+		names := make([]string, 0, len(quantities))
+		for name := range quantities {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+
+		resourceList := corev1.ResourceList{}
+		for _, name := range names {
+			value := quantities[corev1.ResourceName(name)]
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s.%s: %q is not a valid quantity: %v", field, kind, name, value, err))
+				continue
+			}
+			resourceList[corev1.ResourceName(name)] = quantity
+		}
+		return resourceList
+	}
+
+	resources := &corev1.ResourceRequirements{
+		Requests: parse(r.Requests, "requests"),
+		Limits:   parse(r.Limits, "limits"),
+	}
+	if err := errors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (t *serviceAccountTokenConfig) validate() []error {
+	var errs []error
+
+	if len(t.Audience) == 0 {
+		errs = append(errs, fmt.Errorf("serviceAccountToken.audience must be set"))
+	}
+
+	if t.ExpirationSeconds != nil && *t.ExpirationSeconds < minServiceAccountTokenExpirationSeconds {
+		errs = append(errs, fmt.Errorf("serviceAccountToken.expirationSeconds must be at least %d, got %d", minServiceAccountTokenExpirationSeconds, *t.ExpirationSeconds))
+	}
+
+	return errs
+}
+
+func (t *serviceAccountTokenConfig) apply(templateSpec *corev1.PodSpec, container *corev1.Container) {
+	templateSpec.Volumes = append(templateSpec.Volumes, corev1.Volume{
+		Name: serviceAccountTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+						Audience:          t.Audience,
+						ExpirationSeconds: t.ExpirationSeconds,
+						Path:              serviceAccountTokenPath,
+					},
+				}},
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      serviceAccountTokenVolumeName,
+		ReadOnly:  true,
+		MountPath: serviceAccountTokenMountPath,
+	})
+}
+
+func (e *ephemeralStorageConfig) validate() []error {
+	var errs []error
+
+	parse := func(field, value string) *resource.Quantity {
+		if len(value) == 0 {
+			return nil
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil || quantity.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("ephemeralStorage.%s must be a positive quantity, got %q", field, value))
+			return nil
+		}
+		return &quantity
+	}
+
+	request, limit := parse("request", e.Request), parse("limit", e.Limit)
+	if request != nil && limit != nil && limit.Cmp(*request) < 0 {
+		errs = append(errs, fmt.Errorf("ephemeralStorage.limit %q must not be lower than ephemeralStorage.request %q", e.Limit, e.Request))
+	}
+
+	return errs
+}
+
+// apply sets the ephemeral-storage resources of the container, the config is
+// expected to be validated
+func (e *ephemeralStorageConfig) apply(container *corev1.Container) {
+	if len(e.Request) > 0 {
+		if container.Resources.Requests == nil {
+			container.Resources.Requests = corev1.ResourceList{}
+		}
+		container.Resources.Requests[corev1.ResourceEphemeralStorage] = resource.MustParse(e.Request)
+	}
+	if len(e.Limit) > 0 {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceEphemeralStorage] = resource.MustParse(e.Limit)
+	}
+}
+
+func (o *podOverheadConfig) validate() []error {
+	var errs []error
+
+	if validationErrs := validation.IsDNS1123Subdomain(o.RuntimeClassName); len(validationErrs) > 0 {
+		errs = append(errs, fmt.Errorf("podOverhead.runtimeClassName: %q is not a valid runtime class name: %s", o.RuntimeClassName, strings.Join(validationErrs, ", ")))
+	}
+
+	if len(o.Overhead) == 0 {
+		errs = append(errs, fmt.Errorf("podOverhead.overhead must list at least one resource"))
+	}
+	names := make([]string, 0, len(o.Overhead))
+	for name := range o.Overhead {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := o.Overhead[corev1.ResourceName(name)]
+		if quantity, err := resource.ParseQuantity(value); err != nil || quantity.Sign() < 0 {
+			errs = append(errs, fmt.Errorf("podOverhead.overhead.%s must be a non-negative quantity, got %q", name, value))
+		}
+	}
+
+	return errs
+}
+
+// apply sets the runtime class and the overhead of the pods, the config is
+// expected to be validated
+func (o *podOverheadConfig) apply(templateSpec *corev1.PodSpec) {
+	runtimeClassName := o.RuntimeClassName
+	templateSpec.RuntimeClassName = &runtimeClassName
+	templateSpec.Overhead = corev1.ResourceList{}
+	for name, value := range o.Overhead {
+		templateSpec.Overhead[name] = resource.MustParse(value)
+	}
+}
+
+func (s *schedulingConfig) validate() []error {
+	var errs []error
+
+	if s.NodeSelector != nil && len(s.NodeSelector) == 0 {
+		errs = append(errs, fmt.Errorf("scheduling.nodeSelector must not be empty, the pods would be scheduled to any node"))
+	}
+	for _, key := range sets.StringKeySet(s.NodeSelector).List() {
+		if validationErrs := validation.IsQualifiedName(key); len(validationErrs) > 0 {
+			errs = append(errs, fmt.Errorf("scheduling.nodeSelector: %q is not a valid label key: %s", key, strings.Join(validationErrs, ", ")))
+		}
+		if validationErrs := validation.IsValidLabelValue(s.NodeSelector[key]); len(validationErrs) > 0 {
+			errs = append(errs, fmt.Errorf("scheduling.nodeSelector[%s]: %q is not a valid label value: %s", key, s.NodeSelector[key], strings.Join(validationErrs, ", ")))
+		}
+	}
+
+	for i, toleration := range s.Tolerations {
+		field := fmt.Sprintf("scheduling.tolerations[%d]", i)
+		if len(toleration.Key) > 0 {
+			if validationErrs := validation.IsQualifiedName(toleration.Key); len(validationErrs) > 0 {
+				errs = append(errs, fmt.Errorf("%s.key: %q is not a valid taint key: %s", field, toleration.Key, strings.Join(validationErrs, ", ")))
+			}
+		}
+		switch toleration.Operator {
+		case corev1.TolerationOpExists:
+			if len(toleration.Value) > 0 {
+				errs = append(errs, fmt.Errorf("%s.value must be empty with the %q operator", field, toleration.Operator))
+			}
+		case "", corev1.TolerationOpEqual:
+			if len(toleration.Key) == 0 {
+				errs = append(errs, fmt.Errorf("%s.key must be set with the %q operator", field, corev1.TolerationOpEqual))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s.operator must be either %q or %q, got %q", field, corev1.TolerationOpExists, corev1.TolerationOpEqual, toleration.Operator))
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, fmt.Errorf("%s.effect must be one of %q, %q or %q, got %q", field, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute, toleration.Effect))
+		}
+		if toleration.TolerationSeconds != nil && toleration.Effect != corev1.TaintEffectNoExecute {
+			errs = append(errs, fmt.Errorf("%s.tolerationSeconds must only be set with the %q effect", field, corev1.TaintEffectNoExecute))
+		}
+	}
+
+	return errs
+}
+
+// apply replaces the node selector and adds the tolerations of the pods, the
+// config is expected to be validated
+func (s *schedulingConfig) apply(templateSpec *corev1.PodSpec) {
+	if len(s.NodeSelector) > 0 {
+		templateSpec.NodeSelector = map[string]string{}
+		for key, value := range s.NodeSelector {
+			templateSpec.NodeSelector[key] = value
+		}
+	}
+	templateSpec.Tolerations = append(templateSpec.Tolerations, s.Tolerations...)
+}
+
+// apply sets the seccomp profile of the pods, RuntimeDefault unless a localhost
+// profile is configured. The config is expected to be validated.
+func (s *seccompProfileConfig) apply(templateSpec *corev1.PodSpec) {
+	seccompProfile := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	if s != nil {
+		localhostProfile := s.LocalhostProfile
+		seccompProfile = &corev1.SeccompProfile{
+			Type:             corev1.SeccompProfileTypeLocalhost,
+			LocalhostProfile: &localhostProfile,
+		}
+	}
+
+	if templateSpec.SecurityContext == nil {
+		templateSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	templateSpec.SecurityContext.SeccompProfile = seccompProfile
+}
+
+// apply replaces the preStop delay of the oauth-server container with the lame
+// duck period. The hook creates the file that makes oauth-server fail its
+// readiness checks and keeps the container running until the period passes,
+// the period must cover all the failed probes it takes the readiness probe to
+// fail and end within the termination grace period so that oauth-server still
+// gets to shut down.
+func (l *lameDuckConfig) apply(templateSpec *corev1.PodSpec, args arguments.ServerArguments) error {
+	container := &templateSpec.Containers[0]
+	duration, _ := time.ParseDuration(l.Duration)
+	seconds := int64(math.Ceil(duration.Seconds()))
+
+	readinessProbe := container.ReadinessProbe
+	if readinessProbe == nil {
+		return fmt.Errorf("lameDuck requires the readiness probe of the oauth-server container")
+	}
+	if container.Lifecycle == nil {
+		return fmt.Errorf("lameDuck requires the lifecycle hooks of the oauth-server container")
+	}
+	// the probe is left alone, only the lame duck file makes it fail for good
+	if failureWindow := int64(readinessProbe.PeriodSeconds * readinessProbe.FailureThreshold); seconds < failureWindow {
+		return fmt.Errorf("lameDuck.duration %q is shorter than the %ds it takes the readiness probe to fail", l.Duration, failureWindow)
+	}
+	if gracePeriod := templateSpec.TerminationGracePeriodSeconds; gracePeriod != nil && seconds >= *gracePeriod {
+		return fmt.Errorf("lameDuck.duration %q must be shorter than the termination grace period of %ds", l.Duration, *gracePeriod)
+	}
+
+	container.Lifecycle.PreStop = &corev1.LifecycleHandler{
+		Exec: &corev1.ExecAction{
+			Command: []string{"/bin/bash", "-c", fmt.Sprintf("touch %s && sleep %d", lameDuckFile, seconds)},
+		},
+	}
+	args["lame-duck-file"] = []string{lameDuckFile}
+
+	templateSpec.Volumes = append(templateSpec.Volumes, corev1.Volume{
+		Name:         "lame-duck",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "lame-duck",
+		MountPath: lameDuckDir,
+	})
+
+	return nil
+}
+
+func (t *terminationMessageConfig) validate() []error {
+	var errs []error
+
+	switch t.Policy {
+	case "", corev1.TerminationMessageReadFile, corev1.TerminationMessageFallbackToLogsOnError:
+	default:
+		errs = append(errs, fmt.Errorf("terminationMessage.policy must be one of %q or %q, got %q", corev1.TerminationMessageReadFile, corev1.TerminationMessageFallbackToLogsOnError, t.Policy))
+	}
+
+	if len(t.Path) > 0 && !path.IsAbs(t.Path) {
+		errs = append(errs, fmt.Errorf("terminationMessage.path must be an absolute path, got %q", t.Path))
+	}
+
+	return errs
+}
+
+func (h *http2Config) validate() []error {
+	var errs []error
+
+	if h.MaxStreamsPerConnection != nil {
+		if h.Disabled {
+			errs = append(errs, fmt.Errorf("http2.maxStreamsPerConnection cannot be set when HTTP/2 is disabled"))
+		} else if *h.MaxStreamsPerConnection <= 0 {
+			errs = append(errs, fmt.Errorf("http2.maxStreamsPerConnection must be a positive number, got %d", *h.MaxStreamsPerConnection))
+		}
+	}
+
+	return errs
+}
+
+func (h *http2Config) apply(container *corev1.Container, args arguments.ServerArguments) {
+	if h.Disabled {
+		// the go runtime stops negotiating h2 over ALPN with this setting
+		container.Env = appendEnvVar(container.Env, "GODEBUG", "http2server=0")
+		return
+	}
+
+	if h.MaxStreamsPerConnection != nil {
+		args["http2-max-streams-per-connection"] = []string{strconv.Itoa(int(*h.MaxStreamsPerConnection))}
+	}
+}
+
+func (k *kubeAPIClientConfig) validate() []error {
+	var errs []error
+
+	if k.QPS != nil && *k.QPS <= 0 {
+		errs = append(errs, fmt.Errorf("kubeAPIClient.qps must be a positive number, got %d", *k.QPS))
+	}
+	if k.Burst != nil && *k.Burst <= 0 {
+		errs = append(errs, fmt.Errorf("kubeAPIClient.burst must be a positive number, got %d", *k.Burst))
+	}
+	if k.MaxIdleConnections != nil && *k.MaxIdleConnections <= 0 {
+		errs = append(errs, fmt.Errorf("kubeAPIClient.maxIdleConnections must be a positive number, got %d", *k.MaxIdleConnections))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// the burst would never be reached otherwise
+	if qps, burst := k.qps(), k.burst(); burst < qps {
+		errs = append(errs, fmt.Errorf("kubeAPIClient.burst %d must not be below kubeAPIClient.qps %d", burst, qps))
+	}
+
+	return errs
+}
+
+// apply renders the client limits, the defaults are rendered when the config
+// is nil
+func (k *kubeAPIClientConfig) apply(args arguments.ServerArguments) {
+	args["kube-api-qps"] = []string{strconv.Itoa(int(k.qps()))}
+	args["kube-api-burst"] = []string{strconv.Itoa(int(k.burst()))}
+	args["kube-api-max-idle-connections"] = []string{strconv.Itoa(int(k.maxIdleConnections()))}
+}
+
+func (k *kubeAPIClientConfig) qps() int32 {
+	if k == nil || k.QPS == nil {
+		return defaultKubeAPIQPS
+	}
+	return *k.QPS
+}
+
+func (k *kubeAPIClientConfig) burst() int32 {
+	if k == nil || k.Burst == nil {
+		return defaultKubeAPIBurst
+	}
+	return *k.Burst
+}
+
+func (k *kubeAPIClientConfig) maxIdleConnections() int32 {
+	if k == nil || k.MaxIdleConnections == nil {
+		return defaultKubeAPIMaxIdleConnections
+	}
+	return *k.MaxIdleConnections
+}
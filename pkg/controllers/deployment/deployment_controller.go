@@ -5,20 +5,26 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 	configinformer "github.com/openshift/client-go/config/informers/externalversions"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
@@ -31,9 +37,14 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
 	"github.com/openshift/library-go/pkg/operator/status"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"github.com/openshift/library-go/pkg/route/routeapihelpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
 )
 
 var _ workload.Delegate = &oauthServerDeploymentSyncer{}
@@ -54,17 +65,58 @@ type oauthServerDeploymentSyncer struct {
 	// one pod of a given replicaset from landing on a node.
 	ensureAtMostOnePodPerNode ensureAtMostOnePodPerNodeFunc
 
-	deployments appsv1client.DeploymentsGetter
-	auth        operatorv1client.AuthenticationsGetter
+	deployments     appsv1client.DeploymentsGetter
+	configMaps      corev1client.ConfigMapsGetter
+	networkPolicies networkingv1client.NetworkPoliciesGetter
+	auth            operatorv1client.AuthenticationsGetter
 
 	configMapLister corev1listers.ConfigMapLister
 	secretLister    corev1listers.SecretLister
 	podsLister      corev1listers.PodLister
 	proxyLister     configv1listers.ProxyLister
+	oauthLister     configv1listers.OAuthLister
 	routeLister     routev1listers.RouteLister
 
+	// listers for the admin-provided resources in the openshift-config namespace
+	configNSConfigMapLister corev1listers.ConfigMapLister
+	configNSSecretLister    corev1listers.SecretLister
+
+	// resourceSyncer synchronizes the admin-provided resources referenced by
+	// the deployment config to the target namespace
+	resourceSyncer resourcesynccontroller.ResourceSyncer
+	// syncedUserData is the user sync data from the last successful sync,
+	// it is read from the deployment by the first sync
+	syncedUserData *datasync.ConfigSyncData
+
 	bootstrapUserDataGetter    bootstrap.BootstrapUserDataGetter
 	bootstrapUserChangeRollOut bool
+	// bootstrapUserRemovalTime is when the syncer first noticed the bootstrap
+	// user is gone, the rollout that follows can be deferred from then on
+	bootstrapUserRemovalTime time.Time
+
+	// configValidator validates the oauth-server config before a rollout
+	// when requested in the deployment config
+	configValidator configValidator
+
+	// imagePullChecker reports the oauth-server image the pods keep failing
+	// to pull
+	imagePullChecker imagePullChecker
+
+	clock clock.PassiveClock
+	// pendingRolloutHash is the rvs-hash of the last change that is yet to be
+	// rolled out and pendingRolloutChangeTime is when this syncer first saw it,
+	// the rollout waits until no other change came for the cooldown
+	pendingRolloutHash       string
+	pendingRolloutChangeTime time.Time
+
+	// announcedReadyHash is the rvs-hash of the deployment this syncer last
+	// announced as ready, the summary event is emitted again only once a
+	// deployment of another hash becomes ready or the operator restarts
+	announcedReadyHash string
+
+	// networkPolicyEnabled is the NetworkPolicy setting of the last sync, the
+	// policy gets removed once after it is disabled or the operator restarts
+	networkPolicyEnabled *bool
 }
 
 func NewOAuthServerWorkloadController(
@@ -81,6 +133,8 @@ func NewOAuthServerWorkloadController(
 	eventsRecorder events.Recorder,
 	versionRecorder status.VersionGetter,
 	kubeInformersForTargetNamespace informers.SharedInformerFactory,
+	kubeInformersForConfigNamespace informers.SharedInformerFactory,
+	resourceSyncer resourcesynccontroller.ResourceSyncer,
 ) factory.Controller {
 	targetNS := "openshift-authentication"
 
@@ -90,16 +144,38 @@ func NewOAuthServerWorkloadController(
 		countNodes:                countNodes,
 		ensureAtMostOnePodPerNode: ensureAtMostOnePodPerNode,
 
-		deployments: kubeClient.AppsV1(),
-		auth:        authOperatorGetter,
+		deployments:     kubeClient.AppsV1(),
+		configMaps:      kubeClient.CoreV1(),
+		networkPolicies: kubeClient.NetworkingV1(),
+		auth:            authOperatorGetter,
 
 		configMapLister: kubeInformersForTargetNamespace.Core().V1().ConfigMaps().Lister(),
 		secretLister:    kubeInformersForTargetNamespace.Core().V1().Secrets().Lister(),
 		podsLister:      kubeInformersForTargetNamespace.Core().V1().Pods().Lister(),
 		proxyLister:     configInformers.Config().V1().Proxies().Lister(),
+		oauthLister:     configInformers.Config().V1().OAuths().Lister(),
 		routeLister:     routeInformersForTargetNamespace.Route().V1().Routes().Lister(),
 
+		configNSConfigMapLister: kubeInformersForConfigNamespace.Core().V1().ConfigMaps().Lister(),
+		configNSSecretLister:    kubeInformersForConfigNamespace.Core().V1().Secrets().Lister(),
+
+		resourceSyncer: resourceSyncer,
+
 		bootstrapUserDataGetter: bootstrapUserDataGetter,
+
+		configValidator: &podConfigValidator{
+			pods:       kubeClient.CoreV1(),
+			podsLister: kubeInformersForTargetNamespace.Core().V1().Pods().Lister(),
+			clock:      clock.RealClock{},
+		},
+
+		imagePullChecker: &mirrorImagePullChecker{
+			imageDigestMirrorSetLister: configInformers.Config().V1().ImageDigestMirrorSets().Lister(),
+			podsLister:                 kubeInformersForTargetNamespace.Core().V1().Pods().Lister(),
+			clock:                      clock.RealClock{},
+		},
+
+		clock: clock.RealClock{},
 	}
 
 	if userExists, err := oauthDeploymentSyncer.bootstrapUserDataGetter.IsEnabled(); err != nil {
@@ -109,6 +185,20 @@ func NewOAuthServerWorkloadController(
 		oauthDeploymentSyncer.bootstrapUserChangeRollOut = userExists
 	}
 
+	watch := &watchInformers{
+		nodeInformer:                     nodeInformer,
+		configInformers:                  configInformers,
+		routeInformersForTargetNamespace: routeInformersForTargetNamespace,
+		kubeInformersForTargetNamespace:  kubeInformersForTargetNamespace,
+		kubeInformersForConfigNamespace:  kubeInformersForConfigNamespace,
+	}
+	// the namespaced informers watch all the resources of their namespace so
+	// the static dependencies already cover any synchronized resource
+	clusterInformers, targetNSInformers, err := watch.informersFor(staticWatchedResources())
+	if err != nil {
+		panic(err)
+	}
+
 	return workload.NewController(
 		"OAuthServer",
 		"cluster-authentication-operator",
@@ -119,19 +209,8 @@ func NewOAuthServerWorkloadController(
 		operatorClient,
 		kubeClient,
 		kubeInformersForTargetNamespace.Core().V1().Pods().Lister(),
-		[]factory.Informer{
-			configInformers.Config().V1().Ingresses().Informer(),
-			configInformers.Config().V1().Proxies().Informer(),
-			nodeInformer.Informer(),
-		},
-		[]factory.Informer{
-			kubeInformersForTargetNamespace.Apps().V1().Deployments().Informer(),
-			kubeInformersForTargetNamespace.Core().V1().ConfigMaps().Informer(),
-			kubeInformersForTargetNamespace.Core().V1().Secrets().Informer(),
-			kubeInformersForTargetNamespace.Core().V1().Pods().Informer(),
-			kubeInformersForTargetNamespace.Core().V1().Namespaces().Informer(),
-			routeInformersForTargetNamespace.Route().V1().Routes().Informer(),
-		},
+		clusterInformers,
+		targetNSInformers,
 		oauthDeploymentSyncer,
 		openshiftClusterConfigClient,
 		eventsRecorder,
@@ -165,41 +244,117 @@ func (c *oauthServerDeploymentSyncer) Sync(ctx context.Context, syncContext fact
 		return nil, false, append(errs, err)
 	}
 
-	// resourceVersions serves to store versions of config resources so that we
-	// can redeploy our payload should either change. We only omit the operator
-	// config version, it would both cause redeploy loops (status updates cause
-	// version change) and the relevant changes (logLevel, unsupportedConfigOverrides)
-	// will cause a redeploy anyway
+	deploymentConfig, err := getDeploymentConfig(operatorConfig)
+	if err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	if err := c.syncDebugPauseCondition(ctx, deploymentConfig.DebugPause); err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	// report the missing key before the generic validation below refuses to
+	// roll out the deployment without it
+	if err := c.syncTokenEncryptionKey(ctx, deploymentConfig.TokenEncryption); err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	// the synced copies of the admin-provided resources are tracked in the
+	// content hashes below, make sure they are valid and get synced
+	userSyncData, _ := deploymentConfig.userSyncData()
+	if syncDataErrs := userSyncData.Validate(c.configNSConfigMapLister, c.configNSSecretLister); len(syncDataErrs) > 0 {
+		return nil, false, append(errs, syncDataErrs...)
+	}
+	if c.syncedUserData == nil {
+		// the copies synced before the operator restarted are recorded on
+		// the deployment
+		if c.syncedUserData, err = c.recordedSyncedUserData(ctx); err != nil {
+			return nil, false, append(errs, err)
+		}
+	}
+	datasync.HandleIdPConfigSync(c.resourceSyncer, c.syncedUserData, userSyncData)
+	c.syncedUserData = userSyncData
+
+	caBundles, err := c.syncIDPCABundles(ctx, syncContext, operatorConfig, deploymentConfig.caRotationGracePeriod())
+	if err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	if err := c.syncIdentityProviderList(ctx, syncContext.Recorder(), operatorConfig); err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	if err := c.syncIdentityProviderDeprecations(ctx, operatorConfig); err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	// contentHashes serves to store the hashes of the content of the config
+	// resources so that we can redeploy our payload should either change. The
+	// resourceVersions are not used, they change with the no-op updates and the
+	// metadata-only updates of the resources too, which would roll out the
+	// deployment for nothing. We only omit the operator config, it would cause
+	// redeploy loops (status updates) and the relevant changes (logLevel,
+	// unsupportedConfigOverrides) will cause a redeploy anyway
 	// TODO move this hash from deployment meta to operatorConfig.status.generations.[...].hash
-	resourceVersions := []string{}
+	contentHashes := []string{}
 
 	if len(proxyConfig.Name) > 0 {
-		resourceVersions = append(resourceVersions, "proxy:"+proxyConfig.Name+":"+proxyConfig.ResourceVersion)
+		proxyHash, err := proxyContentHash(proxyConfig)
+		if err != nil {
+			return nil, false, append(errs, fmt.Errorf("unable to hash the proxy config: %w", err))
+		}
+		contentHashes = append(contentHashes, "proxy:"+proxyConfig.Name+":"+proxyHash)
 	}
 
-	configResourceVersions, err := c.getConfigResourceVersions()
+	reloadOnly, err := c.getReloadOnlyResources(operatorConfig, userSyncData)
 	if err != nil {
 		return nil, false, append(errs, err)
 	}
 
-	resourceVersions = append(resourceVersions, configResourceVersions...)
+	configContentHashes, err := c.getConfigContentHashes(reloadOnly)
+	if err != nil {
+		return nil, false, append(errs, err)
+	}
+
+	contentHashes = append(contentHashes, configContentHashes...)
 
 	// Determine whether the bootstrap user has been deleted so that
 	// detail can be used in computing the deployment.
 	if c.bootstrapUserChangeRollOut {
 		if userExists, err := c.bootstrapUserDataGetter.IsEnabled(); err != nil {
 			klog.Warningf("unable to determine the state of bootstrap user: %v", err)
-		} else {
-			c.bootstrapUserChangeRollOut = userExists
+		} else if !userExists {
+			if c.bootstrapUserRemovalTime.IsZero() {
+				c.bootstrapUserRemovalTime = c.clock.Now()
+			}
+			// keep the annotation so that the removal does not roll out yet
+			if wait := deploymentConfig.bootstrapUserRemovalDelay(c.bootstrapUserRemovalTime, c.clock.Now()); wait > 0 {
+				klog.V(4).Infof("deferring the rollout that follows the bootstrap user removal for %v", wait)
+				syncContext.Queue().AddAfter(syncContext.QueueKey(), wait)
+			} else {
+				c.bootstrapUserChangeRollOut = false
+			}
 		}
 	}
 
 	// deployment, have RV of all resources
-	expectedDeployment, err := getOAuthServerDeployment(operatorConfig, proxyConfig, c.bootstrapUserChangeRollOut, resourceVersions...)
+	expectedDeployment, err := getOAuthServerDeployment(operatorConfig, proxyConfig, c.bootstrapUserChangeRollOut, contentHashes...)
 	if err != nil {
 		return nil, false, append(errs, err)
 	}
 
+	if err := c.checkInvalidIdentityProviders(operatorConfig, deploymentConfig.InvalidIdentityProvidersPolicy); err != nil {
+		// keep the current deployment running its last valid identity providers
+		return c.getCurrentDeployment(ctx, expectedDeployment, append(errs, err))
+	}
+
+	// the image stays the payload one, the runtime falls back to its mirrors
+	if err := c.imagePullChecker.Check(expectedDeployment.Spec.Template.Spec.Containers[0].Image); err != nil {
+		errs = append(errs, err)
+	}
+
+	useCABundles(&expectedDeployment.Spec.Template.Spec, caBundles)
+
 	if _, err := c.secretLister.Secrets("openshift-authentication").Get("v4-0-config-system-custom-router-certs"); err == nil {
 		expectedDeployment.Spec.Template.Spec.Volumes = append(expectedDeployment.Spec.Template.Spec.Volumes, corev1.Volume{
 			Name: "v4-0-config-system-custom-router-certs",
@@ -216,10 +371,37 @@ func (c *oauthServerDeploymentSyncer) Sync(ctx context.Context, syncContext fact
 		})
 	}
 
+	// the check has to see the final volumes of the pod template
+	if deploymentConfig.ConfigChecksumCheck {
+		if err := c.addConfigChecksumCheck(&expectedDeployment.Spec.Template.Spec, expectedDeployment.Spec.Template.Annotations[deploymentVersionHashKey], reloadOnly); err != nil {
+			return nil, false, append(errs, fmt.Errorf("unable to add the config checksum check: %w", err))
+		}
+		if err := deploymentConfig.applyInitContainerResources(&expectedDeployment.Spec.Template.Spec); err != nil {
+			return nil, false, append(errs, err)
+		}
+	}
+	// the pods of a deployment whose rollout is held back below start with
+	// the config that already moved on
+	if err := c.syncPendingRollout(ctx, syncContext.Recorder(), expectedDeployment.Spec.Template.Annotations[deploymentVersionHashKey], deploymentConfig.ConfigChecksumCheck); err != nil {
+		return nil, false, append(errs, fmt.Errorf("unable to record the pending rollout: %w", err))
+	}
+
+	spreadingAffinity := expectedDeployment.Spec.Template.Spec.Affinity
 	err = c.ensureAtMostOnePodPerNode(&expectedDeployment.Spec, "oauth-openshift")
 	if err != nil {
 		return nil, false, append(errs, fmt.Errorf("unable to ensure at most one pod per node: %v", err))
 	}
+	// the required anti-affinity replaces the affinity of the pod template,
+	// keep preferring distinct zones on top of it
+	if affinity := expectedDeployment.Spec.Template.Spec.Affinity; affinity != spreadingAffinity && affinity != nil && spreadingAffinity != nil && spreadingAffinity.PodAntiAffinity != nil {
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			spreadingAffinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...,
+		)
+	}
 
 	// Set the replica count to the number of master nodes.
 	masterNodeCount, err := c.countNodes(expectedDeployment.Spec.Template.Spec.NodeSelector)
@@ -227,6 +409,71 @@ func (c *oauthServerDeploymentSyncer) Sync(ctx context.Context, syncContext fact
 		return nil, false, append(errs, fmt.Errorf("failed to determine number of master nodes: %v", err))
 	}
 	expectedDeployment.Spec.Replicas = masterNodeCount
+	if replicas := deploymentConfig.Replicas; replicas != nil {
+		// the pods do not share nodes, the extra ones would never get scheduled
+		if masterNodeCount != nil && *replicas > *masterNodeCount {
+			return c.getCurrentDeployment(ctx, expectedDeployment, append(errs, fmt.Errorf("replicas %d exceeds the %d nodes the oauth-server pods may be scheduled to", *replicas, *masterNodeCount)))
+		}
+		expectedDeployment.Spec.Replicas = replicas
+	}
+
+	if deploymentConfig.ValidateConfig {
+		validated, err := c.configValidator.Validate(ctx, expectedDeployment)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if err == nil && !validated {
+			// the pod that is stuck does not necessarily get updated, check
+			// the validation once it is due to time out
+			syncContext.Queue().AddAfter(syncContext.QueueKey(), configValidationTimeout)
+		}
+		if err != nil || !validated {
+			// keep the current deployment running until the new config is known to be valid
+			return c.getCurrentDeployment(ctx, expectedDeployment, errs)
+		}
+	}
+
+	currentDeployment, err := c.deployments.Deployments(expectedDeployment.Namespace).Get(ctx, expectedDeployment.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, false, append(errs, err)
+	}
+	rollout := err == nil && currentDeployment.Spec.Template.Annotations[deploymentVersionHashKey] != expectedDeployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	var lastRecordedRollout time.Time
+	if err == nil {
+		lastRecordedRollout = recordedRolloutTime(currentDeployment)
+	}
+	if rollout {
+		// batch the changes that come in a burst, every change restarts the
+		// cooldown and the sync after it passes rolls out the latest config
+		if expectedHash := expectedDeployment.Spec.Template.Annotations[deploymentVersionHashKey]; expectedHash != c.pendingRolloutHash {
+			c.pendingRolloutHash = expectedHash
+			c.pendingRolloutChangeTime = c.clock.Now()
+		}
+		if wait := c.pendingRolloutChangeTime.Add(deploymentConfig.rolloutCooldown()).Sub(c.clock.Now()); wait > 0 {
+			klog.V(4).Infof("holding back the oauth-server rollout for %v", wait)
+			syncContext.Queue().AddAfter(syncContext.QueueKey(), wait)
+			return currentDeployment, false, errs
+		}
+
+		if interval := deploymentConfig.minRolloutInterval(); interval > 0 && !lastRecordedRollout.IsZero() {
+			if wait := lastRecordedRollout.Add(interval).Sub(c.clock.Now()); wait > 0 {
+				klog.Infof("holding back the oauth-server rollout for %v to keep the minimum interval of %v between the rollouts", wait, interval)
+				syncContext.Queue().AddAfter(syncContext.QueueKey(), wait)
+				return currentDeployment, false, errs
+			}
+		}
+	}
+	if rollout || errors.IsNotFound(err) {
+		lastRecordedRollout = c.clock.Now()
+	}
+	recordRolloutTime(expectedDeployment, lastRecordedRollout)
+	var appliedDeployment *appsv1.Deployment
+	if err == nil {
+		appliedDeployment = currentDeployment
+	}
+	if err := recordSyncedUserData(expectedDeployment, appliedDeployment, userSyncData); err != nil {
+		return nil, false, append(errs, err)
+	}
 
 	deployment, _, err := resourceapply.ApplyDeployment(ctx, c.deployments,
 		syncContext.Recorder(),
@@ -236,10 +483,97 @@ func (c *oauthServerDeploymentSyncer) Sync(ctx context.Context, syncContext fact
 	if err != nil {
 		return nil, false, append(errs, fmt.Errorf("applying deployment of the integrated OAuth server failed: %w", err))
 	}
+	recordAppliedDeployment(deployment.Spec.Template.Annotations[deploymentVersionHashKey])
+	if rollout {
+		c.pendingRolloutHash = ""
+		recordRollout(rolloutReason(currentDeployment, expectedDeployment))
+	}
+
+	if err := c.announceReadyDeployment(syncContext.Recorder(), operatorConfig, deployment); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.syncNetworkPolicy(ctx, operatorConfig, proxyConfig, deploymentConfig.NetworkPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("unable to reconcile the oauth-server NetworkPolicy: %w", err))
+	}
 
+	if len(errs) == 0 {
+		recordSuccessfulSync(c.clock.Now())
+	}
 	return deployment, true, errs
 }
 
+// syncIDPCABundles keeps the bundles of the identity provider CAs and makes
+// the sync happen again once the nearest CA rotation grace period passes
+func (c *oauthServerDeploymentSyncer) syncIDPCABundles(ctx context.Context, syncContext factory.SyncContext, operatorConfig *operatorv1.Authentication, grace time.Duration) (map[string]string, error) {
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	idpSyncData, err := getSyncDataFromOperatorConfig(observedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get IDP sync data: %v", err)
+	}
+
+	bundles, requeue, err := c.syncCABundles(ctx, syncContext.Recorder(), idpSyncData, grace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sync the identity provider CA bundles: %w", err)
+	}
+	if requeue > 0 {
+		klog.V(4).Infof("trusting the previous identity provider CAs for another %v", requeue)
+		syncContext.Queue().AddAfter(syncContext.QueueKey(), requeue)
+	}
+	return bundles, nil
+}
+
+func (c *oauthServerDeploymentSyncer) syncNetworkPolicy(ctx context.Context, operatorConfig *operatorv1.Authentication, proxyConfig *configv1.Proxy, enabled bool) error {
+	if !enabled {
+		if c.networkPolicyEnabled != nil && !*c.networkPolicyEnabled {
+			return nil
+		}
+		if err := deleteNetworkPolicy(ctx, c.networkPolicies); err != nil {
+			return err
+		}
+		c.networkPolicyEnabled = &enabled
+		return nil
+	}
+
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	networkPolicy, err := oauthServerNetworkPolicy(observedConfig, proxyConfig)
+	if err != nil {
+		return err
+	}
+	if err := applyNetworkPolicy(ctx, c.networkPolicies, networkPolicy); err != nil {
+		return err
+	}
+	c.networkPolicyEnabled = &enabled
+	return nil
+}
+
+// getCurrentDeployment returns the deployment as it is in the cluster for the
+// cases when the expected deployment must not be applied
+func (c *oauthServerDeploymentSyncer) getCurrentDeployment(ctx context.Context, expectedDeployment *appsv1.Deployment, errs []error) (*appsv1.Deployment, bool, []error) {
+	currentDeployment, err := c.deployments.Deployments(expectedDeployment.Namespace).Get(ctx, expectedDeployment.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+		return nil, false, errs
+	}
+	return currentDeployment, false, errs
+}
+
 func (c *oauthServerDeploymentSyncer) getProxyConfig() (*configv1.Proxy, error) {
 	proxyConfig, err := c.proxyLister.Get("cluster")
 	if err != nil {
@@ -252,17 +586,76 @@ func (c *oauthServerDeploymentSyncer) getProxyConfig() (*configv1.Proxy, error)
 	return proxyConfig, nil
 }
 
-func (c *oauthServerDeploymentSyncer) getConfigResourceVersions() ([]string, error) {
-	var configRVs []string
+// reloadOnlyAnnotation marks the admin-provided resources that oauth-server
+// picks up without a restart, their copies are still mounted but their changes
+// do not roll out the deployment
+const reloadOnlyAnnotation = "operator.openshift.io/reload-only"
+
+// getReloadOnlyResources returns the names of the synced copies whose sources
+// in openshift-config are marked reload-only. The annotation is read from the
+// sources, the sync only ever adds the annotations to the copies and these
+// would stay marked once the annotation got removed from a source. The
+// changes to the resources oauth-server cannot reload still roll out even
+// when marked.
+func (c *oauthServerDeploymentSyncer) getReloadOnlyResources(operatorConfig *operatorv1.Authentication, userSyncData *datasync.ConfigSyncData) (sets.String, error) {
+	reloadUnsupported, err := getReloadUnsupportedResources(operatorConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+	idpSyncData, err := getSyncDataFromOperatorConfig(observedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	reloadOnly := sets.NewString()
+	for _, resource := range append(idpSyncData.Resources(), userSyncData.Resources()...) {
+		var source metav1.Object
+		switch resource.Type {
+		case datasync.ConfigMapType:
+			source, err = c.configNSConfigMapLister.ConfigMaps("openshift-config").Get(resource.Source)
+		case datasync.SecretType:
+			source, err = c.configNSSecretLister.Secrets("openshift-config").Get(resource.Source)
+		default:
+			continue
+		}
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if source.GetAnnotations()[reloadOnlyAnnotation] != "true" {
+			continue
+		}
+		if reloadUnsupported.Has(resource.Dest) {
+			klog.V(4).Infof("%s cannot be reloaded by oauth-server, its changes roll out the deployment regardless of the %s annotation", resource.Dest, reloadOnlyAnnotation)
+			continue
+		}
+		reloadOnly.Insert(resource.Dest)
+	}
+	return reloadOnly, nil
+}
+
+// getConfigContentHashes returns the hashes of the content of the v4-0-config-
+// resources the oauth-server pods mount, except for the reload-only ones
+func (c *oauthServerDeploymentSyncer) getConfigContentHashes(reloadOnly sets.String) ([]string, error) {
+	var configHashes []string
 
 	configMaps, err := c.configMapLister.ConfigMaps("openshift-authentication").List(labels.Everything())
 	if err != nil {
 		return nil, fmt.Errorf("unable to list configmaps in %q namespace: %v", "openshift-authentication", err)
 	}
 	for _, cm := range configMaps {
-		if strings.HasPrefix(cm.Name, "v4-0-config-") {
-			// prefix the RV to make it clear where it came from since each resource can be from different etcd
-			configRVs = append(configRVs, "configmaps:"+cm.Name+":"+cm.ResourceVersion)
+		if strings.HasPrefix(cm.Name, "v4-0-config-") && !reloadOnly.Has(cm.Name) {
+			configHashes = append(configHashes, "configmaps:"+cm.Name+":"+configMapContentHash(cm))
 		}
 	}
 
@@ -271,11 +664,10 @@ func (c *oauthServerDeploymentSyncer) getConfigResourceVersions() ([]string, err
 		return nil, fmt.Errorf("unable to list secrets in %q namespace: %v", "openshift-authentication", err)
 	}
 	for _, secret := range secrets {
-		if strings.HasPrefix(secret.Name, "v4-0-config-") {
-			// prefix the RV to make it clear where it came from since each resource can be from different etcd
-			configRVs = append(configRVs, "secrets:"+secret.Name+":"+secret.ResourceVersion)
+		if strings.HasPrefix(secret.Name, "v4-0-config-") && !reloadOnly.Has(secret.Name) {
+			configHashes = append(configHashes, "secrets:"+secret.Name+":"+secretContentHash(secret))
 		}
 	}
 
-	return configRVs, nil
+	return configHashes, nil
 }
@@ -0,0 +1,1618 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	bootstrap "github.com/openshift/library-go/pkg/authentication/bootstrapauthenticator"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/apiserver/controller/workload"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+type fakeAuthentications struct {
+	operatorv1client.AuthenticationInterface
+	authentication *operatorv1.Authentication
+}
+
+func (f *fakeAuthentications) Get(_ context.Context, _ string, _ metav1.GetOptions) (*operatorv1.Authentication, error) {
+	return f.authentication.DeepCopy(), nil
+}
+
+type fakeAuthenticationsGetter struct {
+	authentications *fakeAuthentications
+}
+
+func (f *fakeAuthenticationsGetter) Authentications() operatorv1client.AuthenticationInterface {
+	return f.authentications
+}
+
+type fakeResourceSyncer struct {
+	// synced maps destination -> source, an empty source means the destination gets removed
+	synced map[string]string
+}
+
+func (rs *fakeResourceSyncer) SyncConfigMap(destination, source resourcesynccontroller.ResourceLocation) error {
+	rs.synced["configmap/"+destination.Namespace+"/"+destination.Name] = source.Namespace + "/" + source.Name
+	return nil
+}
+
+func (rs *fakeResourceSyncer) SyncSecret(destination, source resourcesynccontroller.ResourceLocation) error {
+	rs.synced["secret/"+destination.Namespace+"/"+destination.Name] = source.Namespace + "/" + source.Name
+	return nil
+}
+
+type fakeConfigValidator struct {
+	validated bool
+	err       error
+	calls     int
+}
+
+func (v *fakeConfigValidator) Validate(_ context.Context, _ *appsv1.Deployment) (bool, error) {
+	v.calls++
+	return v.validated, v.err
+}
+
+type fakeImagePullChecker struct {
+	err error
+}
+
+func (c *fakeImagePullChecker) Check(_ string) error {
+	return c.err
+}
+
+type fakeBootstrapUserDataGetter struct {
+	exists bool
+}
+
+func (g *fakeBootstrapUserDataGetter) Get() (*bootstrap.BootstrapUserData, bool, error) {
+	return nil, g.exists, nil
+}
+
+func (g *fakeBootstrapUserDataGetter) IsEnabled() (bool, error) {
+	return g.exists, nil
+}
+
+func newTestSyncer(operatorConfig *operatorv1.Authentication, kubeObjects ...runtime.Object) (*oauthServerDeploymentSyncer, *fake.Clientset) {
+	kubeClient := fake.NewSimpleClientset(kubeObjects...)
+
+	newIndexer := func() cache.Indexer {
+		return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	}
+	configMapIndexer, secretIndexer, podIndexer := newIndexer(), newIndexer(), newIndexer()
+	for _, obj := range kubeObjects {
+		var err error
+		switch obj.(type) {
+		case *corev1.ConfigMap:
+			err = configMapIndexer.Add(obj)
+		case *corev1.Secret:
+			err = secretIndexer.Add(obj)
+		case *corev1.Pod:
+			err = podIndexer.Add(obj)
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &oauthServerDeploymentSyncer{
+		countNodes: func(_ map[string]string) (*int32, error) {
+			replicas := int32(3)
+			return &replicas, nil
+		},
+		ensureAtMostOnePodPerNode: func(_ *appsv1.DeploymentSpec, _ string) error { return nil },
+
+		deployments:     kubeClient.AppsV1(),
+		configMaps:      kubeClient.CoreV1(),
+		networkPolicies: kubeClient.NetworkingV1(),
+		auth:            &fakeAuthenticationsGetter{authentications: &fakeAuthentications{authentication: operatorConfig}},
+		operatorClient:  v1helpers.NewFakeOperatorClient(&operatorConfig.Spec.OperatorSpec, &operatorConfig.Status.OperatorStatus, nil),
+
+		configMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+		secretLister:    corev1listers.NewSecretLister(secretIndexer),
+		podsLister:      corev1listers.NewPodLister(podIndexer),
+		proxyLister:     configv1listers.NewProxyLister(newIndexer()),
+		oauthLister:     configv1listers.NewOAuthLister(newIndexer()),
+
+		configNSConfigMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+		configNSSecretLister:    corev1listers.NewSecretLister(secretIndexer),
+
+		resourceSyncer: &fakeResourceSyncer{synced: map[string]string{}},
+		syncedUserData: datasync.NewConfigSyncData(),
+
+		imagePullChecker: &mirrorImagePullChecker{
+			imageDigestMirrorSetLister: configv1listers.NewImageDigestMirrorSetLister(newIndexer()),
+			podsLister:                 corev1listers.NewPodLister(podIndexer),
+			clock:                      clock.RealClock{},
+		},
+
+		clock: clock.RealClock{},
+	}, kubeClient
+}
+
+// withoutRolloutCooldown disables the rollout cooldown in the given
+// unsupportedConfigOverrides for the tests that expect the changes to roll out
+// right away
+func withoutRolloutCooldown(unsupportedConfigOverrides string) string {
+	overrides := map[string]map[string]interface{}{}
+	if len(unsupportedConfigOverrides) > 0 {
+		if err := json.Unmarshal([]byte(unsupportedConfigOverrides), &overrides); err != nil {
+			panic(err)
+		}
+	}
+	if overrides["oauthServer"] == nil {
+		overrides["oauthServer"] = map[string]interface{}{}
+	}
+	overrides["oauthServer"]["rolloutCooldown"] = "0s"
+	overridesJSON, err := json.Marshal(overrides)
+	if err != nil {
+		panic(err)
+	}
+	return string(overridesJSON)
+}
+
+func testSyncContext() factory.SyncContext {
+	return factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+}
+
+func TestSyncConfigValidation(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+
+	tests := []struct {
+		name            string
+		overrides       string
+		validator       *fakeConfigValidator
+		wantCalls       int
+		wantApplied     bool
+		wantErrContains string
+	}{
+		{
+			name:        "validation disabled",
+			validator:   &fakeConfigValidator{},
+			wantApplied: true,
+		},
+		{
+			name:        "validation passed",
+			overrides:   `{"oauthServer":{"validateConfig":true}}`,
+			validator:   &fakeConfigValidator{validated: true},
+			wantCalls:   1,
+			wantApplied: true,
+		},
+		{
+			name:      "validation in progress",
+			overrides: `{"oauthServer":{"validateConfig":true}}`,
+			validator: &fakeConfigValidator{},
+			wantCalls: 1,
+		},
+		{
+			name:            "validation failed",
+			overrides:       `{"oauthServer":{"validateConfig":true}}`,
+			validator:       &fakeConfigValidator{validated: true, err: fmt.Errorf("oauth-server config validation failed: bad identity provider")},
+			wantCalls:       1,
+			wantErrContains: "oauth-server config validation failed: bad identity provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, kubeClient := newTestSyncer(testOperatorConfig(withoutRolloutCooldown(tt.overrides)), existingDeployment.DeepCopy())
+			syncer.configValidator = tt.validator
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+
+			if tt.validator.calls != tt.wantCalls {
+				t.Errorf("expected %d validations, got %d", tt.wantCalls, tt.validator.calls)
+			}
+
+			if len(tt.wantErrContains) > 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			if deployment == nil {
+				t.Fatalf("expected a deployment to be returned")
+			}
+
+			applied := false
+			for _, action := range kubeClient.Actions() {
+				if action.Matches("update", "deployments") {
+					applied = true
+				}
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("expected the deployment to be applied: %v, got %v", tt.wantApplied, applied)
+			}
+		})
+	}
+}
+
+func TestSyncKubeClientCertificate(t *testing.T) {
+	tests := []struct {
+		name            string
+		secret          *corev1.Secret
+		wantErrContains string
+		wantSynced      map[string]string
+	}{
+		{
+			name:            "missing secret",
+			wantErrContains: `secret "kube-client" not found`,
+			wantSynced:      map[string]string{},
+		},
+		{
+			name: "missing key",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-client", Namespace: "openshift-config"},
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a cert")},
+			},
+			wantErrContains: `missing required key: "tls.key"`,
+			wantSynced:      map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.secret != nil {
+				objects = append(objects, tt.secret)
+			}
+			syncer, _ := newTestSyncer(testOperatorConfig(`{"oauthServer":{"kubeClientCertificate":{"name":"kube-client"}}}`), objects...)
+
+			_, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			found := false
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.wantErrContains) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+			}
+
+			if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, tt.wantSynced) {
+				t.Errorf("expected synced resources %v, got %v", tt.wantSynced, synced)
+			}
+		})
+	}
+}
+
+func TestSyncRecordsAppliedDeploymentHash(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+
+	for _, overrides := range []string{"", `{"oauthServer":{"gogc":"200"}}`} {
+		syncer, _ := newTestSyncer(testOperatorConfig(withoutRolloutCooldown(overrides)), existingDeployment.DeepCopy())
+		deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+
+		expected := fmt.Sprintf(`
+# HELP openshift_authentication_operator_oauth_server_deployment_info [ALPHA] Reports the rvs-hash of the oauth-openshift deployment that was last applied by the operator. Always has the value of 1.
+# TYPE openshift_authentication_operator_oauth_server_deployment_info gauge
+openshift_authentication_operator_oauth_server_deployment_info{rvs_hash="%s"} 1
+`, deployment.Spec.Template.Annotations[deploymentVersionHashKey])
+		if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "openshift_authentication_operator_oauth_server_deployment_info"); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSyncRecordsRollouts(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+
+	syncer, _ := newTestSyncer(testOperatorConfig(withoutRolloutCooldown("")), existingDeployment)
+	applied, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if lastSync, err := testutil.GetGaugeMetricValue(lastSuccessfulSync); err != nil || lastSync == 0 {
+		t.Errorf("expected the time of the successful sync to be recorded, got %v: %v", lastSync, err)
+	}
+
+	rollouts := func() float64 {
+		value, err := testutil.GetCounterMetricValue(rolloutsTotal.WithLabelValues(rolloutReasonDeploymentConfigChange))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	before := rollouts()
+
+	syncer, _ = newTestSyncer(testOperatorConfig(withoutRolloutCooldown(`{"oauthServer":{"gogc":"200"}}`)), applied)
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got := rollouts(); got != before+1 {
+		t.Errorf("expected the rollout to be counted as a %s, got %v more", rolloutReasonDeploymentConfigChange, got-before)
+	}
+}
+
+func TestSyncImagePullFailures(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "oauth-openshift", Image: "quay.io/openshift/oauth-server@sha256:1234"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		checker         *fakeImagePullChecker
+		wantErrContains string
+	}{
+		{
+			name:    "image gets pulled",
+			checker: &fakeImagePullChecker{},
+		},
+		{
+			// the config changes still roll out, the image is reported
+			name:            "no source serves the image",
+			checker:         &fakeImagePullChecker{err: fmt.Errorf("unable to pull the oauth-server image \"quay.io/openshift/oauth-server@sha256:1234\" from any of its sources for 5m0s")},
+			wantErrContains: "unable to pull the oauth-server image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMAGE_OAUTH_SERVER", "quay.io/openshift/oauth-server@sha256:5678")
+			syncer, kubeClient := newTestSyncer(testOperatorConfig(withoutRolloutCooldown("")), existingDeployment.DeepCopy())
+			syncer.imagePullChecker = tt.checker
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			// the payload image is never replaced by one of its mirrors
+			if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "quay.io/openshift/oauth-server@sha256:5678" {
+				t.Errorf("expected the payload image, got %q", image)
+			}
+
+			applied := false
+			for _, action := range kubeClient.Actions() {
+				if action.Matches("update", "deployments") {
+					applied = true
+				}
+			}
+			if !applied {
+				t.Errorf("expected the deployment to be applied")
+			}
+		})
+	}
+}
+
+func TestSyncLocaleBundle(t *testing.T) {
+	tests := []struct {
+		name            string
+		configMap       *corev1.ConfigMap
+		wantErrContains string
+		wantSynced      map[string]string
+	}{
+		{
+			name: "translations get synced",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-translations", Namespace: "openshift-config"},
+				Data:       map[string]string{"locales.json": `{"de":{"Log in":"Anmelden"}}`},
+			},
+			wantSynced: map[string]string{
+				"configmap/openshift-authentication/v4-0-config-user-locale-bundle": "openshift-config/login-translations",
+			},
+		},
+		{
+			name: "empty translations",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-translations", Namespace: "openshift-config"},
+				Data:       map[string]string{"locales.json": ""},
+			},
+			wantErrContains: "required data is empty",
+			wantSynced:      map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, _ := newTestSyncer(testOperatorConfig(`{"oauthServer":{"localeBundle":{"name":"login-translations"}}}`), tt.configMap)
+
+			_, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, tt.wantSynced) {
+				t.Errorf("expected synced resources %v, got %v", tt.wantSynced, synced)
+			}
+		})
+	}
+}
+
+func TestSyncUnsyncsUserDataAcrossRestarts(t *testing.T) {
+	translations := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "login-translations", Namespace: "openshift-config"},
+		Data:       map[string]string{"locales.json": `{"de":{"Log in":"Anmelden"}}`},
+	}
+	syncer, _ := newTestSyncer(testOperatorConfig(withoutRolloutCooldown(`{"oauthServer":{"localeBundle":{"name":"login-translations"}}}`)), translations)
+	applied, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(applied.Annotations[syncedUserDataKey]) == 0 {
+		t.Fatalf("expected the synced user data to be recorded on the deployment, got the annotations %v", applied.Annotations)
+	}
+
+	// the restarted operator only knows the copies synced before from the
+	// deployment, the locale bundle got dropped from the config meanwhile
+	restarted, _ := newTestSyncer(testOperatorConfig(withoutRolloutCooldown("")), translations, applied)
+	restarted.syncedUserData = nil
+	deployment, _, errs := restarted.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	wantSynced := map[string]string{
+		"configmap/openshift-authentication/v4-0-config-user-locale-bundle": "/",
+	}
+	if synced := restarted.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, wantSynced) {
+		t.Errorf("expected synced resources %v, got %v", wantSynced, synced)
+	}
+	if recorded, ok := deployment.Annotations[syncedUserDataKey]; ok {
+		t.Errorf("expected the synced user data to be removed from the deployment, got %q", recorded)
+	}
+}
+
+func TestSyncLoginPageSnippets(t *testing.T) {
+	tests := []struct {
+		name            string
+		configMap       *corev1.ConfigMap
+		wantErrContains string
+		wantSynced      map[string]string
+	}{
+		{
+			name: "snippet gets synced",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-footer", Namespace: "openshift-config"},
+				Data:       map[string]string{"snippet.html": `<p>Authorized use only</p>`},
+			},
+			wantSynced: map[string]string{
+				"configmap/openshift-authentication/v4-0-config-user-login-page-footer": "openshift-config/login-footer",
+			},
+		},
+		{
+			name: "snippet under an unexpected key",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-footer", Namespace: "openshift-config"},
+				Data:       map[string]string{"footer.html": `<p>Authorized use only</p>`},
+			},
+			wantErrContains: `missing required key: "snippet.html"`,
+			wantSynced:      map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, _ := newTestSyncer(testOperatorConfig(`{"oauthServer":{"loginPageSnippets":{"footer":{"name":"login-footer"}}}}`), tt.configMap)
+
+			_, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, tt.wantSynced) {
+				t.Errorf("expected synced resources %v, got %v", tt.wantSynced, synced)
+			}
+		})
+	}
+}
+
+func TestSyncRolloutCooldown(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+
+	syncer, kubeClient := newTestSyncer(testOperatorConfig(`{"oauthServer":{"rolloutCooldown":"30s"}}`), existingDeployment.DeepCopy())
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	syncer.clock = fakeClock
+	authentications := syncer.auth.(*fakeAuthenticationsGetter).authentications
+
+	// the applies that do not change the rvs-hash are not rollouts
+	countUpdates := func() int {
+		updates, rvsHash := 0, ""
+		for _, action := range kubeClient.Actions() {
+			if !action.Matches("update", "deployments") {
+				continue
+			}
+			updated := action.(clienttesting.UpdateAction).GetObject().(*appsv1.Deployment)
+			if hash := updated.Spec.Template.Annotations[deploymentVersionHashKey]; hash != rvsHash {
+				updates, rvsHash = updates+1, hash
+			}
+		}
+		return updates
+	}
+
+	sync := func() *appsv1.Deployment {
+		t.Helper()
+		deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		return deployment
+	}
+
+	gogcOf := func(deployment *appsv1.Deployment) string {
+		if len(deployment.Spec.Template.Spec.Containers) == 0 {
+			return ""
+		}
+		if gogc := findEnvVar(deployment.Spec.Template.Spec.Containers[0].Env, "GOGC"); gogc != nil {
+			return gogc.Value
+		}
+		return ""
+	}
+	setGOGC := func(gogc string) {
+		authentications.authentication = testOperatorConfig(fmt.Sprintf(`{"oauthServer":{"rolloutCooldown":"30s","gogc":%q}}`, gogc))
+	}
+
+	// a burst of changes right after a quiet period, every change restarts
+	// the cooldown
+	burst := func(gogcs ...string) {
+		t.Helper()
+		for _, gogc := range gogcs {
+			setGOGC(gogc)
+			if deployment := sync(); gogcOf(deployment) == gogc {
+				t.Fatalf("expected the change to GOGC=%s to be held back during the cooldown", gogc)
+			}
+			fakeClock.SetTime(fakeClock.Now().Add(5 * time.Second))
+		}
+		// the cooldown runs from the last change of the burst
+		fakeClock.SetTime(fakeClock.Now().Add(20 * time.Second))
+		if deployment := sync(); gogcOf(deployment) == gogcs[len(gogcs)-1] {
+			t.Fatalf("expected the rollout to wait for the cooldown after the last change")
+		}
+	}
+
+	burst("100", "150", "200")
+	if updates := countUpdates(); updates != 0 {
+		t.Fatalf("expected the burst to be held back during the cooldown, got %d updates", updates)
+	}
+	fakeClock.SetTime(fakeClock.Now().Add(5 * time.Second))
+	deployment := sync()
+	if updates := countUpdates(); updates != 1 {
+		t.Fatalf("expected a single rollout of the burst, got %d updates", updates)
+	}
+	if gogc := gogcOf(deployment); gogc != "200" {
+		t.Errorf("expected the rollout to have the latest config GOGC=200, got %q", gogc)
+	}
+
+	// a sync without changes is not a rollout
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	if resynced := sync(); resynced.Spec.Template.Annotations[deploymentVersionHashKey] != deployment.Spec.Template.Annotations[deploymentVersionHashKey] {
+		t.Errorf("expected the rvs-hash to stay the same without changes")
+	}
+	if updates := countUpdates(); updates != 1 {
+		t.Fatalf("expected no rollout without changes, got %d updates", updates)
+	}
+
+	// the next burst after the quiet period is rolled out as a whole again
+	burst("250", "300")
+	fakeClock.SetTime(fakeClock.Now().Add(5 * time.Second))
+	deployment = sync()
+	if updates := countUpdates(); updates != 2 {
+		t.Fatalf("expected a single rollout of the second burst, got %d updates", updates)
+	}
+	if gogc := gogcOf(deployment); gogc != "300" {
+		t.Errorf("expected the rollout to have the latest config GOGC=300, got %q", gogc)
+	}
+}
+
+func TestSyncReplicas(t *testing.T) {
+	tests := []struct {
+		name            string
+		overrides       string
+		wantReplicas    int32
+		wantErrContains string
+	}{
+		{
+			name:         "one replica per node by default",
+			wantReplicas: 3,
+		},
+		{
+			name:         "fewer replicas than nodes",
+			overrides:    `{"oauthServer":{"replicas":2}}`,
+			wantReplicas: 2,
+		},
+		{
+			name:            "more replicas than nodes",
+			overrides:       `{"oauthServer":{"replicas":4}}`,
+			wantErrContains: "replicas 4 exceeds the 3 nodes the oauth-server pods may be scheduled to",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, kubeClient := newTestSyncer(testOperatorConfig(tt.overrides))
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+				if _, err := kubeClient.AppsV1().Deployments("openshift-authentication").Get(context.Background(), "oauth-openshift", metav1.GetOptions{}); err == nil {
+					t.Errorf("expected no deployment to be applied")
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if replicas := deployment.Spec.Replicas; replicas == nil || *replicas != tt.wantReplicas {
+				t.Errorf("expected %d replicas, got %v", tt.wantReplicas, replicas)
+			}
+		})
+	}
+}
+
+func TestSyncMinRolloutInterval(t *testing.T) {
+	existingDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication", Generation: 1},
+	}
+	overrides := func(gogc int) string {
+		return fmt.Sprintf(`{"oauthServer":{"rolloutCooldown":"0s","minRolloutInterval":"1m","gogc":"%d"}}`, gogc)
+	}
+
+	syncer, kubeClient := newTestSyncer(testOperatorConfig(overrides(100)), existingDeployment.DeepCopy())
+	fakeClock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	syncer.clock = fakeClock
+	authentications := syncer.auth.(*fakeAuthenticationsGetter).authentications
+
+	countUpdates := func() int {
+		updates := 0
+		for _, action := range kubeClient.Actions() {
+			if action.Matches("update", "deployments") {
+				updates++
+			}
+		}
+		return updates
+	}
+	gogcOf := func(deployment *appsv1.Deployment) string {
+		if len(deployment.Spec.Template.Spec.Containers) == 0 {
+			return ""
+		}
+		if gogc := findEnvVar(deployment.Spec.Template.Spec.Containers[0].Env, "GOGC"); gogc != nil {
+			return gogc.Value
+		}
+		return ""
+	}
+
+	// a change every 20 seconds for three minutes
+	var rolledOut []string
+	var deployment *appsv1.Deployment
+	for i := 0; i <= 9; i++ {
+		authentications.authentication = testOperatorConfig(overrides(100 + 10*i))
+		var errs []error
+		deployment, _, errs = syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		if updates := countUpdates(); updates > len(rolledOut) {
+			rolledOut = append(rolledOut, gogcOf(deployment))
+		}
+		fakeClock.SetTime(fakeClock.Now().Add(20 * time.Second))
+	}
+
+	// the changes within each interval are coalesced into a single rollout
+	// of the latest of them
+	if want := []string{"100", "130", "160", "190"}; !equality.Semantic.DeepEqual(want, rolledOut) {
+		t.Errorf("expected the rollouts %v, got %v", want, rolledOut)
+	}
+	if gogc := gogcOf(deployment); gogc != "190" {
+		t.Errorf("expected the deployment to converge to the latest config GOGC=190, got %q", gogc)
+	}
+	if recorded := deployment.Annotations[lastRolloutTimeKey]; recorded != "2026-01-01T10:03:00Z" {
+		t.Errorf("expected the last rollout to be recorded on the deployment, got %q", recorded)
+	}
+
+	// the interval holds across the restarts of the operator
+	restartedSyncer, restartedKubeClient := newTestSyncer(testOperatorConfig(overrides(200)), deployment.DeepCopy())
+	restartedSyncer.clock = clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 10, 3, 30, 0, time.UTC))
+	if deployment, _, errs := restartedSyncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	} else if gogc := gogcOf(deployment); gogc != "190" {
+		t.Errorf("expected the restarted operator to keep the current deployment within the interval, got GOGC=%q", gogc)
+	}
+	for _, action := range restartedKubeClient.Actions() {
+		if action.Matches("update", "deployments") {
+			t.Errorf("expected the restarted operator not to roll out within the interval")
+		}
+	}
+}
+
+func TestSyncBootstrapUserRemoval(t *testing.T) {
+	const bootstrapUserAnnotation = "operator.openshift.io/bootstrap-user-exists"
+	// outside of the 02:00-04:00 window below
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		overrides string
+		// advance is the time that passes after the removal was first observed
+		advance time.Duration
+		// wantDeferred and wantDeferredAfterAdvance tell whether the rollout
+		// is held back when the removal is observed and after the advance
+		wantDeferred             bool
+		wantDeferredAfterAdvance bool
+	}{
+		{
+			name:      "immediate removal without config",
+			overrides: `{"oauthServer":{"rolloutCooldown":"0s"}}`,
+		},
+		{
+			name:                     "deferred outside of the maintenance window",
+			overrides:                `{"oauthServer":{"rolloutCooldown":"0s","bootstrapUserRemoval":{"maintenanceWindow":{"start":"02:00","duration":"2h"}}}}`,
+			advance:                  time.Hour,
+			wantDeferred:             true,
+			wantDeferredAfterAdvance: true,
+		},
+		{
+			name:      "removed in the maintenance window",
+			overrides: `{"oauthServer":{"rolloutCooldown":"0s","bootstrapUserRemoval":{"maintenanceWindow":{"start":"02:00","duration":"2h"}}}}`,
+			// 02:30 the next day
+			advance:      16*time.Hour + 30*time.Minute,
+			wantDeferred: true,
+		},
+		{
+			name:         "removed after the max delay",
+			overrides:    `{"oauthServer":{"rolloutCooldown":"0s","bootstrapUserRemoval":{"maintenanceWindow":{"start":"02:00","duration":"2h"},"maxDelay":"3h"}}}`,
+			advance:      3 * time.Hour,
+			wantDeferred: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, _ := newTestSyncer(testOperatorConfig(tt.overrides))
+			fakeClock := clocktesting.NewFakePassiveClock(now)
+			syncer.clock = fakeClock
+			bootstrapUser := &fakeBootstrapUserDataGetter{exists: true}
+			syncer.bootstrapUserDataGetter = bootstrapUser
+			syncer.bootstrapUserChangeRollOut = true
+
+			sync := func() *appsv1.Deployment {
+				t.Helper()
+				deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return deployment
+			}
+
+			if deployment := sync(); deployment.Spec.Template.Annotations[bootstrapUserAnnotation] != "true" {
+				t.Fatalf("expected the deployment to carry the bootstrap user annotation")
+			}
+
+			bootstrapUser.exists = false
+			deployment := sync()
+			if _, annotated := deployment.Spec.Template.Annotations[bootstrapUserAnnotation]; annotated != tt.wantDeferred {
+				t.Fatalf("expected the removal to be deferred: %v, got the annotation present: %v", tt.wantDeferred, annotated)
+			}
+
+			fakeClock.SetTime(now.Add(tt.advance))
+			deployment = sync()
+			if _, annotated := deployment.Spec.Template.Annotations[bootstrapUserAnnotation]; annotated != tt.wantDeferredAfterAdvance {
+				t.Errorf("expected the removal to be deferred after %v: %v, got the annotation present: %v", tt.advance, tt.wantDeferredAfterAdvance, annotated)
+			}
+		})
+	}
+}
+
+func TestSyncSNICertificates(t *testing.T) {
+	// a serving certificate without the client authentication EKU
+	servingCert, err := crypto.MakeSelfSignedCAConfigForDuration("login.example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := servingCert.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		secret          *corev1.Secret
+		wantErrContains string
+		wantSynced      map[string]string
+	}{
+		{
+			name: "incomplete pair",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-cert", Namespace: "openshift-config"},
+				Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+			},
+			wantErrContains: `missing required key: "tls.key"`,
+			wantSynced:      map[string]string{},
+		},
+		{
+			name: "complete pair",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "login-cert", Namespace: "openshift-config"},
+				Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+			},
+			wantSynced: map[string]string{
+				"secret/openshift-authentication/v4-0-config-user-sni-0-cert": "openshift-config/login-cert",
+				"secret/openshift-authentication/v4-0-config-user-sni-0-key":  "openshift-config/login-cert",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, _ := newTestSyncer(testOperatorConfig(`{"oauthServer":{"sniCertificates":[{"secret":{"name":"login-cert"}}]}}`), tt.secret)
+
+			_, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				found := false
+				for _, err := range errs {
+					if strings.Contains(err.Error(), tt.wantErrContains) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Errorf("unexpected errors: %v", errs)
+			}
+
+			if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, tt.wantSynced) {
+				t.Errorf("expected synced resources %v, got %v", tt.wantSynced, synced)
+			}
+		})
+	}
+}
+
+func TestSyncNetworkPolicy(t *testing.T) {
+	syncer, kubeClient := newTestSyncer(testOperatorConfig(`{"oauthServer":{"networkPolicy":true}}`))
+	authentications := syncer.auth.(*fakeAuthenticationsGetter).authentications
+
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, err := kubeClient.NetworkingV1().NetworkPolicies("openshift-authentication").Get(context.Background(), networkPolicyName, metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the NetworkPolicy to be created: %v", err)
+	}
+
+	authentications.authentication = testOperatorConfig("")
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, err := kubeClient.NetworkingV1().NetworkPolicies("openshift-authentication").Get(context.Background(), networkPolicyName, metav1.GetOptions{}); err == nil {
+		t.Errorf("expected the NetworkPolicy to be removed once disabled")
+	}
+}
+
+// idpVolumesToMount returns the observed sync data of the identity provider
+// resources, each of them is synced from the openshift-config resource of the
+// same name without the v4-0-config-user- prefix
+func idpVolumesToMount(t *testing.T, resourceType datasync.ResourceType, key string, dests ...string) string {
+	t.Helper()
+	volumes := map[string]interface{}{}
+	for _, dest := range dests {
+		volumes[dest] = map[string]interface{}{
+			"name":      strings.TrimPrefix(dest, "v4-0-config-user-"),
+			"mountPath": "/var/config/user/idp/" + dest,
+			"key":       key,
+			"type":      resourceType,
+		}
+	}
+	volumesJSON, err := json.Marshal(volumes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoted, err := json.Marshal(string(volumesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return `"volumesToMount":{"identityProviders":` + string(quoted) + `}`
+}
+
+func TestSyncReloadOnlyResources(t *testing.T) {
+	operatorConfig := testOperatorConfig("")
+	operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(`{"oauthServer":{` +
+		idpVolumesToMount(t, datasync.SecretType, "clientSecret", "v4-0-config-user-idp-0-client-secret", "v4-0-config-user-idp-1-client-secret") +
+		`}}`)}
+
+	secret := func(namespace, name, content string, reloadOnly bool) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"clientSecret": []byte(content)},
+		}
+		if reloadOnly {
+			secret.Annotations = map[string]string{reloadOnlyAnnotation: "true"}
+		}
+		return secret
+	}
+	// the sync only adds the annotations to the copies, they keep the
+	// annotation regardless of their sources
+	idpSecret := func(name, content string) *corev1.Secret {
+		return secret("openshift-authentication", name, content, true)
+	}
+	sources := func(reloadOnly bool) []runtime.Object {
+		return []runtime.Object{
+			secret("openshift-config", "idp-0-client-secret", "", reloadOnly),
+			secret("openshift-config", "idp-1-client-secret", "", false),
+		}
+	}
+
+	hashFor := func(objects ...runtime.Object) string {
+		t.Helper()
+		syncer, _ := newTestSyncer(operatorConfig, objects...)
+		deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		return deployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	}
+
+	initialHash := hashFor(append(sources(true),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "1"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "1"),
+	)...)
+	if reloadedHash := hashFor(append(sources(true),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "2"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "1"),
+	)...); reloadedHash != initialHash {
+		t.Errorf("expected a change of the reload-only secret not to roll out the deployment")
+	}
+	if changedHash := hashFor(append(sources(true),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "1"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "2"),
+	)...); changedHash == initialHash {
+		t.Errorf("expected a change of the secret whose source is not marked to roll out the deployment")
+	}
+	if systemHash := hashFor(append(sources(true),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "1"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "1"),
+		secret("openshift-authentication", "v4-0-config-system-session", "1", true),
+	)...); systemHash == initialHash {
+		t.Errorf("expected the operator-managed secrets to be tracked regardless of the annotation")
+	}
+
+	unmarkedHash := hashFor(append(sources(false),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "1"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "1"),
+	)...)
+	if changedHash := hashFor(append(sources(false),
+		idpSecret("v4-0-config-user-idp-0-client-secret", "2"),
+		idpSecret("v4-0-config-user-idp-1-client-secret", "1"),
+	)...); changedHash == unmarkedHash {
+		t.Errorf("expected a change of the secret to roll out the deployment once the annotation got removed from its source")
+	}
+}
+
+func TestSyncReloadOnlyIdentityProviderCAs(t *testing.T) {
+	operatorConfig := testOperatorConfig("")
+	operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(`{"oauthServer":{"oauthConfig":{"identityProviders":[` +
+		`{"name":"sso","login":true,"mappingMethod":"claim","provider":{"apiVersion":"osin.config.openshift.io/v1","kind":"OpenIDIdentityProvider","ca":"/var/config/user/idp/0/configMap/v4-0-config-user-idp-0-ca/ca.crt"}},` +
+		`{"name":"proxy","login":true,"mappingMethod":"claim","provider":{"apiVersion":"osin.config.openshift.io/v1","kind":"RequestHeaderIdentityProvider","clientCA":"/var/config/user/idp/1/configMap/v4-0-config-user-idp-1-ca/ca.crt"}}` +
+		`]},` + idpVolumesToMount(t, datasync.ConfigMapType, "ca.crt", "v4-0-config-user-idp-0-ca", "v4-0-config-user-idp-1-ca") + `}}`)}
+
+	configMap := func(namespace, name, content string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Annotations: map[string]string{reloadOnlyAnnotation: "true"},
+			},
+			Data: map[string]string{"ca.crt": content},
+		}
+	}
+	reloadOnlyCA := func(name, content string) *corev1.ConfigMap {
+		return configMap("openshift-authentication", name, content)
+	}
+
+	hashFor := func(configMaps ...runtime.Object) string {
+		t.Helper()
+		configMaps = append(configMaps, configMap("openshift-config", "idp-0-ca", ""), configMap("openshift-config", "idp-1-ca", ""))
+		syncer, _ := newTestSyncer(operatorConfig, configMaps...)
+		deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		return deployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	}
+
+	initialHash := hashFor(reloadOnlyCA("v4-0-config-user-idp-0-ca", "1"), reloadOnlyCA("v4-0-config-user-idp-1-ca", "1"))
+	if reloadedHash := hashFor(reloadOnlyCA("v4-0-config-user-idp-0-ca", "2"), reloadOnlyCA("v4-0-config-user-idp-1-ca", "1")); reloadedHash != initialHash {
+		t.Errorf("expected a change of the reload-only CA of the OpenID provider not to roll out the deployment")
+	}
+	if clientCAHash := hashFor(reloadOnlyCA("v4-0-config-user-idp-0-ca", "1"), reloadOnlyCA("v4-0-config-user-idp-1-ca", "2")); clientCAHash == initialHash {
+		t.Errorf("expected a change of the client CA of the request header provider to roll out the deployment regardless of the annotation")
+	}
+}
+
+func TestSyncContentHash(t *testing.T) {
+	session := func(resourceVersion string, labels map[string]string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "v4-0-config-system-session",
+				Namespace:       "openshift-authentication",
+				ResourceVersion: resourceVersion,
+				Labels:          labels,
+			},
+			Data: data,
+		}
+	}
+	cliConfig := func(resourceVersion string, data map[string]string, binaryData map[string][]byte) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "v4-0-config-system-cliconfig", Namespace: "openshift-authentication", ResourceVersion: resourceVersion},
+			Data:       data,
+			BinaryData: binaryData,
+		}
+	}
+
+	hashFor := func(objs ...runtime.Object) string {
+		t.Helper()
+		syncer, _ := newTestSyncer(testOperatorConfig(""), objs...)
+		deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+		if len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		return deployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	}
+
+	initialHash := hashFor(
+		session("1", nil, map[string][]byte{"v4-0-config-system-session": []byte("secrets")}),
+		cliConfig("1", map[string]string{"v4-0-config-system-cliconfig": "config"}, nil),
+	)
+
+	tests := []struct {
+		name     string
+		objs     []runtime.Object
+		wantRoll bool
+	}{
+		{
+			name: "identical content re-applied",
+			objs: []runtime.Object{
+				session("2", nil, map[string][]byte{"v4-0-config-system-session": []byte("secrets")}),
+				cliConfig("2", map[string]string{"v4-0-config-system-cliconfig": "config"}, nil),
+			},
+		},
+		{
+			name: "metadata-only change",
+			objs: []runtime.Object{
+				session("3", map[string]string{"app": "oauth-openshift"}, map[string][]byte{"v4-0-config-system-session": []byte("secrets")}),
+				cliConfig("1", map[string]string{"v4-0-config-system-cliconfig": "config"}, nil),
+			},
+		},
+		{
+			name: "data moved to the binary data",
+			objs: []runtime.Object{
+				session("1", nil, map[string][]byte{"v4-0-config-system-session": []byte("secrets")}),
+				cliConfig("2", nil, map[string][]byte{"v4-0-config-system-cliconfig": []byte("config")}),
+			},
+		},
+		{
+			name: "changed data",
+			objs: []runtime.Object{
+				session("1", nil, map[string][]byte{"v4-0-config-system-session": []byte("rotated secrets")}),
+				cliConfig("1", map[string]string{"v4-0-config-system-cliconfig": "config"}, nil),
+			},
+			wantRoll: true,
+		},
+		{
+			name: "added key",
+			objs: []runtime.Object{
+				session("1", nil, map[string][]byte{"v4-0-config-system-session": []byte("secrets"), "extra": nil}),
+				cliConfig("1", map[string]string{"v4-0-config-system-cliconfig": "config"}, nil),
+			},
+			wantRoll: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rolled := hashFor(tt.objs...) != initialHash; rolled != tt.wantRoll {
+				t.Errorf("expected the deployment to roll out: %v, got %v", tt.wantRoll, rolled)
+			}
+		})
+	}
+}
+
+func TestDataHash(t *testing.T) {
+	if dataHash(map[string][]byte{"a": []byte("bc")}) == dataHash(map[string][]byte{"ab": []byte("c")}) {
+		t.Errorf("expected moving bytes between the key and the value to change the hash")
+	}
+	if dataHash(map[string][]byte{"a": []byte("1:b:1"), "c": nil}) == dataHash(map[string][]byte{"a": nil, "b": []byte("1c:0:")}) {
+		t.Errorf("expected moving bytes between the keys to change the hash")
+	}
+	if dataHash(map[string][]byte{"a": []byte("1"), "b": []byte("2")}) != dataHash(map[string][]byte{"b": []byte("2"), "a": []byte("1")}) {
+		t.Errorf("expected the hash not to depend on the order of the keys")
+	}
+}
+
+func TestSyncSessionStore(t *testing.T) {
+	tests := []struct {
+		name            string
+		secretData      map[string][]byte
+		wantErrContains string
+		wantSynced      map[string]string
+	}{
+		{
+			name:            "missing password",
+			secretData:      map[string][]byte{"url": []byte("rediss://redis.example.com:6380")},
+			wantErrContains: `missing required key: "password"`,
+			wantSynced:      map[string]string{},
+		},
+		{
+			name:       "complete connection secret",
+			secretData: map[string][]byte{"url": []byte("rediss://redis.example.com:6380"), "password": []byte("secret")},
+			wantSynced: map[string]string{
+				"secret/openshift-authentication/v4-0-config-user-session-store-url":      "openshift-config/redis-connection",
+				"secret/openshift-authentication/v4-0-config-user-session-store-password": "openshift-config/redis-connection",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "redis-connection", Namespace: "openshift-config"},
+				Data:       tt.secretData,
+			}
+			syncer, _ := newTestSyncer(testOperatorConfig(`{"oauthServer":{"sessionStore":{"type":"redis","connectionSecret":{"name":"redis-connection"}}}}`), secret)
+
+			_, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				found := false
+				for _, err := range errs {
+					if strings.Contains(err.Error(), tt.wantErrContains) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErrContains, errs)
+				}
+			} else if len(errs) > 0 {
+				t.Errorf("unexpected errors: %v", errs)
+			}
+
+			if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; !equality.Semantic.DeepEqual(synced, tt.wantSynced) {
+				t.Errorf("expected synced resources %v, got %v", tt.wantSynced, synced)
+			}
+		})
+	}
+}
+
+func TestSyncIdentityProviderDeprecations(t *testing.T) {
+	tests := []struct {
+		name           string
+		observedConfig string
+		wantStatus     operatorv1.ConditionStatus
+		wantMessage    string
+	}{
+		{
+			name:           "clean config",
+			observedConfig: `{"oauthServer":{"identityProviderCount":1}}`,
+			wantStatus:     operatorv1.ConditionFalse,
+		},
+		{
+			name:           "deprecated setting",
+			observedConfig: `{"oauthServer":{"identityProviderCount":1,"identityProviderDeprecations":["identity provider \"ldap\": ldap.insecure is deprecated"]}}`,
+			wantStatus:     operatorv1.ConditionTrue,
+			wantMessage:    `identity provider "ldap": ldap.insecure is deprecated`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorConfig := testOperatorConfig("")
+			operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(tt.observedConfig)}
+
+			syncer, _ := newTestSyncer(operatorConfig)
+			// the deprecated settings do not block the rollout
+			if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			_, status, _, _ := syncer.operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, identityProviderDeprecationsConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", identityProviderDeprecationsConditionType)
+			}
+			if condition.Status != tt.wantStatus || condition.Message != tt.wantMessage {
+				t.Errorf("expected the condition %s with %q, got %s with %q", tt.wantStatus, tt.wantMessage, condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestSyncTrustedCABundle(t *testing.T) {
+	trustedCABundle := func(content string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "v4-0-config-system-trusted-ca-bundle",
+				Namespace: "openshift-authentication",
+				Labels:    map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"},
+			},
+			Data: map[string]string{"ca-bundle.crt": content},
+		}
+	}
+
+	syncer, _ := newTestSyncer(testOperatorConfig(""), trustedCABundle("proxy CA"))
+	deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// the network operator injects the trusted CA of the proxy config into
+	// the bundle, the clusters without one keep running with an empty volume
+	templateSpec := deployment.Spec.Template.Spec
+	var volume *corev1.Volume
+	for i := range templateSpec.Volumes {
+		if templateSpec.Volumes[i].Name == "v4-0-config-system-trusted-ca-bundle" {
+			volume = &templateSpec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.ConfigMap == nil || volume.ConfigMap.Optional == nil || !*volume.ConfigMap.Optional {
+		t.Fatalf("expected an optional volume of the trusted CA bundle, got %#v", volume)
+	}
+	mounted := false
+	for _, mount := range templateSpec.Containers[0].VolumeMounts {
+		if mount.Name == volume.Name {
+			mounted = mount.MountPath == "/var/config/system/configmaps/v4-0-config-system-trusted-ca-bundle"
+		}
+	}
+	if !mounted {
+		t.Errorf("expected the trusted CA bundle to be mounted, got %#v", templateSpec.Containers[0].VolumeMounts)
+	}
+	if !strings.Contains(templateSpec.Containers[0].Args[0], "/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem") {
+		t.Errorf("expected the trusted CA bundle to be copied over the system trust store, got:\n%s", templateSpec.Containers[0].Args[0])
+	}
+
+	rotatedSyncer, _ := newTestSyncer(testOperatorConfig(""), trustedCABundle("rotated proxy CA"))
+	rotatedDeployment, _, errs := rotatedSyncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if rotatedDeployment.Spec.Template.Annotations[deploymentVersionHashKey] == deployment.Spec.Template.Annotations[deploymentVersionHashKey] {
+		t.Errorf("expected a rotated trusted CA bundle to roll out")
+	}
+}
+
+func TestSyncPodSpreading(t *testing.T) {
+	syncer, _ := newTestSyncer(testOperatorConfig(""))
+	syncer.ensureAtMostOnePodPerNode = workload.EnsureAtMostOnePodPerNode
+
+	deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	antiAffinity := deployment.Spec.Template.Spec.Affinity.PodAntiAffinity
+	if len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Errorf("expected the required anti-affinity of at most one pod per node, got %#v", antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	preferredTopologyKeys := []string{}
+	for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		preferredTopologyKeys = append(preferredTopologyKeys, term.PodAffinityTerm.TopologyKey)
+	}
+	if !equality.Semantic.DeepEqual(preferredTopologyKeys, []string{"kubernetes.io/hostname", "topology.kubernetes.io/zone"}) {
+		t.Errorf("expected the preferred anti-affinity to be kept, got the terms for %v", preferredTopologyKeys)
+	}
+	if len(deployment.Spec.Template.Spec.TopologySpreadConstraints) != 2 {
+		t.Errorf("expected the spread constraints to be kept, got %#v", deployment.Spec.Template.Spec.TopologySpreadConstraints)
+	}
+}
+
+func TestSyncIdentityProviderBrandingIcon(t *testing.T) {
+	operatorConfig := testOperatorConfig(`{"oauthServer":{"identityProviderBranding":{"github":{"icon":{"name":"github-icon"}}}}}`)
+
+	syncer, _ := newTestSyncer(operatorConfig)
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) == 0 {
+		t.Errorf("expected a missing icon configmap to fail the sync")
+	}
+
+	icon := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-icon", Namespace: "openshift-config"},
+		BinaryData: map[string][]byte{"icon": []byte("\x89PNG")},
+	}
+	syncer, _ = newTestSyncer(operatorConfig, icon)
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if synced := syncer.resourceSyncer.(*fakeResourceSyncer).synced; synced["configmap/openshift-authentication/v4-0-config-user-idp-icon-0"] != "openshift-config/github-icon" {
+		t.Errorf("expected the icon to be synced, got %v", synced)
+	}
+}
+
+func TestSyncTokenEncryptionKey(t *testing.T) {
+	operatorConfig := testOperatorConfig(`{"oauthServer":{"tokenEncryption":{"keySecret":{"name":"token-key"}}}}`)
+
+	tests := []struct {
+		name           string
+		keySecret      *corev1.Secret
+		wantStatus     operatorv1.ConditionStatus
+		wantMessage    string
+		wantDeployment bool
+	}{
+		{
+			name:        "missing key secret",
+			wantStatus:  operatorv1.ConditionTrue,
+			wantMessage: "the token encryption key secret openshift-config/token-key does not exist",
+		},
+		{
+			name: "missing key",
+			keySecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "token-key", Namespace: "openshift-config"},
+				Data:       map[string][]byte{"other": []byte("0123456789abcdef0123456789abcdef")},
+			},
+			wantStatus:  operatorv1.ConditionTrue,
+			wantMessage: `the token encryption key secret openshift-config/token-key is missing the "key" key`,
+		},
+		{
+			name: "present key",
+			keySecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "token-key", Namespace: "openshift-config"},
+				Data:       map[string][]byte{"key": []byte("0123456789abcdef0123456789abcdef")},
+			},
+			wantStatus:     operatorv1.ConditionFalse,
+			wantDeployment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []runtime.Object
+			if tt.keySecret != nil {
+				objs = append(objs, tt.keySecret)
+			}
+			syncer, kubeClient := newTestSyncer(operatorConfig, objs...)
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if tt.wantDeployment && len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if !tt.wantDeployment && len(errs) == 0 {
+				t.Fatalf("expected the missing key to fail the sync")
+			}
+
+			_, status, _, _ := syncer.operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, tokenEncryptionKeyConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", tokenEncryptionKeyConditionType)
+			}
+			if condition.Status != tt.wantStatus || condition.Message != tt.wantMessage {
+				t.Errorf("expected the condition %s with %q, got %s with %q", tt.wantStatus, tt.wantMessage, condition.Status, condition.Message)
+			}
+
+			if !tt.wantDeployment {
+				if _, err := kubeClient.AppsV1().Deployments("openshift-authentication").Get(context.Background(), "oauth-openshift", metav1.GetOptions{}); err == nil {
+					t.Errorf("expected no deployment to be applied without the key")
+				}
+				return
+			}
+			args := deployment.Spec.Template.Spec.Containers[0].Args[0]
+			for _, wantArg := range []string{
+				"--token-encryption-key-file=/var/config/user/secret/v4-0-config-user-token-encryption-key/key",
+				"--token-encryption-fail-closed=true",
+			} {
+				if !strings.Contains(args, wantArg) {
+					t.Errorf("expected the container args to contain %q, got:\n%s", wantArg, args)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncDebugPause(t *testing.T) {
+	tests := []struct {
+		name       string
+		overrides  string
+		wantStatus operatorv1.ConditionStatus
+		wantPaused bool
+	}{
+		{
+			name:       "not paused",
+			wantStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:       "paused",
+			overrides:  `{"oauthServer":{"debugPause":{"acknowledgeLoginOutage":true}}}`,
+			wantStatus: operatorv1.ConditionTrue,
+			wantPaused: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer, _ := newTestSyncer(testOperatorConfig(tt.overrides))
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			_, status, _, _ := syncer.operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, debugPauseConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", debugPauseConditionType)
+			}
+			if condition.Status != tt.wantStatus {
+				t.Errorf("expected the condition %s, got %s with %q", tt.wantStatus, condition.Status, condition.Message)
+			}
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			if paused := findEnvVar(container.Env, debugPauseCommandEnv) != nil; paused != tt.wantPaused {
+				t.Errorf("expected the deployment to be paused: %v, got %v", tt.wantPaused, paused)
+			}
+		})
+	}
+}
+
+func TestSyncReadyDeploymentEvent(t *testing.T) {
+	operatorConfig := testOperatorConfig("")
+	operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(`{"oauthServer":{"identityProviderCount":2}}`)}
+
+	syncer, kubeClient := newTestSyncer(operatorConfig)
+	recorder := events.NewInMemoryRecorder("test")
+	syncContext := factory.NewSyncContext("test", recorder)
+	readyEvents := func() []string {
+		var messages []string
+		for _, event := range recorder.Events() {
+			if event.Reason == "OAuthServerReady" {
+				messages = append(messages, event.Message)
+			}
+		}
+		return messages
+	}
+
+	deployment, _, errs := syncer.Sync(context.Background(), syncContext)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got := readyEvents(); len(got) > 0 {
+		t.Fatalf("expected no event before the pods are available, got %v", got)
+	}
+
+	deployment.Status = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}
+	if _, err := kubeClient.AppsV1().Deployments("openshift-authentication").UpdateStatus(context.Background(), deployment, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the following syncs of the same ready deployment do not repeat the event
+	for i := 0; i < 3; i++ {
+		if _, _, errs := syncer.Sync(context.Background(), syncContext); len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+	}
+
+	want := fmt.Sprintf("oauth-server is ready with 2 identity providers, image %s, rvs-hash %s",
+		deployment.Spec.Template.Spec.Containers[0].Image, deployment.Spec.Template.Annotations[deploymentVersionHashKey])
+	if got := readyEvents(); !equality.Semantic.DeepEqual(got, []string{want}) {
+		t.Errorf("expected exactly one event %q, got %v", want, got)
+	}
+}
+
+func TestSyncInvalidIdentityProviders(t *testing.T) {
+	tests := []struct {
+		name                string
+		policy              string
+		observedConfig      string
+		bootstrapUserExists bool
+		wantErrContains     string
+	}{
+		{
+			name: "deployment without identity providers rolls out by default",
+		},
+		{
+			name:            "fail closed",
+			policy:          "FailClosed",
+			wantErrContains: "none of the 1 configured identity providers is valid, keeping the current deployment",
+		},
+		{
+			name:           "fail closed with valid identity providers",
+			policy:         "FailClosed",
+			observedConfig: `{"oauthServer":{"identityProviderCount":1}}`,
+		},
+		{
+			name:                "bootstrap only",
+			policy:              "BootstrapOnly",
+			bootstrapUserExists: true,
+		},
+		{
+			name:            "bootstrap only without the bootstrap user",
+			policy:          "BootstrapOnly",
+			wantErrContains: "there is no bootstrap user to fall back to",
+		},
+		{
+			name:            "unknown policy",
+			policy:          "FailOpen",
+			wantErrContains: `invalidIdentityProvidersPolicy must be either "FailClosed" or "BootstrapOnly", got "FailOpen"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides := ""
+			if len(tt.policy) > 0 {
+				overrides = fmt.Sprintf(`{"oauthServer":{"invalidIdentityProvidersPolicy":%q}}`, tt.policy)
+			}
+			operatorConfig := testOperatorConfig(withoutRolloutCooldown(overrides))
+			if len(tt.observedConfig) > 0 {
+				operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(tt.observedConfig)}
+			}
+
+			currentDeployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{deploymentVersionHashKey: "last-known-good"}},
+					},
+				},
+			}
+			syncer, _ := newTestSyncer(operatorConfig, currentDeployment)
+			syncer.bootstrapUserDataGetter = &fakeBootstrapUserDataGetter{exists: tt.bootstrapUserExists}
+
+			// none of the identity providers made it to the observed config
+			oauthIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := oauthIndexer.Add(&configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.OAuthSpec{
+					IdentityProviders: []configv1.IdentityProvider{{Name: "ldap"}},
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			syncer.oauthLister = configv1listers.NewOAuthLister(oauthIndexer)
+
+			deployment, _, errs := syncer.Sync(context.Background(), testSyncContext())
+			if len(tt.wantErrContains) > 0 {
+				if err := utilerrors.NewAggregate(errs); err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErrContains, errs)
+				}
+				if deployment != nil && deployment.Spec.Template.Annotations[deploymentVersionHashKey] != "last-known-good" {
+					t.Errorf("expected the current deployment to be kept, got the rvs-hash %q", deployment.Spec.Template.Annotations[deploymentVersionHashKey])
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if deployment.Spec.Template.Annotations[deploymentVersionHashKey] == "last-known-good" {
+				t.Errorf("expected the new deployment to roll out")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+const (
+	// identityProviderListNamespace is where the other components find the
+	// published configuration of the cluster
+	identityProviderListNamespace = "openshift-config-managed"
+	identityProviderListName      = "oauth-identity-providers"
+	identityProviderListKey       = "identityProviders.json"
+)
+
+// identityProviderList returns the configmap that lists the names and the
+// types of the active identity providers, owned by the operator config so
+// that it goes away along with it
+func identityProviderList(operatorConfig *operatorv1.Authentication, summaries []observeoauth.IdentityProviderSummary) (*corev1.ConfigMap, error) {
+	list, err := json.Marshal(summaries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      identityProviderListName,
+			Namespace: identityProviderListNamespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: operatorv1.GroupVersion.String(),
+					Kind:       "Authentication",
+					Name:       operatorConfig.Name,
+					UID:        operatorConfig.UID,
+				},
+			},
+		},
+		Data: map[string]string{
+			identityProviderListKey: string(list),
+		},
+	}, nil
+}
+
+// syncIdentityProviderList publishes the active identity providers from the
+// observed config for the tools that need to know which ones are available
+// without reading the OAuth config and the secrets it references
+func (c *oauthServerDeploymentSyncer) syncIdentityProviderList(ctx context.Context, recorder events.Recorder, operatorConfig *operatorv1.Authentication) error {
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	summaries, err := observeoauth.GetIdentityProviderSummaries(configDeserialized)
+	if err != nil {
+		return fmt.Errorf("unable to get the identity providers: %w", err)
+	}
+
+	required, err := identityProviderList(operatorConfig, summaries)
+	if err != nil {
+		return err
+	}
+	if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMaps, recorder, required); err != nil {
+		return fmt.Errorf("unable to apply the identity provider list %s/%s: %w", identityProviderListNamespace, identityProviderListName, err)
+	}
+	return nil
+}
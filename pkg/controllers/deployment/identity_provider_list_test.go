@@ -0,0 +1,64 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+func TestSyncIdentityProviderList(t *testing.T) {
+	withIdentityProviders := func(identityProviders string) *operatorv1.Authentication {
+		operatorConfig := testOperatorConfig("")
+		operatorConfig.UID = types.UID("authentication-uid")
+		operatorConfig.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(`{"oauthServer":{"oauthConfig":{"identityProviders":` + identityProviders + `}}}`)}
+		return operatorConfig
+	}
+
+	syncer, kubeClient := newTestSyncer(withIdentityProviders(`[` +
+		`{"name":"htpasswd","challenge":true,"login":true,"mappingMethod":"claim","provider":{"apiVersion":"osin.config.openshift.io/v1","kind":"HTPasswdPasswordIdentityProvider","file":"/var/config/user/idp/0/secret/v4-0-config-user-idp-0-file-data/htpasswd"}},` +
+		`{"name":"sso","challenge":false,"login":true,"mappingMethod":"claim","provider":{"apiVersion":"osin.config.openshift.io/v1","kind":"OpenIDIdentityProvider","clientID":"oauth","clientSecret":{"file":"/var/config/user/idp/1/secret/v4-0-config-user-idp-1-client-secret/clientSecret"}}}` +
+		`]`))
+
+	getList := func() string {
+		t.Helper()
+		list, err := kubeClient.CoreV1().ConfigMaps(identityProviderListNamespace).Get(context.Background(), identityProviderListName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(list.OwnerReferences) != 1 || list.OwnerReferences[0].Kind != "Authentication" || list.OwnerReferences[0].UID != "authentication-uid" {
+			t.Errorf("expected the list to be owned by the operator config, got %#v", list.OwnerReferences)
+		}
+		return list.Data[identityProviderListKey]
+	}
+
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got, want := getList(), `[{"name":"htpasswd","type":"HTPasswd"},{"name":"sso","type":"OpenID"}]`; got != want {
+		t.Errorf("expected the identity providers %s, got %s", want, got)
+	}
+
+	// the list follows the observed config
+	syncer.auth = &fakeAuthenticationsGetter{authentications: &fakeAuthentications{authentication: withIdentityProviders(`[` +
+		`{"name":"ldap","challenge":true,"login":true,"mappingMethod":"claim","provider":{"apiVersion":"osin.config.openshift.io/v1","kind":"LDAPPasswordIdentityProvider","url":"ldap://ldap.example.com/ou=users,dc=example,dc=com?uid"}}` +
+		`]`)}}
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got, want := getList(), `[{"name":"ldap","type":"LDAP"}]`; got != want {
+		t.Errorf("expected the identity providers %s, got %s", want, got)
+	}
+
+	syncer.auth = &fakeAuthenticationsGetter{authentications: &fakeAuthentications{authentication: withIdentityProviders(`[]`)}}
+	if _, _, errs := syncer.Sync(context.Background(), testSyncContext()); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got, want := getList(), `[]`; got != want {
+		t.Errorf("expected no identity providers, got %s", got)
+	}
+}
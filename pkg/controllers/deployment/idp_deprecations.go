@@ -0,0 +1,57 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+// identityProviderDeprecationsConditionType is informational only, it does not
+// end with any of the suffixes the clusteroperator status gets aggregated from
+const identityProviderDeprecationsConditionType = "IdentityProviderSettingsDeprecated"
+
+// syncIdentityProviderDeprecations reports the deprecated identity provider
+// settings found by the config observer in the operator status. The settings
+// still apply, the condition only lets the admin know they need to be replaced.
+func (c *oauthServerDeploymentSyncer) syncIdentityProviderDeprecations(ctx context.Context, operatorConfig *operatorv1.Authentication) error {
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	deprecations, err := observeoauth.GetIdentityProviderDeprecations(configDeserialized)
+	if err != nil {
+		return fmt.Errorf("unable to get the deprecated identity provider settings: %w", err)
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   identityProviderDeprecationsConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(deprecations) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "DeprecatedSettingsInUse"
+		condition.Message = strings.Join(deprecations, "\n")
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
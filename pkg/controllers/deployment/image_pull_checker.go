@@ -0,0 +1,150 @@
+package deployment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+)
+
+// imagePullFailureTimeout is how long the oauth-server pods may keep failing
+// to pull their image before it is reported, the kubelet retries the pulls
+// with a backoff and recovers from the transient failures of the registries
+const imagePullFailureTimeout = 5 * time.Minute
+
+// imagePullChecker tells whether the oauth-server image can be pulled
+type imagePullChecker interface {
+	// Check returns an error when the pods of the deployment persistently
+	// fail to pull the given image
+	Check(image string) error
+}
+
+var _ imagePullChecker = &mirrorImagePullChecker{}
+
+// mirrorImagePullChecker watches the oauth-server pods for failures to pull
+// their image. The image is not rewritten to any of its mirrors, CRI-O already
+// tries all the mirrors of the cluster's ImageDigestMirrorSets along with the
+// source on every pull, so a pull failure means none of them served the image.
+// The mirror sets only make the reported error list the sources that failed.
+type mirrorImagePullChecker struct {
+	imageDigestMirrorSetLister configv1listers.ImageDigestMirrorSetLister
+	podsLister                 corev1listers.PodLister
+	clock                      clock.PassiveClock
+
+	// failingImage is the image the pods were seen to fail to pull since
+	// failingSince, the time is reset once none of the pods fails anymore
+	failingImage string
+	failingSince time.Time
+}
+
+func (c *mirrorImagePullChecker) Check(image string) error {
+	failing, err := c.podsFailingToPull(image)
+	if err != nil {
+		return err
+	}
+	if !failing {
+		c.failingImage, c.failingSince = "", time.Time{}
+		return nil
+	}
+
+	now := c.clock.Now()
+	if c.failingImage != image {
+		c.failingImage, c.failingSince = image, now
+	}
+	if failingFor := now.Sub(c.failingSince); failingFor < imagePullFailureTimeout {
+		klog.V(4).Infof("the oauth-server pods have been failing to pull the image %q for %v", image, failingFor)
+		return nil
+	}
+
+	sources := []string{image}
+	// mirrors only apply to images pulled by digest
+	if strings.Contains(image, "@") {
+		mirrorSets, err := c.imageDigestMirrorSetLister.List(labels.Everything())
+		if err != nil {
+			return fmt.Errorf("unable to list the image digest mirror sets: %w", err)
+		}
+		sources = imageSources(image, mirrorSets)
+	}
+	return fmt.Errorf("unable to pull the oauth-server image %q from any of its sources for %v: %s", image, imagePullFailureTimeout, strings.Join(sources, ", "))
+}
+
+// podsFailingToPull tells whether any of the oauth-server pods currently fails
+// to pull the image
+func (c *mirrorImagePullChecker) podsFailingToPull(image string) (bool, error) {
+	pods, err := c.podsLister.Pods("openshift-authentication").List(labels.SelectorFromSet(labels.Set{"app": "oauth-openshift"}))
+	if err != nil {
+		return false, fmt.Errorf("unable to list the oauth-openshift pods: %w", err)
+	}
+
+	for _, pod := range pods {
+		images := map[string]string{}
+		for _, container := range pod.Spec.Containers {
+			images[container.Name] = container.Image
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if images[status.Name] == image && isImagePullFailure(status) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func isImagePullFailure(status corev1.ContainerStatus) bool {
+	if status.State.Waiting == nil {
+		return false
+	}
+	switch status.State.Waiting.Reason {
+	case "ErrImagePull", "ImagePullBackOff":
+		return true
+	}
+	return false
+}
+
+// imageSources returns the references the image is pulled from, the mirrors
+// go first in the order of their mirror sets, and the image itself last unless
+// one of the matching sources forbids contacting it
+func imageSources(image string, mirrorSets []*configv1.ImageDigestMirrorSet) []string {
+	digestIdx := strings.Index(image, "@")
+	repository, digest := image[:digestIdx], image[digestIdx:]
+
+	sorted := make([]*configv1.ImageDigestMirrorSet, len(mirrorSets))
+	copy(sorted, mirrorSets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	sources := []string{}
+	seen := sets.NewString()
+	contactSource := true
+	for _, mirrorSet := range sorted {
+		for _, digestMirrors := range mirrorSet.Spec.ImageDigestMirrors {
+			if repository != digestMirrors.Source && !strings.HasPrefix(repository, digestMirrors.Source+"/") {
+				continue
+			}
+			if digestMirrors.MirrorSourcePolicy == configv1.NeverContactSource {
+				contactSource = false
+			}
+			for _, mirror := range digestMirrors.Mirrors {
+				source := string(mirror) + strings.TrimPrefix(repository, digestMirrors.Source) + digest
+				if !seen.Has(source) {
+					sources = append(sources, source)
+					seen.Insert(source)
+				}
+			}
+		}
+	}
+
+	if contactSource && !seen.Has(image) {
+		sources = append(sources, image)
+	}
+	return sources
+}
@@ -0,0 +1,149 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+)
+
+func testPullingPod(name, image, waitingReason string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-authentication", Labels: map[string]string{"app": "oauth-openshift"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "oauth-openshift", Image: image}}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "oauth-openshift",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}},
+			}},
+		},
+	}
+}
+
+func testMirrorSet() *configv1.ImageDigestMirrorSet {
+	return &configv1.ImageDigestMirrorSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirrors"},
+		Spec: configv1.ImageDigestMirrorSetSpec{
+			ImageDigestMirrors: []configv1.ImageDigestMirrors{{
+				Source:  "quay.io/openshift",
+				Mirrors: []configv1.ImageMirror{"mirror-a.example.com/openshift", "mirror-b.example.com/openshift"},
+			}},
+		},
+	}
+}
+
+func TestImageSources(t *testing.T) {
+	const image = "quay.io/openshift/oauth-server@sha256:1234"
+
+	neverContactSource := testMirrorSet()
+	neverContactSource.Spec.ImageDigestMirrors[0].MirrorSourcePolicy = configv1.NeverContactSource
+	otherSource := testMirrorSet()
+	otherSource.Spec.ImageDigestMirrors[0].Source = "quay.io/other"
+
+	tests := []struct {
+		name        string
+		mirrorSets  []*configv1.ImageDigestMirrorSet
+		wantSources []string
+	}{
+		{
+			name:        "no mirrors",
+			wantSources: []string{image},
+		},
+		{
+			name:       "mirrors before the source",
+			mirrorSets: []*configv1.ImageDigestMirrorSet{testMirrorSet()},
+			wantSources: []string{
+				"mirror-a.example.com/openshift/oauth-server@sha256:1234",
+				"mirror-b.example.com/openshift/oauth-server@sha256:1234",
+				image,
+			},
+		},
+		{
+			name:       "source never contacted",
+			mirrorSets: []*configv1.ImageDigestMirrorSet{neverContactSource},
+			wantSources: []string{
+				"mirror-a.example.com/openshift/oauth-server@sha256:1234",
+				"mirror-b.example.com/openshift/oauth-server@sha256:1234",
+			},
+		},
+		{
+			name:        "mirrors of another source",
+			mirrorSets:  []*configv1.ImageDigestMirrorSet{otherSource},
+			wantSources: []string{image},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageSources(image, tt.mirrorSets); strings.Join(got, ",") != strings.Join(tt.wantSources, ",") {
+				t.Errorf("expected the sources %v, got %v", tt.wantSources, got)
+			}
+		})
+	}
+}
+
+func TestMirrorImagePullChecker(t *testing.T) {
+	const image = "quay.io/openshift/oauth-server@sha256:1234"
+
+	mirrorSetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := mirrorSetIndexer.Add(testMirrorSet()); err != nil {
+		t.Fatal(err)
+	}
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	fakeClock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	checker := &mirrorImagePullChecker{
+		imageDigestMirrorSetLister: configv1listers.NewImageDigestMirrorSetLister(mirrorSetIndexer),
+		podsLister:                 corev1listers.NewPodLister(podIndexer),
+		clock:                      fakeClock,
+	}
+	check := func(step time.Duration) error {
+		fakeClock.SetTime(fakeClock.Now().Add(step))
+		return checker.Check(image)
+	}
+
+	if err := check(0); err != nil {
+		t.Fatalf("unexpected error without any pods: %v", err)
+	}
+
+	// the pods of the previous image are not of interest
+	if err := podIndexer.Add(testPullingPod("oauth-openshift-0", "quay.io/openshift/oauth-server@sha256:0000", "ImagePullBackOff")); err != nil {
+		t.Fatal(err)
+	}
+	failingPod := testPullingPod("oauth-openshift-1", image, "ImagePullBackOff")
+	if err := podIndexer.Add(failingPod); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(0); err != nil {
+		t.Fatalf("expected a new pull failure not to be reported, got %v", err)
+	}
+	if err := check(imagePullFailureTimeout - time.Minute); err != nil {
+		t.Fatalf("expected a pull failure within the timeout not to be reported, got %v", err)
+	}
+
+	// the transient failure recovers, the timeout starts over with the next one
+	if err := podIndexer.Delete(failingPod); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(0); err != nil {
+		t.Fatalf("unexpected error once the pull recovered: %v", err)
+	}
+	if err := podIndexer.Add(failingPod); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(time.Minute); err != nil {
+		t.Fatalf("expected the timeout to start over, got %v", err)
+	}
+
+	err := check(imagePullFailureTimeout)
+	wantErr := `unable to pull the oauth-server image "quay.io/openshift/oauth-server@sha256:1234" from any of its sources for 5m0s: mirror-a.example.com/openshift/oauth-server@sha256:1234, mirror-b.example.com/openshift/oauth-server@sha256:1234, quay.io/openshift/oauth-server@sha256:1234`
+	if err == nil || err.Error() != wantErr {
+		t.Fatalf("expected the persistent pull failure to be reported as %q, got %v", wantErr, err)
+	}
+}
@@ -0,0 +1,72 @@
+package deployment
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+// checkInvalidIdentityProviders applies the policy for the identity providers
+// of the cluster OAuth config that are all invalid. The config observer keeps
+// the last valid identity providers when some become invalid, so they are
+// only all invalid when none were ever observed. The error says why the
+// current deployment should be kept.
+func (c *oauthServerDeploymentSyncer) checkInvalidIdentityProviders(operatorConfig *operatorv1.Authentication, policy invalidIdentityProvidersPolicy) error {
+	if len(policy) == 0 {
+		return nil
+	}
+
+	oauthConfig, err := c.oauthLister.Get("cluster")
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	configured := len(oauthConfig.Spec.IdentityProviders)
+	if configured == 0 {
+		return nil
+	}
+
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	observed, err := observeoauth.GetIdentityProviderCount(configDeserialized)
+	if err != nil {
+		return fmt.Errorf("unable to get the identity provider count: %w", err)
+	}
+	if observed > 0 {
+		return nil
+	}
+
+	if policy == invalidIdentityProvidersBootstrapOnly {
+		bootstrapUserExists, err := c.bootstrapUserDataGetter.IsEnabled()
+		if err != nil {
+			return fmt.Errorf("unable to determine the state of bootstrap user: %w", err)
+		}
+		if bootstrapUserExists {
+			klog.Warningf("none of the %d configured identity providers is valid, only the bootstrap user can log in", configured)
+			return nil
+		}
+		return fmt.Errorf("none of the %d configured identity providers is valid and there is no bootstrap user to fall back to, keeping the current deployment", configured)
+	}
+	return fmt.Errorf("none of the %d configured identity providers is valid, keeping the current deployment", configured)
+}
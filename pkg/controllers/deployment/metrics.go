@@ -0,0 +1,58 @@
+package deployment
+
+import (
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	appliedDeploymentInfo = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "openshift_authentication_operator_oauth_server_deployment_info",
+			Help:           "Reports the rvs-hash of the oauth-openshift deployment that was last applied by the operator. Always has the value of 1.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"rvs_hash"},
+	)
+
+	rolloutsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "openshift_authentication_operator_oauth_server_rollouts_total",
+			Help:           "Counts the rollouts of the oauth-openshift pods triggered by the operator by the most specific reason of the rollout.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+
+	lastSuccessfulSync = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Name:           "openshift_authentication_operator_oauth_server_last_successful_sync_timestamp_seconds",
+			Help:           "Reports the unix time of the last sync of the oauth-openshift deployment that finished without errors.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(appliedDeploymentInfo, rolloutsTotal, lastSuccessfulSync)
+}
+
+// recordAppliedDeployment makes the info metric only report the rvs-hash of
+// the given deployment so that dashboards can correlate rollouts with other metrics
+func recordAppliedDeployment(rvsHash string) {
+	appliedDeploymentInfo.Reset()
+	appliedDeploymentInfo.WithLabelValues(rvsHash).Set(1)
+}
+
+// recordRollout counts a rollout of the oauth-server pods
+func recordRollout(reason string) {
+	rolloutsTotal.WithLabelValues(reason).Inc()
+}
+
+// recordSuccessfulSync records the time of the last sync without errors, the
+// time since the sync is the difference from the time of the scrape
+func recordSuccessfulSync(now time.Time) {
+	lastSuccessfulSync.Set(float64(now.Unix()))
+}
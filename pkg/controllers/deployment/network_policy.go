@@ -0,0 +1,249 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+const (
+	networkPolicyName = "oauth-openshift"
+	// policyGroupLabelPrefix is the prefix of the namespace labels OpenShift
+	// networking uses to select the traffic of the router and of the host network
+	policyGroupLabelPrefix = "policy-group.network.openshift.io/"
+)
+
+// probingNamespaces are the namespaces whose pods connect to the oauth-server
+// pods directly: the operator checks the endpoints of the oauth-openshift
+// service and the monitoring scrapes its metrics
+var probingNamespaces = []string{"openshift-authentication-operator", "openshift-monitoring"}
+
+var (
+	oauthServerPort = intstr.FromInt(6443)
+	// the cluster DNS listens on 5353 behind the 53 of its service
+	dnsPorts         = []intstr.IntOrString{intstr.FromInt(53), intstr.FromInt(5353)}
+	kubeAPIPort      = intstr.FromInt(6443)
+	defaultHTTPSPort = intstr.FromInt(443)
+)
+
+// oauthServerNetworkPolicy returns a NetworkPolicy that only allows the traffic
+// oauth-server needs: the ingress from the router, the operator and the
+// monitoring and the egress to the cluster DNS, the kube-apiserver, the
+// cluster proxy and the identity providers from the observed config. The
+// endpoints referenced by their hostnames can only be restricted by their
+// ports as NetworkPolicies do not match on names.
+func oauthServerNetworkPolicy(observedConfig []byte, proxy *configv1.Proxy) (*networkingv1.NetworkPolicy, error) {
+	endpoints, err := getEgressEndpoints(observedConfig, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tcp, udp := corev1.ProtocolTCP, corev1.ProtocolUDP
+	policyPort := func(protocol corev1.Protocol, port intstr.IntOrString) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port}
+	}
+
+	dnsRule := networkingv1.NetworkPolicyEgressRule{}
+	for _, port := range dnsPorts {
+		dnsRule.Ports = append(dnsRule.Ports, policyPort(udp, port), policyPort(tcp, port))
+	}
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		dnsRule,
+		{Ports: []networkingv1.NetworkPolicyPort{policyPort(tcp, kubeAPIPort)}},
+	}
+	for _, endpoint := range endpoints {
+		rule := networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{policyPort(tcp, endpoint.port)},
+		}
+		if len(endpoint.cidr) > 0 {
+			rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: endpoint.cidr}}}
+		}
+		egress = append(egress, rule)
+	}
+
+	namespacePeer := func(policyGroup string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{policyGroupLabelPrefix + policyGroup: ""},
+			},
+		}
+	}
+
+	// the router runs in the host network on some platforms
+	from := []networkingv1.NetworkPolicyPeer{namespacePeer("ingress"), namespacePeer("host-network")}
+	for _, namespace := range probingNamespaces {
+		from = append(from, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+			},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyName,
+			Namespace: targetNamespace,
+			Labels:    map[string]string{"app": "oauth-openshift"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "oauth-openshift"},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From:  from,
+					Ports: []networkingv1.NetworkPolicyPort{policyPort(tcp, oauthServerPort)},
+				},
+			},
+			Egress: egress,
+		},
+	}, nil
+}
+
+type egressEndpoint struct {
+	// cidr is only set for the endpoints referenced by their IPs
+	cidr string
+	port intstr.IntOrString
+}
+
+// getEgressEndpoints returns the deduplicated endpoints of the identity
+// providers from the observed config and of the cluster proxy in a stable order
+func getEgressEndpoints(observedConfig []byte, proxy *configv1.Proxy) ([]egressEndpoint, error) {
+	config := map[string]interface{}{}
+	if len(observedConfig) > 0 {
+		if err := json.Unmarshal(observedConfig, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the observedConfig: %w", err)
+		}
+	}
+	idpEndpoints, err := observeoauth.GetIdentityProviderEndpoints(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the identity provider endpoints: %w", err)
+	}
+
+	var rawURLs []string
+	for _, endpoint := range idpEndpoints {
+		rawURLs = append(rawURLs, endpoint.URL)
+	}
+	// oauth-server reaches the identity providers outside of the cluster
+	// through the proxy
+	for _, proxyURL := range []string{proxy.Status.HTTPProxy, proxy.Status.HTTPSProxy} {
+		if len(proxyURL) > 0 {
+			rawURLs = append(rawURLs, proxyURL)
+		}
+	}
+
+	found := map[egressEndpoint]bool{}
+	for _, rawURL := range rawURLs {
+		endpoint, err := toEgressEndpoint(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		found[endpoint] = true
+	}
+
+	endpoints := make([]egressEndpoint, 0, len(found))
+	for endpoint := range found {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].cidr != endpoints[j].cidr {
+			return endpoints[i].cidr < endpoints[j].cidr
+		}
+		return endpoints[i].port.IntValue() < endpoints[j].port.IntValue()
+	})
+	return endpoints, nil
+}
+
+func toEgressEndpoint(rawURL string) (egressEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return egressEndpoint{}, fmt.Errorf("failed to parse the URL %q: %w", rawURL, err)
+	}
+
+	endpoint := egressEndpoint{port: defaultHTTPSPort}
+	switch u.Scheme {
+	case "ldap":
+		endpoint.port = intstr.FromInt(389)
+	case "ldaps":
+		endpoint.port = intstr.FromInt(636)
+	case "http":
+		endpoint.port = intstr.FromInt(80)
+	}
+	if port := u.Port(); len(port) > 0 {
+		portNumber, err := strconv.Atoi(port)
+		if err != nil {
+			return egressEndpoint{}, fmt.Errorf("invalid port in the URL %q: %w", rawURL, err)
+		}
+		endpoint.port = intstr.FromInt(portNumber)
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if ip.To4() != nil {
+			endpoint.cidr = ip.String() + "/32"
+		} else {
+			endpoint.cidr = ip.String() + "/128"
+		}
+	}
+
+	return endpoint, nil
+}
+
+// applyNetworkPolicy creates or updates the NetworkPolicy. Clusters that do
+// not serve the NetworkPolicy API are left alone.
+func applyNetworkPolicy(ctx context.Context, client networkingv1client.NetworkPoliciesGetter, required *networkingv1.NetworkPolicy) error {
+	existing, err := client.NetworkPolicies(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.NetworkPolicies(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			klog.Warningf("unable to create the oauth-server NetworkPolicy, the API is not available: %v", err)
+			return nil
+		}
+		return err
+	}
+	if meta.IsNoMatchError(err) {
+		klog.Warningf("unable to get the oauth-server NetworkPolicy, the API is not available: %v", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, required.Spec) && equality.Semantic.DeepEqual(existing.Labels, required.Labels) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels = required.Labels
+	updated.Spec = required.Spec
+	_, err = client.NetworkPolicies(required.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// deleteNetworkPolicy removes the NetworkPolicy once it is no longer requested
+func deleteNetworkPolicy(ctx context.Context, client networkingv1client.NetworkPoliciesGetter) error {
+	err := client.NetworkPolicies(targetNamespace).Delete(ctx, networkPolicyName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
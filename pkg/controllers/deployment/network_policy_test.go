@@ -0,0 +1,159 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestOAuthServerNetworkPolicy(t *testing.T) {
+	observedConfig := []byte(`{
+		"oauthConfig": {
+			"identityProviders": [
+				{"name": "github", "provider": {"kind": "GitHubIdentityProvider", "hostname": "github.example.com"}},
+				{"name": "ldap", "provider": {"kind": "LDAPPasswordIdentityProvider", "url": "ldaps://10.0.0.10/ou=users,dc=example,dc=com?uid"}},
+				{"name": "oidc", "provider": {"kind": "OpenIDIdentityProvider", "urls": {"authorize": "https://sso.example.com:8443/auth", "token": "https://sso.example.com:8443/token"}}}
+			]
+		}
+	}`)
+
+	policy, err := oauthServerNetworkPolicy(observedConfig, &configv1.Proxy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (metav1.LabelSelector{MatchLabels: map[string]string{"app": "oauth-openshift"}}); !cmp.Equal(want, policy.Spec.PodSelector) {
+		t.Errorf("unexpected pod selector: %s", cmp.Diff(want, policy.Spec.PodSelector))
+	}
+	if want := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}; !cmp.Equal(want, policy.Spec.PolicyTypes) {
+		t.Errorf("unexpected policy types: %s", cmp.Diff(want, policy.Spec.PolicyTypes))
+	}
+
+	tcp, udp := corev1.ProtocolTCP, corev1.ProtocolUDP
+	port := func(protocol *corev1.Protocol, port int) networkingv1.NetworkPolicyPort {
+		p := intstr.FromInt(port)
+		return networkingv1.NetworkPolicyPort{Protocol: protocol, Port: &p}
+	}
+	namespaceSelector := func(policyGroup string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"policy-group.network.openshift.io/" + policyGroup: ""},
+		}}
+	}
+
+	namespaceNameSelector := func(namespace string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+		}}
+	}
+
+	wantIngress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				namespaceSelector("ingress"),
+				namespaceSelector("host-network"),
+				// the endpoint checks of the operator and the metrics scraping
+				namespaceNameSelector("openshift-authentication-operator"),
+				namespaceNameSelector("openshift-monitoring"),
+			},
+			Ports: []networkingv1.NetworkPolicyPort{port(&tcp, 6443)},
+		},
+	}
+	if !cmp.Equal(wantIngress, policy.Spec.Ingress) {
+		t.Errorf("unexpected ingress rules: %s", cmp.Diff(wantIngress, policy.Spec.Ingress))
+	}
+
+	wantEgress := []networkingv1.NetworkPolicyEgressRule{
+		// DNS
+		{Ports: []networkingv1.NetworkPolicyPort{port(&udp, 53), port(&tcp, 53), port(&udp, 5353), port(&tcp, 5353)}},
+		// kube-apiserver
+		{Ports: []networkingv1.NetworkPolicyPort{port(&tcp, 6443)}},
+		// github and the OpenID provider can only be restricted by their ports
+		{Ports: []networkingv1.NetworkPolicyPort{port(&tcp, 443)}},
+		{Ports: []networkingv1.NetworkPolicyPort{port(&tcp, 8443)}},
+		// ldap
+		{
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.10/32"}}},
+			Ports: []networkingv1.NetworkPolicyPort{port(&tcp, 636)},
+		},
+	}
+	if !cmp.Equal(wantEgress, policy.Spec.Egress) {
+		t.Errorf("unexpected egress rules: %s", cmp.Diff(wantEgress, policy.Spec.Egress))
+	}
+
+	kubeClient := fake.NewSimpleClientset()
+	for i := 0; i < 2; i++ {
+		if err := applyNetworkPolicy(context.Background(), kubeClient.NetworkingV1(), policy); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	writes := 0
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "create" || action.GetVerb() == "update" {
+			writes++
+		}
+	}
+	if writes != 1 {
+		t.Errorf("expected an unchanged policy not to be updated, got %d writes", writes)
+	}
+}
+
+func TestOAuthServerNetworkPolicyEgressEndpoints(t *testing.T) {
+	tcp := corev1.ProtocolTCP
+	rule := func(cidr string, portNumber int) networkingv1.NetworkPolicyEgressRule {
+		port := intstr.FromInt(portNumber)
+		rule := networkingv1.NetworkPolicyEgressRule{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &port}}}
+		if len(cidr) > 0 {
+			rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+		}
+		return rule
+	}
+
+	tests := []struct {
+		name           string
+		observedConfig string
+		proxy          configv1.ProxyStatus
+		want           []networkingv1.NetworkPolicyEgressRule
+	}{
+		{
+			name:           "github.com",
+			observedConfig: `{"oauthConfig":{"identityProviders":[{"name":"github","provider":{"kind":"GitHubIdentityProvider"}}]}}`,
+			want:           []networkingv1.NetworkPolicyEgressRule{rule("", 443)},
+		},
+		{
+			name:           "google",
+			observedConfig: `{"oauthConfig":{"identityProviders":[{"name":"google","provider":{"kind":"GoogleIdentityProvider"}}]}}`,
+			want:           []networkingv1.NetworkPolicyEgressRule{rule("", 443)},
+		},
+		{
+			name:           "proxied cluster",
+			observedConfig: `{"oauthConfig":{"identityProviders":[{"name":"google","provider":{"kind":"GoogleIdentityProvider"}}]}}`,
+			proxy:          configv1.ProxyStatus{HTTPProxy: "http://10.0.0.5:3128", HTTPSProxy: "http://proxy.example.com:3129"},
+			want:           []networkingv1.NetworkPolicyEgressRule{rule("", 443), rule("", 3129), rule("10.0.0.5/32", 3128)},
+		},
+		{
+			name: "no identity providers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := oauthServerNetworkPolicy([]byte(tt.observedConfig), &configv1.Proxy{Status: tt.proxy})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// the DNS and the kube-apiserver rules always come first
+			if got := policy.Spec.Egress[2:]; !cmp.Equal(tt.want, got, cmpopts.EquateEmpty()) {
+				t.Errorf("unexpected egress rules: %s", cmp.Diff(tt.want, got, cmpopts.EquateEmpty()))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package deployment
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+// announceReadyDeployment emits a single event summarizing the active
+// configuration the first time all the pods of a deployment run its rvs-hash
+// and are available, so that the operator events record when a config change
+// actually took effect
+func (c *oauthServerDeploymentSyncer) announceReadyDeployment(recorder events.Recorder, operatorConfig *operatorv1.Authentication, deployment *appsv1.Deployment) error {
+	rvsHash := deployment.Spec.Template.Annotations[deploymentVersionHashKey]
+	if rvsHash == c.announcedReadyHash || !deploymentFullyReady(deployment) {
+		return nil
+	}
+
+	observedConfig, err := common.UnstructuredConfigFrom(
+		operatorConfig.Spec.ObservedConfig.Raw,
+		configobservation.OAuthServerConfigPrefix,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	idpCount, err := observeoauth.GetIdentityProviderCount(configDeserialized)
+	if err != nil {
+		return fmt.Errorf("unable to get the identity provider count: %w", err)
+	}
+
+	recorder.Eventf("OAuthServerReady", "oauth-server is ready with %d identity providers, image %s, rvs-hash %s",
+		idpCount, deployment.Spec.Template.Spec.Containers[0].Image, rvsHash)
+	c.announcedReadyHash = rvsHash
+	return nil
+}
+
+// deploymentFullyReady tells whether the rollout of the current template of
+// the deployment is complete and no pods of the previous templates remain
+func deploymentFullyReady(deployment *appsv1.Deployment) bool {
+	if deployment.Spec.Replicas == nil || deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := *deployment.Spec.Replicas
+	return replicas > 0 &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
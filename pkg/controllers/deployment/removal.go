@@ -0,0 +1,178 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	routeinformers "github.com/openshift/client-go/route/informers/externalversions"
+	routev1listers "github.com/openshift/client-go/route/listers/route/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	// cliConfigName is the configmap with the config of oauth-server
+	cliConfigName = "v4-0-config-system-cliconfig"
+	// metadataName is the configmap with the OAuth metadata served by oauth-server
+	metadataName = "v4-0-config-system-metadata"
+	// sessionSecretName is the secret with the session secrets of oauth-server
+	sessionSecretName = "v4-0-config-system-session"
+)
+
+type oauthServerRemovalController struct {
+	operatorClient v1helpers.OperatorClient
+
+	deployments     appsv1client.DeploymentsGetter
+	networkPolicies networkingv1client.NetworkPoliciesGetter
+	configMaps      corev1client.ConfigMapsGetter
+	secrets         corev1client.SecretsGetter
+	routes          routev1client.RoutesGetter
+
+	deploymentLister    appsv1listers.DeploymentLister
+	networkPolicyLister networkingv1listers.NetworkPolicyLister
+	configMapLister     corev1listers.ConfigMapLister
+	secretLister        corev1listers.SecretLister
+	routeLister         routev1listers.RouteLister
+}
+
+// NewOAuthServerRemovalController returns a controller that deletes the
+// oauth-openshift deployment, its route and the config the operator generates
+// for it once the management state of the operator is Removed. The controllers
+// that manage these resources do not run in the Removed state and restore them
+// once the operator is Managed again. The namespace with the static resources
+// and the synced copies of the admin-provided resources are kept.
+func NewOAuthServerRemovalController(
+	operatorClient v1helpers.OperatorClient,
+	kubeClient kubernetes.Interface,
+	routeClient routev1client.RoutesGetter,
+	kubeInformersForTargetNamespace informers.SharedInformerFactory,
+	routeInformersForTargetNamespace routeinformers.SharedInformerFactory,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &oauthServerRemovalController{
+		operatorClient: operatorClient,
+
+		deployments:     kubeClient.AppsV1(),
+		networkPolicies: kubeClient.NetworkingV1(),
+		configMaps:      kubeClient.CoreV1(),
+		secrets:         kubeClient.CoreV1(),
+		routes:          routeClient,
+
+		deploymentLister:    kubeInformersForTargetNamespace.Apps().V1().Deployments().Lister(),
+		networkPolicyLister: kubeInformersForTargetNamespace.Networking().V1().NetworkPolicies().Lister(),
+		configMapLister:     kubeInformersForTargetNamespace.Core().V1().ConfigMaps().Lister(),
+		secretLister:        kubeInformersForTargetNamespace.Core().V1().Secrets().Lister(),
+		routeLister:         routeInformersForTargetNamespace.Route().V1().Routes().Lister(),
+	}
+
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			kubeInformersForTargetNamespace.Apps().V1().Deployments().Informer(),
+			kubeInformersForTargetNamespace.Networking().V1().NetworkPolicies().Informer(),
+			kubeInformersForTargetNamespace.Core().V1().ConfigMaps().Informer(),
+			kubeInformersForTargetNamespace.Core().V1().Secrets().Informer(),
+			routeInformersForTargetNamespace.Route().V1().Routes().Informer(),
+		).
+		WithSync(c.sync).
+		WithSyncDegradedOnError(operatorClient).
+		ResyncEvery(wait.Jitter(time.Minute, 1.0)).
+		ToController("OAuthServerRemovalController", recorder.WithComponentSuffix("oauth-server-removal-controller"))
+}
+
+func (c *oauthServerRemovalController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if spec.ManagementState != operatorv1.Removed {
+		return nil
+	}
+
+	// the deployment goes first so that oauth-server does not run with
+	// its config missing
+	errs := []error{
+		c.remove(ctx, syncCtx.Recorder(), "deployment", "oauth-openshift",
+			func(name string) error {
+				_, err := c.deploymentLister.Deployments(targetNamespace).Get(name)
+				return err
+			},
+			func(ctx context.Context, name string) error {
+				return c.deployments.Deployments(targetNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		),
+		c.remove(ctx, syncCtx.Recorder(), "networkpolicy", networkPolicyName,
+			func(name string) error {
+				_, err := c.networkPolicyLister.NetworkPolicies(targetNamespace).Get(name)
+				return err
+			},
+			func(ctx context.Context, name string) error {
+				return c.networkPolicies.NetworkPolicies(targetNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		),
+		c.remove(ctx, syncCtx.Recorder(), "route", "oauth-openshift",
+			func(name string) error {
+				_, err := c.routeLister.Routes(targetNamespace).Get(name)
+				return err
+			},
+			func(ctx context.Context, name string) error {
+				return c.routes.Routes(targetNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		),
+		c.remove(ctx, syncCtx.Recorder(), "secret", sessionSecretName,
+			func(name string) error {
+				_, err := c.secretLister.Secrets(targetNamespace).Get(name)
+				return err
+			},
+			func(ctx context.Context, name string) error {
+				return c.secrets.Secrets(targetNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		),
+	}
+	for _, name := range []string{cliConfigName, metadataName} {
+		errs = append(errs, c.remove(ctx, syncCtx.Recorder(), "configmap", name,
+			func(name string) error {
+				_, err := c.configMapLister.ConfigMaps(targetNamespace).Get(name)
+				return err
+			},
+			func(ctx context.Context, name string) error {
+				return c.configMaps.ConfigMaps(targetNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		))
+	}
+	return v1helpers.NewMultiLineAggregate(errs)
+}
+
+// remove deletes the named resource of the target namespace unless the lister
+// no longer finds it
+func (c *oauthServerRemovalController) remove(ctx context.Context, recorder events.Recorder, kind, name string, get func(name string) error, del func(ctx context.Context, name string) error) error {
+	if err := get(name); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to get the %s %s/%s: %w", kind, targetNamespace, name, err)
+	}
+
+	if err := del(ctx, name); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to delete the %s %s/%s: %w", kind, targetNamespace, name, err)
+	}
+	recorder.Eventf("OAuthServerRemoved", "Deleted the %s %s/%s of the removed oauth-server", kind, targetNamespace, name)
+	return nil
+}
@@ -0,0 +1,181 @@
+package deployment
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	routev1listers "github.com/openshift/client-go/route/listers/route/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+type fakeRoutes struct {
+	routev1client.RouteInterface
+	namespace string
+	deleted   []string
+}
+
+func (f *fakeRoutes) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
+	f.deleted = append(f.deleted, f.namespace+"/"+name)
+	return nil
+}
+
+type fakeRoutesGetter struct {
+	routes *fakeRoutes
+}
+
+func (f *fakeRoutesGetter) Routes(namespace string) routev1client.RouteInterface {
+	f.routes.namespace = namespace
+	return f.routes
+}
+
+func newTestRemovalController(managementState operatorv1.ManagementState, objs ...runtime.Object) (*oauthServerRemovalController, *fake.Clientset, *fakeRoutes) {
+	var kubeObjects []runtime.Object
+	newIndexer := func() cache.Indexer {
+		return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	}
+	deploymentIndexer, networkPolicyIndexer, configMapIndexer, secretIndexer, routeIndexer := newIndexer(), newIndexer(), newIndexer(), newIndexer(), newIndexer()
+	for _, obj := range objs {
+		var err error
+		switch obj.(type) {
+		case *appsv1.Deployment:
+			err = deploymentIndexer.Add(obj)
+		case *networkingv1.NetworkPolicy:
+			err = networkPolicyIndexer.Add(obj)
+		case *corev1.ConfigMap:
+			err = configMapIndexer.Add(obj)
+		case *corev1.Secret:
+			err = secretIndexer.Add(obj)
+		case *routev1.Route:
+			err = routeIndexer.Add(obj)
+		}
+		if err != nil {
+			panic(err)
+		}
+		if _, isRoute := obj.(*routev1.Route); !isRoute {
+			kubeObjects = append(kubeObjects, obj)
+		}
+	}
+
+	kubeClient := fake.NewSimpleClientset(kubeObjects...)
+	routes := &fakeRoutes{}
+	return &oauthServerRemovalController{
+		operatorClient: v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: managementState}, &operatorv1.OperatorStatus{}, nil),
+
+		deployments:     kubeClient.AppsV1(),
+		networkPolicies: kubeClient.NetworkingV1(),
+		configMaps:      kubeClient.CoreV1(),
+		secrets:         kubeClient.CoreV1(),
+		routes:          &fakeRoutesGetter{routes: routes},
+
+		deploymentLister:    appsv1listers.NewDeploymentLister(deploymentIndexer),
+		networkPolicyLister: networkingv1listers.NewNetworkPolicyLister(networkPolicyIndexer),
+		configMapLister:     corev1listers.NewConfigMapLister(configMapIndexer),
+		secretLister:        corev1listers.NewSecretLister(secretIndexer),
+		routeLister:         routev1listers.NewRouteLister(routeIndexer),
+	}, kubeClient, routes
+}
+
+func TestOAuthServerRemovalSync(t *testing.T) {
+	objectMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "openshift-authentication"}
+	}
+	oauthServerObjects := func() []runtime.Object {
+		return []runtime.Object{
+			&appsv1.Deployment{ObjectMeta: objectMeta("oauth-openshift")},
+			&networkingv1.NetworkPolicy{ObjectMeta: objectMeta("oauth-openshift")},
+			&routev1.Route{ObjectMeta: objectMeta("oauth-openshift")},
+			&corev1.Secret{ObjectMeta: objectMeta("v4-0-config-system-session")},
+			&corev1.ConfigMap{ObjectMeta: objectMeta("v4-0-config-system-cliconfig")},
+			&corev1.ConfigMap{ObjectMeta: objectMeta("v4-0-config-system-metadata")},
+			// synced from openshift-config, kept in the Removed state
+			&corev1.ConfigMap{ObjectMeta: objectMeta("v4-0-config-user-idp-0-ca")},
+			&corev1.Secret{ObjectMeta: objectMeta("v4-0-config-system-router-certs")},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		managementState operatorv1.ManagementState
+		objects         []runtime.Object
+		wantDeleted     []string
+	}{
+		{
+			name:            "managed",
+			managementState: operatorv1.Managed,
+			objects:         oauthServerObjects(),
+		},
+		{
+			name:            "unmanaged",
+			managementState: operatorv1.Unmanaged,
+			objects:         oauthServerObjects(),
+		},
+		{
+			name:            "removed",
+			managementState: operatorv1.Removed,
+			objects:         oauthServerObjects(),
+			wantDeleted: []string{
+				"configmaps/v4-0-config-system-cliconfig",
+				"configmaps/v4-0-config-system-metadata",
+				"deployments/oauth-openshift",
+				"networkpolicies/oauth-openshift",
+				"routes/oauth-openshift",
+				"secrets/v4-0-config-system-session",
+			},
+		},
+		{
+			name:            "already removed",
+			managementState: operatorv1.Removed,
+			objects: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: objectMeta("v4-0-config-user-idp-0-ca")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, kubeClient, routes := newTestRemovalController(tt.managementState, tt.objects...)
+
+			if err := c.sync(context.Background(), testSyncContext()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var deleted []string
+			for _, action := range kubeClient.Actions() {
+				if action.GetVerb() != "delete" {
+					continue
+				}
+				if action.GetNamespace() != "openshift-authentication" {
+					t.Errorf("unexpected namespace of %v", action)
+				}
+				deleted = append(deleted, action.GetResource().Resource+"/"+action.(clienttesting.DeleteAction).GetName())
+			}
+			for _, route := range routes.deleted {
+				if route != "openshift-authentication/oauth-openshift" {
+					t.Errorf("unexpected route deleted: %s", route)
+				}
+				deleted = append(deleted, "routes/oauth-openshift")
+			}
+			sort.Strings(deleted)
+
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("expected deleted %v, got %v", tt.wantDeleted, deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package deployment
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// lastRolloutTimeKey records on the deployment when its pods were last rolled
+// out so that the minimum interval between the rollouts holds across the
+// restarts of the operator
+const lastRolloutTimeKey = "operator.openshift.io/last-rollout-time"
+
+// recordedRolloutTime returns the time of the last rollout recorded on the
+// deployment, zero when none is recorded or it cannot be parsed
+func recordedRolloutTime(deployment *appsv1.Deployment) time.Time {
+	rolloutTime, err := time.Parse(time.RFC3339, deployment.Annotations[lastRolloutTimeKey])
+	if err != nil {
+		return time.Time{}
+	}
+	return rolloutTime
+}
+
+// recordRolloutTime records the time of the last rollout on the deployment,
+// the deployments that have never been rolled out by an operator that records
+// it are left as they are
+func recordRolloutTime(deployment *appsv1.Deployment, rolloutTime time.Time) {
+	if rolloutTime.IsZero() {
+		return
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[lastRolloutTimeKey] = rolloutTime.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,106 @@
+package deployment
+
+import (
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// rolloutTriggersKey records on the deployment a hash of the tracked inputs
+// per rollout reason so that the next rollout can tell what changed. It is not
+// set on the pod template, it must not roll out the pods itself.
+const rolloutTriggersKey = "operator.openshift.io/rollout-triggers"
+
+// bootstrapUserExistsKey keeps the pods rolling out once the bootstrap user
+// gets removed
+const bootstrapUserExistsKey = "operator.openshift.io/bootstrap-user-exists"
+
+// the reasons of the rollouts of the oauth-server pods, from the most specific
+// to the least specific one
+const (
+	rolloutReasonBootstrapUserRemoved   = "bootstrap-user-removed"
+	rolloutReasonProxyChange            = "proxy-change"
+	rolloutReasonIdPConfigChange        = "idp-config-change"
+	rolloutReasonDeploymentConfigChange = "deployment-config-change"
+	rolloutReasonConfigChange           = "config-change"
+	// rolloutReasonUnknown is reported for the deployments that were applied
+	// before the triggers were recorded
+	rolloutReasonUnknown = "unknown"
+)
+
+var rolloutReasons = []string{
+	rolloutReasonProxyChange,
+	rolloutReasonIdPConfigChange,
+	rolloutReasonDeploymentConfigChange,
+	rolloutReasonConfigChange,
+}
+
+// rolloutReasonOf returns the reason of the rollouts that the changes of the
+// tracked input cause
+func rolloutReasonOf(hashInput string) string {
+	switch {
+	case strings.HasPrefix(hashInput, "proxy:"):
+		return rolloutReasonProxyChange
+	case strings.HasPrefix(hashInput, "configmaps:v4-0-config-user-idp-"),
+		strings.HasPrefix(hashInput, "secrets:v4-0-config-user-idp-"),
+		strings.HasPrefix(hashInput, "identityproviders"):
+		return rolloutReasonIdPConfigChange
+	case strings.HasPrefix(hashInput, "deploymentconfig:"):
+		return rolloutReasonDeploymentConfigChange
+	default:
+		return rolloutReasonConfigChange
+	}
+}
+
+// rolloutTriggers returns the value of the rollout triggers annotation for
+// the tracked inputs, the hashes of the inputs of every reason in a stable order
+func rolloutTriggers(hashInputs []string) string {
+	inputsByReason := map[string]map[string][]byte{}
+	for _, input := range hashInputs {
+		reason := rolloutReasonOf(input)
+		if inputsByReason[reason] == nil {
+			inputsByReason[reason] = map[string][]byte{}
+		}
+		inputsByReason[reason][input] = nil
+	}
+
+	triggers := make([]string, 0, len(inputsByReason))
+	for reason, inputs := range inputsByReason {
+		triggers = append(triggers, reason+"="+dataHash(inputs))
+	}
+	sort.Strings(triggers)
+	return strings.Join(triggers, ",")
+}
+
+func parseRolloutTriggers(triggers string) map[string]string {
+	parsed := map[string]string{}
+	for _, trigger := range strings.Split(triggers, ",") {
+		if reason, hash, ok := strings.Cut(trigger, "="); ok {
+			parsed[reason] = hash
+		}
+	}
+	return parsed
+}
+
+// rolloutReason tells why the pods of the current deployment get rolled out
+// to the expected one. A single rollout can follow several changes, the most
+// specific reason is reported.
+func rolloutReason(current, expected *appsv1.Deployment) string {
+	if len(current.Spec.Template.Annotations[bootstrapUserExistsKey]) > 0 && len(expected.Spec.Template.Annotations[bootstrapUserExistsKey]) == 0 {
+		return rolloutReasonBootstrapUserRemoved
+	}
+
+	currentTriggers, ok := current.Annotations[rolloutTriggersKey]
+	if !ok {
+		return rolloutReasonUnknown
+	}
+	currentHashes := parseRolloutTriggers(currentTriggers)
+	expectedHashes := parseRolloutTriggers(expected.Annotations[rolloutTriggersKey])
+	for _, reason := range rolloutReasons {
+		if currentHashes[reason] != expectedHashes[reason] {
+			return reason
+		}
+	}
+	return rolloutReasonUnknown
+}
@@ -0,0 +1,112 @@
+package deployment
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func TestRolloutReason(t *testing.T) {
+	hashInputs := []string{
+		"configmaps:v4-0-config-system-cliconfig:cliconfig-hash",
+		"configmaps:v4-0-config-user-idp-0-ca:ca-hash",
+		"deploymentconfig:{\"logLevel\":\"Debug\"}",
+		"identityproviders:1",
+		"identityprovidersconfig:[{\"name\":\"ldap\"}]",
+		"proxy:cluster:proxy-hash",
+		"secrets:v4-0-config-system-session:session-hash",
+	}
+	deploymentWith := func(bootstrapUserExists bool, hashInputs ...string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{}
+		deployment.Annotations = map[string]string{rolloutTriggersKey: rolloutTriggers(hashInputs)}
+		deployment.Spec.Template.Annotations = map[string]string{}
+		if bootstrapUserExists {
+			deployment.Spec.Template.Annotations[bootstrapUserExistsKey] = "true"
+		}
+		return deployment
+	}
+	replaced := func(old, new string) []string {
+		inputs := make([]string, 0, len(hashInputs))
+		for _, input := range hashInputs {
+			if input == old {
+				input = new
+			}
+			inputs = append(inputs, input)
+		}
+		return inputs
+	}
+
+	tests := []struct {
+		name     string
+		current  *appsv1.Deployment
+		expected *appsv1.Deployment
+		want     string
+	}{
+		{
+			name:     "bootstrap user removed",
+			current:  deploymentWith(true, hashInputs...),
+			expected: deploymentWith(false, replaced("secrets:v4-0-config-system-session:session-hash", "secrets:v4-0-config-system-session:rotated")...),
+			want:     rolloutReasonBootstrapUserRemoved,
+		},
+		{
+			name:     "proxy changed",
+			current:  deploymentWith(false, hashInputs...),
+			expected: deploymentWith(false, replaced("proxy:cluster:proxy-hash", "proxy:cluster:new")...),
+			want:     rolloutReasonProxyChange,
+		},
+		{
+			name:     "identity provider CA changed",
+			current:  deploymentWith(false, hashInputs...),
+			expected: deploymentWith(false, replaced("configmaps:v4-0-config-user-idp-0-ca:ca-hash", "configmaps:v4-0-config-user-idp-0-ca:new")...),
+			want:     rolloutReasonIdPConfigChange,
+		},
+		{
+			name:     "identity provider edited",
+			current:  deploymentWith(false, hashInputs...),
+			expected: deploymentWith(false, replaced("identityprovidersconfig:[{\"name\":\"ldap\"}]", "identityprovidersconfig:[{\"name\":\"ldap2\"}]")...),
+			want:     rolloutReasonIdPConfigChange,
+		},
+		{
+			name:     "deployment config changed",
+			current:  deploymentWith(false, hashInputs...),
+			expected: deploymentWith(false, replaced("deploymentconfig:{\"logLevel\":\"Debug\"}", "deploymentconfig:{}")...),
+			want:     rolloutReasonDeploymentConfigChange,
+		},
+		{
+			name:     "session secret rotated",
+			current:  deploymentWith(false, hashInputs...),
+			expected: deploymentWith(false, replaced("secrets:v4-0-config-system-session:session-hash", "secrets:v4-0-config-system-session:rotated")...),
+			want:     rolloutReasonConfigChange,
+		},
+		{
+			name:     "deployment without the triggers",
+			current:  &appsv1.Deployment{},
+			expected: deploymentWith(false, hashInputs...),
+			want:     rolloutReasonUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rolloutReason(tt.current, tt.expected); got != tt.want {
+				t.Errorf("expected the reason %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRolloutTriggers(t *testing.T) {
+	triggers := rolloutTriggers([]string{"proxy:cluster:a", "secrets:v4-0-config-system-session:b", "deploymentconfig:c"})
+	if reordered := rolloutTriggers([]string{"deploymentconfig:c", "secrets:v4-0-config-system-session:b", "proxy:cluster:a"}); reordered != triggers {
+		t.Errorf("expected the triggers not to depend on the order of the inputs, got %q and %q", triggers, reordered)
+	}
+
+	parsed := parseRolloutTriggers(triggers)
+	for _, reason := range []string{rolloutReasonProxyChange, rolloutReasonDeploymentConfigChange, rolloutReasonConfigChange} {
+		if len(parsed[reason]) == 0 {
+			t.Errorf("expected a hash for the reason %q in %q", reason, triggers)
+		}
+	}
+	if _, ok := parsed[rolloutReasonIdPConfigChange]; ok {
+		t.Errorf("expected no hash for the reason %q in %q", rolloutReasonIdPConfigChange, triggers)
+	}
+}
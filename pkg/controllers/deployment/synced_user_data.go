@@ -0,0 +1,65 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+// syncedUserDataKey records on the deployment the user sync data it was
+// applied with so that the copies the config no longer references get
+// unsynced across the restarts of the operator
+const syncedUserDataKey = "operator.openshift.io/synced-user-data"
+
+// recordedSyncedUserData returns the user sync data recorded on the current
+// deployment, empty when there is no deployment or nothing is recorded
+func (c *oauthServerDeploymentSyncer) recordedSyncedUserData(ctx context.Context) (*datasync.ConfigSyncData, error) {
+	deployment, err := c.deployments.Deployments(targetNamespace).Get(ctx, "oauth-openshift", metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return datasync.NewConfigSyncData(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to get the oauth-openshift deployment: %w", err)
+	}
+
+	syncData, err := datasync.NewConfigSyncDataFromJSON([]byte(deployment.Annotations[syncedUserDataKey]))
+	if err != nil {
+		// the copies are still synced from the config, only the stale ones
+		// are left behind
+		klog.Warningf("unable to parse the synced user data recorded on the oauth-openshift deployment: %v", err)
+		return datasync.NewConfigSyncData(), nil
+	}
+	return syncData, nil
+}
+
+// recordSyncedUserData records the user sync data on the deployment, the
+// annotation gets removed from the current deployment, nil when there is
+// none yet, once there is no user sync data
+func recordSyncedUserData(deployment, currentDeployment *appsv1.Deployment, syncData *datasync.ConfigSyncData) error {
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	if syncData.Len() == 0 {
+		if currentDeployment == nil {
+			return nil
+		}
+		if _, recorded := currentDeployment.Annotations[syncedUserDataKey]; recorded {
+			// the trailing dash removes the annotation when the deployment
+			// is applied, the deployments being created must not carry it
+			deployment.Annotations[syncedUserDataKey+"-"] = ""
+		}
+		return nil
+	}
+
+	syncDataBytes, err := syncData.Bytes()
+	if err != nil {
+		return fmt.Errorf("unable to marshal the user sync data: %w", err)
+	}
+	deployment.Annotations[syncedUserDataKey] = string(syncDataBytes)
+	return nil
+}
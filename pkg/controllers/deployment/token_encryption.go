@@ -0,0 +1,51 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+// tokenEncryptionKeyConditionType ends with "Degraded" so that the missing key
+// degrades the clusteroperator
+const tokenEncryptionKeyConditionType = "TokenEncryptionKeyDegraded"
+
+// syncTokenEncryptionKey reports whether the token encryption key oauth-server
+// is configured with is available. A missing key fails the sync so that the
+// current deployment keeps running instead of one that cannot issue tokens.
+func (c *oauthServerDeploymentSyncer) syncTokenEncryptionKey(ctx context.Context, tokenEncryption *tokenEncryptionConfig) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   tokenEncryptionKeyConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+
+	var keyErr error
+	if tokenEncryption != nil {
+		secret, err := c.configNSSecretLister.Secrets("openshift-config").Get(tokenEncryption.KeySecret.Name)
+		switch {
+		case errors.IsNotFound(err):
+			keyErr = fmt.Errorf("the token encryption key secret openshift-config/%s does not exist", tokenEncryption.KeySecret.Name)
+		case err != nil:
+			return err
+		case len(secret.Data[datasync.TokenEncryptionKeyKey]) == 0:
+			keyErr = fmt.Errorf("the token encryption key secret openshift-config/%s is missing the %q key", tokenEncryption.KeySecret.Name, datasync.TokenEncryptionKeyKey)
+		}
+	}
+	if keyErr != nil {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "KeyMissing"
+		condition.Message = keyErr.Error()
+	}
+
+	if _, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition)); err != nil {
+		return err
+	}
+	return keyErr
+}
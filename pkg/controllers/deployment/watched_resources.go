@@ -0,0 +1,142 @@
+package deployment
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	configinformer "github.com/openshift/client-go/config/informers/externalversions"
+	routeinformers "github.com/openshift/client-go/route/informers/externalversions"
+	"github.com/openshift/library-go/pkg/controller/factory"
+)
+
+const (
+	targetNamespace = "openshift-authentication"
+	configNamespace = "openshift-config"
+)
+
+// watchedResource is a resource whose changes affect the oauth-server
+// deployment. An empty Name stands for all the resources of the kind in the
+// namespace.
+type watchedResource struct {
+	schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+func (r watchedResource) String() string {
+	return fmt.Sprintf("%s %s/%s", r.GroupVersionKind, r.Namespace, r.Name)
+}
+
+var (
+	configMapGVK            = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	secretGVK               = corev1.SchemeGroupVersion.WithKind("Secret")
+	podGVK                  = corev1.SchemeGroupVersion.WithKind("Pod")
+	namespaceGVK            = corev1.SchemeGroupVersion.WithKind("Namespace")
+	nodeGVK                 = corev1.SchemeGroupVersion.WithKind("Node")
+	deploymentGVK           = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	routeGVK                = routev1.GroupVersion.WithKind("Route")
+	proxyGVK                = configv1.GroupVersion.WithKind("Proxy")
+	oauthGVK                = configv1.GroupVersion.WithKind("OAuth")
+	ingressGVK              = configv1.GroupVersion.WithKind("Ingress")
+	imageDigestMirrorSetGVK = configv1.GroupVersion.WithKind("ImageDigestMirrorSet")
+)
+
+var staticWatchedResourceSet = []watchedResource{
+	{GroupVersionKind: proxyGVK, Name: "cluster"},
+	// the identity providers are compared with the observed ones
+	{GroupVersionKind: oauthGVK, Name: "cluster"},
+	{GroupVersionKind: ingressGVK, Name: "cluster"},
+	{GroupVersionKind: imageDigestMirrorSetGVK},
+	{GroupVersionKind: nodeGVK},
+	{GroupVersionKind: namespaceGVK, Name: targetNamespace},
+	{GroupVersionKind: deploymentGVK, Namespace: targetNamespace, Name: "oauth-openshift"},
+	{GroupVersionKind: routeGVK, Namespace: targetNamespace, Name: "oauth-openshift"},
+	{GroupVersionKind: podGVK, Namespace: targetNamespace},
+	// the rvs-hash covers all the v4-0-config- resources of the namespace
+	{GroupVersionKind: configMapGVK, Namespace: targetNamespace},
+	{GroupVersionKind: secretGVK, Namespace: targetNamespace},
+	// the admin-provided resources are checked before they get synchronized
+	{GroupVersionKind: configMapGVK, Namespace: configNamespace},
+	{GroupVersionKind: secretGVK, Namespace: configNamespace},
+}
+
+// staticWatchedResources returns the resources the deployment depends on
+// regardless of its configuration. The informers of the configmaps and the
+// secrets are not limited to any names, they also cover the resources of the
+// sync data and their copies whatever identity providers are configured.
+func staticWatchedResources() []watchedResource {
+	return append([]watchedResource{}, staticWatchedResourceSet...)
+}
+
+// watchInformers maps the watched resources to the informers of the
+// controller, the informers of the target namespace are returned separately
+type watchInformers struct {
+	nodeInformer                     coreinformers.NodeInformer
+	configInformers                  configinformer.SharedInformerFactory
+	routeInformersForTargetNamespace routeinformers.SharedInformerFactory
+	kubeInformersForTargetNamespace  informers.SharedInformerFactory
+	kubeInformersForConfigNamespace  informers.SharedInformerFactory
+}
+
+func (w *watchInformers) informersFor(resources []watchedResource) ([]factory.Informer, []factory.Informer, error) {
+	var clusterInformers, targetNSInformers []factory.Informer
+	seen := map[factory.Informer]bool{}
+
+	for _, resource := range resources {
+		informer, isTargetNS, err := w.informerFor(resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[informer] {
+			continue
+		}
+		seen[informer] = true
+
+		if isTargetNS {
+			targetNSInformers = append(targetNSInformers, informer)
+		} else {
+			clusterInformers = append(clusterInformers, informer)
+		}
+	}
+
+	return clusterInformers, targetNSInformers, nil
+}
+
+func (w *watchInformers) informerFor(resource watchedResource) (factory.Informer, bool, error) {
+	switch {
+	case resource.GroupVersionKind == proxyGVK:
+		return w.configInformers.Config().V1().Proxies().Informer(), false, nil
+	case resource.GroupVersionKind == oauthGVK:
+		return w.configInformers.Config().V1().OAuths().Informer(), false, nil
+	case resource.GroupVersionKind == ingressGVK:
+		return w.configInformers.Config().V1().Ingresses().Informer(), false, nil
+	case resource.GroupVersionKind == imageDigestMirrorSetGVK:
+		return w.configInformers.Config().V1().ImageDigestMirrorSets().Informer(), false, nil
+	case resource.GroupVersionKind == nodeGVK:
+		return w.nodeInformer.Informer(), false, nil
+	case resource.GroupVersionKind == namespaceGVK && resource.Name == targetNamespace:
+		return w.kubeInformersForTargetNamespace.Core().V1().Namespaces().Informer(), true, nil
+	case resource.Namespace == configNamespace && resource.GroupVersionKind == configMapGVK:
+		return w.kubeInformersForConfigNamespace.Core().V1().ConfigMaps().Informer(), false, nil
+	case resource.Namespace == configNamespace && resource.GroupVersionKind == secretGVK:
+		return w.kubeInformersForConfigNamespace.Core().V1().Secrets().Informer(), false, nil
+	case resource.Namespace == targetNamespace && resource.GroupVersionKind == configMapGVK:
+		return w.kubeInformersForTargetNamespace.Core().V1().ConfigMaps().Informer(), true, nil
+	case resource.Namespace == targetNamespace && resource.GroupVersionKind == secretGVK:
+		return w.kubeInformersForTargetNamespace.Core().V1().Secrets().Informer(), true, nil
+	case resource.Namespace == targetNamespace && resource.GroupVersionKind == podGVK:
+		return w.kubeInformersForTargetNamespace.Core().V1().Pods().Informer(), true, nil
+	case resource.Namespace == targetNamespace && resource.GroupVersionKind == deploymentGVK:
+		return w.kubeInformersForTargetNamespace.Apps().V1().Deployments().Informer(), true, nil
+	case resource.Namespace == targetNamespace && resource.GroupVersionKind == routeGVK:
+		return w.routeInformersForTargetNamespace.Route().V1().Routes().Informer(), true, nil
+	}
+	return nil, false, fmt.Errorf("no informer available for %s", resource)
+}
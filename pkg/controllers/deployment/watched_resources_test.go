@@ -0,0 +1,79 @@
+package deployment
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configinformer "github.com/openshift/client-go/config/informers/externalversions"
+	routeinformers "github.com/openshift/client-go/route/informers/externalversions"
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/operator/datasync"
+)
+
+func TestStaticWatchedResourcesCoverMultipleIdentityProviders(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformers := informers.NewSharedInformerFactory(kubeClient, 0)
+	watch := &watchInformers{
+		nodeInformer:                     kubeInformers.Core().V1().Nodes(),
+		configInformers:                  configinformer.NewSharedInformerFactory(nil, 0),
+		routeInformersForTargetNamespace: routeinformers.NewSharedInformerFactory(nil, 0),
+		kubeInformersForTargetNamespace:  informers.NewSharedInformerFactoryWithOptions(kubeClient, 0, informers.WithNamespace(targetNamespace)),
+		kubeInformersForConfigNamespace:  informers.NewSharedInformerFactoryWithOptions(kubeClient, 0, informers.WithNamespace(configNamespace)),
+	}
+	clusterInformers, targetNSInformers, err := watch.informersFor(staticWatchedResources())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// proxies, oauths, ingresses, IDMS, nodes and the configmaps and secrets of openshift-config
+	if len(clusterInformers) != 7 {
+		t.Errorf("expected 7 cluster informers, got %d", len(clusterInformers))
+	}
+	// namespaces, deployments, routes, pods, configmaps and secrets
+	if len(targetNSInformers) != 6 {
+		t.Errorf("expected 6 target namespace informers, got %d", len(targetNSInformers))
+	}
+	watched := map[factory.Informer]bool{}
+	for _, informer := range append(clusterInformers, targetNSInformers...) {
+		watched[informer] = true
+	}
+
+	idpSyncData := datasync.NewConfigSyncData()
+	// github
+	idpSyncData.AddIDPSecret(0, configv1.SecretNameReference{Name: "github-client-secret"}, "client-secret", configv1.ClientSecretKey)
+	idpSyncData.AddIDPConfigMap(0, configv1.ConfigMapNameReference{Name: "github-ca"}, "ca", corev1.ServiceAccountRootCAKey)
+	// ldap
+	idpSyncData.AddIDPSecret(1, configv1.SecretNameReference{Name: "ldap-bind-password"}, "bind-password", configv1.BindPasswordKey)
+	idpSyncData.AddIDPConfigMap(1, configv1.ConfigMapNameReference{Name: "ldap-ca"}, "ca", corev1.ServiceAccountRootCAKey)
+
+	userSyncData, _ := (&deploymentConfig{
+		LocaleBundle: &configv1.ConfigMapNameReference{Name: "login-locales"},
+	}).userSyncData()
+
+	// both the sources and their copies have to be watched
+	for _, syncData := range []*datasync.ConfigSyncData{idpSyncData, userSyncData} {
+		for _, resource := range syncData.Resources() {
+			gvk := secretGVK
+			if resource.Type == datasync.ConfigMapType {
+				gvk = configMapGVK
+			}
+			for _, syncedResource := range []watchedResource{
+				{GroupVersionKind: gvk, Namespace: configNamespace, Name: resource.Source},
+				{GroupVersionKind: gvk, Namespace: targetNamespace, Name: resource.Dest},
+			} {
+				informer, _, err := watch.informerFor(syncedResource)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					continue
+				}
+				if !watched[informer] {
+					t.Errorf("expected %s to be watched", syncedResource)
+				}
+			}
+		}
+	}
+}
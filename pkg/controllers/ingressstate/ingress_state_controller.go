@@ -72,6 +72,15 @@ func (c *ingressStateController) checkPodStatus(ctx context.Context, reference *
 }
 
 func (c *ingressStateController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if spec.ManagementState == operatorv1.Removed {
+		// there is no oauth-server to check in the Removed state
+		return nil
+	}
+
 	endpoints, err := c.endpointsGetter.Endpoints(c.targetNamespace).Get(context.TODO(), "oauth-openshift", metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		// Clear the error to allow checkSubset to report degraded because endpoints == nil
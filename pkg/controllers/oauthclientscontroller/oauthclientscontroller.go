@@ -25,6 +25,7 @@ import (
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/oauth/oauthdiscovery"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"github.com/openshift/library-go/pkg/route/routeapihelpers"
 
@@ -33,6 +34,7 @@ import (
 )
 
 type oauthsClientsController struct {
+	operatorClient    v1helpers.OperatorClient
 	oauthClientClient oauthclient.OAuthClientInterface
 
 	oauthClientLister oauthv1listers.OAuthClientLister
@@ -49,6 +51,7 @@ func NewOAuthClientsController(
 	eventRecorder events.Recorder,
 ) factory.Controller {
 	c := &oauthsClientsController{
+		operatorClient:    operatorClient,
 		oauthClientClient: oauthsClientClient,
 
 		oauthClientLister: oauthInformers.Oauth().V1().OAuthClients().Lister(),
@@ -73,6 +76,14 @@ func NewOAuthClientsController(
 }
 
 func (c *oauthsClientsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(spec.ManagementState) {
+		return nil
+	}
+
 	ingress, err := c.getIngressConfig()
 	if err != nil {
 		return err
@@ -17,6 +17,7 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	oauthv1 "github.com/openshift/api/oauth/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	fakeoauthclient "github.com/openshift/client-go/oauth/clientset/versioned/fake"
@@ -24,6 +25,7 @@ import (
 	routev1listers "github.com/openshift/client-go/route/listers/route/v1"
 	"github.com/openshift/library-go/pkg/oauth/oauthdiscovery"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
 
 const (
@@ -106,6 +108,7 @@ func newRouteLister(t *testing.T, routes ...*routev1.Route) routev1listers.Route
 
 func newTestOAuthsClientsController(t *testing.T) *oauthsClientsController {
 	return &oauthsClientsController{
+		operatorClient:    v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
 		oauthClientClient: fakeoauthclient.NewSimpleClientset().OauthV1().OAuthClients(),
 		oauthClientLister: oauthv1listers.NewOAuthClientLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
 		routeLister:       newRouteLister(t, defaultRoute),
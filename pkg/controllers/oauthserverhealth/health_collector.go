@@ -0,0 +1,127 @@
+package oauthserverhealth
+
+import (
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+)
+
+var (
+	replicasDesc = metrics.NewDesc(
+		"openshift_authentication_operator_oauth_server_replicas",
+		"Reports the number of oauth-openshift replicas by their state.",
+		[]string{"state"},
+		nil,
+		metrics.ALPHA,
+		"",
+	)
+	identityProvidersDesc = metrics.NewDesc(
+		"openshift_authentication_operator_identity_providers",
+		"Reports the number of identity providers in the observed oauth-server config.",
+		nil,
+		nil,
+		metrics.ALPHA,
+		"",
+	)
+	degradedConditionsDesc = metrics.NewDesc(
+		"openshift_authentication_operator_degraded_conditions",
+		"Reports the Degraded conditions of the operator that are currently true along with their reasons. Always has the value of 1.",
+		[]string{"condition", "reason"},
+		nil,
+		metrics.ALPHA,
+		"",
+	)
+)
+
+// healthCollector summarizes the health of oauth-server for external
+// dashboards from what the operator already keeps in its caches, the hash of
+// the last rollout is reported by the deployment controller
+type healthCollector struct {
+	metrics.BaseStableCollector
+
+	operatorClient   v1helpers.OperatorClient
+	deploymentLister appsv1listers.DeploymentLister
+}
+
+// NewHealthCollector returns a collector that reports the readiness of the
+// oauth-openshift replicas, the number of the configured identity providers
+// and the Degraded conditions of the operator on each scrape
+func NewHealthCollector(operatorClient v1helpers.OperatorClient, deploymentLister appsv1listers.DeploymentLister) metrics.StableCollector {
+	return &healthCollector{
+		operatorClient:   operatorClient,
+		deploymentLister: deploymentLister,
+	}
+}
+
+func (c *healthCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+	ch <- replicasDesc
+	ch <- identityProvidersDesc
+	ch <- degradedConditionsDesc
+}
+
+func (c *healthCollector) CollectWithStability(ch chan<- metrics.Metric) {
+	c.collectReplicas(ch)
+
+	spec, status, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		klog.V(4).Infof("unable to get the operator state: %v", err)
+		return
+	}
+
+	if idpCount, err := countIdentityProviders(spec.ObservedConfig.Raw); err != nil {
+		klog.V(4).Infof("unable to count the identity providers: %v", err)
+	} else {
+		ch <- metrics.NewLazyConstMetric(identityProvidersDesc, metrics.GaugeValue, float64(idpCount))
+	}
+
+	for _, condition := range status.Conditions {
+		if strings.HasSuffix(condition.Type, operatorv1.OperatorStatusTypeDegraded) && condition.Status == operatorv1.ConditionTrue {
+			ch <- metrics.NewLazyConstMetric(degradedConditionsDesc, metrics.GaugeValue, 1, condition.Type, condition.Reason)
+		}
+	}
+}
+
+func (c *healthCollector) collectReplicas(ch chan<- metrics.Metric) {
+	deployment, err := c.deploymentLister.Deployments("openshift-authentication").Get("oauth-openshift")
+	if errors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		klog.V(4).Infof("unable to get the oauth-openshift deployment: %v", err)
+		return
+	}
+
+	var desired int32
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	ch <- metrics.NewLazyConstMetric(replicasDesc, metrics.GaugeValue, float64(desired), "desired")
+	ch <- metrics.NewLazyConstMetric(replicasDesc, metrics.GaugeValue, float64(deployment.Status.UpdatedReplicas), "updated")
+	ch <- metrics.NewLazyConstMetric(replicasDesc, metrics.GaugeValue, float64(deployment.Status.ReadyReplicas), "ready")
+}
+
+func countIdentityProviders(observedConfigRaw []byte) (int, error) {
+	if len(observedConfigRaw) == 0 {
+		return 0, nil
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := json.Unmarshal(observedConfigRaw, &observedConfig); err != nil {
+		return 0, err
+	}
+
+	identityProviders, _, err := unstructured.NestedSlice(observedConfig, configobservation.OAuthServerConfigPrefix, "oauthConfig", "identityProviders")
+	if err != nil {
+		return 0, err
+	}
+	return len(identityProviders), nil
+}
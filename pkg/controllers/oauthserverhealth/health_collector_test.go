@@ -0,0 +1,98 @@
+package oauthserverhealth
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/pointer"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestHealthCollector(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth-openshift", Namespace: "openshift-authentication"},
+		Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(3)},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 3, ReadyReplicas: 2},
+	}
+
+	tests := []struct {
+		name        string
+		deployment  *appsv1.Deployment
+		spec        *operatorv1.OperatorSpec
+		status      *operatorv1.OperatorStatus
+		wantMetrics string
+	}{
+		{
+			name:       "healthy",
+			deployment: deployment,
+			spec: &operatorv1.OperatorSpec{
+				ObservedConfig: runtime.RawExtension{Raw: []byte(`{"oauthServer":{"oauthConfig":{"identityProviders":[{"name":"htpasswd"},{"name":"github"}]}}}`)},
+			},
+			status: &operatorv1.OperatorStatus{
+				Conditions: []operatorv1.OperatorCondition{
+					{Type: "OAuthServerDeploymentDegraded", Status: operatorv1.ConditionFalse, Reason: "AsExpected"},
+				},
+			},
+			wantMetrics: `
+# HELP openshift_authentication_operator_identity_providers [ALPHA] Reports the number of identity providers in the observed oauth-server config.
+# TYPE openshift_authentication_operator_identity_providers gauge
+openshift_authentication_operator_identity_providers 2
+# HELP openshift_authentication_operator_oauth_server_replicas [ALPHA] Reports the number of oauth-openshift replicas by their state.
+# TYPE openshift_authentication_operator_oauth_server_replicas gauge
+openshift_authentication_operator_oauth_server_replicas{state="desired"} 3
+openshift_authentication_operator_oauth_server_replicas{state="ready"} 2
+openshift_authentication_operator_oauth_server_replicas{state="updated"} 3
+`,
+		},
+		{
+			name: "degraded without a deployment",
+			spec: &operatorv1.OperatorSpec{},
+			status: &operatorv1.OperatorStatus{
+				Conditions: []operatorv1.OperatorCondition{
+					{Type: "OAuthServerDeploymentDegraded", Status: operatorv1.ConditionTrue, Reason: "NoDeployment"},
+					{Type: "OAuthServerRouteEndpointAccessibleControllerDegraded", Status: operatorv1.ConditionTrue, Reason: "SyncError"},
+					{Type: "OAuthServerDeploymentAvailable", Status: operatorv1.ConditionFalse, Reason: "NoDeployment"},
+				},
+			},
+			wantMetrics: `
+# HELP openshift_authentication_operator_degraded_conditions [ALPHA] Reports the Degraded conditions of the operator that are currently true along with their reasons. Always has the value of 1.
+# TYPE openshift_authentication_operator_degraded_conditions gauge
+openshift_authentication_operator_degraded_conditions{condition="OAuthServerDeploymentDegraded",reason="NoDeployment"} 1
+openshift_authentication_operator_degraded_conditions{condition="OAuthServerRouteEndpointAccessibleControllerDegraded",reason="SyncError"} 1
+# HELP openshift_authentication_operator_identity_providers [ALPHA] Reports the number of identity providers in the observed oauth-server config.
+# TYPE openshift_authentication_operator_identity_providers gauge
+openshift_authentication_operator_identity_providers 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tt.deployment != nil {
+				if err := indexer.Add(tt.deployment); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			collector := NewHealthCollector(
+				v1helpers.NewFakeOperatorClient(tt.spec, tt.status, nil),
+				appsv1listers.NewDeploymentLister(indexer),
+			)
+
+			registry := metrics.NewKubeRegistry()
+			registry.CustomMustRegister(collector)
+			if err := testutil.GatherAndCompare(registry, strings.NewReader(tt.wantMetrics)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/net/http/httpproxy"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+const (
+	// idpReachabilityConditionType is informational only, it does not end
+	// with any of the suffixes the clusteroperator status gets aggregated from
+	idpReachabilityConditionType = "IdentityProvidersUnreachable"
+
+	defaultIDPProbeTimeout = 5 * time.Second
+)
+
+// idpReachabilityConfig is read from
+// spec.unsupportedConfigOverrides.oauthServer.identityProviderReachability
+type idpReachabilityConfig struct {
+	// Enabled turns the reachability checks of the identity providers on
+	Enabled bool `json:"enabled,omitempty"`
+	// Timeout is a duration string that overrides how long a single
+	// endpoint gets to respond
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// idpProber checks that an identity provider endpoint can be connected to,
+// proxy returns the proxy to connect through, if any
+type idpProber interface {
+	probe(ctx context.Context, endpoint *url.URL, proxy func(*url.URL) (*url.URL, error)) error
+}
+
+type idpReachabilityController struct {
+	operatorClient v1helpers.OperatorClient
+	proxyLister    configv1listers.ProxyLister
+	prober         idpProber
+}
+
+// NewIdentityProviderReachabilityController returns a controller that, when
+// enabled, connects to the endpoints of the configured identity providers the
+// way oauth-server does and lists the unreachable ones in the
+// IdentityProvidersUnreachable condition of the operator. The condition does
+// not affect the deployment so that it only helps finding a broken identity
+// provider before the users do.
+func NewIdentityProviderReachabilityController(
+	operatorClient v1helpers.OperatorClient,
+	proxyInformer configv1informers.ProxyInformer,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &idpReachabilityController{
+		operatorClient: operatorClient,
+		proxyLister:    proxyInformer.Lister(),
+		prober:         &networkProber{},
+	}
+
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			proxyInformer.Informer(),
+		).
+		WithSync(c.sync).
+		ResyncEvery(wait.Jitter(5*time.Minute, 1.0)).
+		ToController("IdentityProviderReachabilityController", recorder.WithComponentSuffix("identity-provider-reachability-controller"))
+}
+
+func (c *idpReachabilityController) sync(ctx context.Context, _ factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+
+	config, err := getIDPReachabilityConfig(spec.UnsupportedConfigOverrides.Raw)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   idpReachabilityConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if config.Enabled {
+		timeout := defaultIDPProbeTimeout
+		if len(config.Timeout) > 0 {
+			timeout, _ = time.ParseDuration(config.Timeout)
+		}
+
+		unreachable, err := c.probeIdentityProviders(ctx, spec.ObservedConfig.Raw, timeout)
+		if err != nil {
+			return err
+		}
+		if len(unreachable) > 0 {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = "EndpointsUnreachable"
+			condition.Message = strings.Join(unreachable, "\n")
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+func getIDPReachabilityConfig(unsupportedConfigOverrides []byte) (*idpReachabilityConfig, error) {
+	unsupportedConfig, err := common.UnstructuredConfigFrom(unsupportedConfigOverrides, configobservation.OAuthServerConfigPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the unsupportedConfigOverrides prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	config := struct {
+		IdentityProviderReachability idpReachabilityConfig `json:"identityProviderReachability"`
+	}{}
+	if len(unsupportedConfig) > 0 {
+		if err := json.Unmarshal(unsupportedConfig, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the identity provider reachability config: %w", err)
+		}
+	}
+
+	if timeout := config.IdentityProviderReachability.Timeout; len(timeout) > 0 {
+		if duration, err := time.ParseDuration(timeout); err != nil || duration <= 0 {
+			return nil, fmt.Errorf("identityProviderReachability.timeout must be a positive duration, got %q", timeout)
+		}
+	}
+
+	return &config.IdentityProviderReachability, nil
+}
+
+// probeIdentityProviders returns a message for every identity provider from
+// the observed config whose endpoint cannot be reached
+func (c *idpReachabilityController) probeIdentityProviders(ctx context.Context, observedConfigRaw []byte, timeout time.Duration) ([]string, error) {
+	observedConfig, err := common.UnstructuredConfigFrom(observedConfigRaw, configobservation.OAuthServerConfigPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the operatorconfig prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	var configDeserialized map[string]interface{}
+	if err := yaml.Unmarshal(observedConfig, &configDeserialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the observedConfig: %v", err)
+	}
+
+	endpoints, err := observeoauth.GetIdentityProviderEndpoints(configDeserialized)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the identity provider endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	proxy, err := c.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	var unreachable []string
+	for _, endpoint := range endpoints {
+		endpointURL, err := url.Parse(endpoint.URL)
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("identity provider %q: %q is not a valid URL: %v", endpoint.Name, endpoint.URL, err))
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = c.prober.probe(probeCtx, endpointURL, proxy)
+		cancel()
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("identity provider %q: %s is unreachable: %v", endpoint.Name, endpoint.URL, err))
+		}
+	}
+	return unreachable, nil
+}
+
+// proxyFunc returns the proxy selection of the cluster proxy config that
+// oauth-server gets in its environment
+func (c *idpReachabilityController) proxyFunc() (func(*url.URL) (*url.URL, error), error) {
+	proxyConfig, err := c.proxyLister.Get("cluster")
+	if kerrors.IsNotFound(err) {
+		proxyConfig = &configv1.Proxy{}
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to get cluster proxy configuration: %w", err)
+	}
+
+	return (&httpproxy.Config{
+		HTTPProxy:  proxyConfig.Status.HTTPProxy,
+		HTTPSProxy: proxyConfig.Status.HTTPSProxy,
+		NoProxy:    proxyConfig.Status.NoProxy,
+	}).ProxyFunc(), nil
+}
+
+// networkProber connects to the HTTP endpoints through the proxy and to the
+// LDAP servers directly, as oauth-server does
+type networkProber struct{}
+
+func (p *networkProber) probe(ctx context.Context, endpoint *url.URL, proxy func(*url.URL) (*url.URL, error)) error {
+	switch endpoint.Scheme {
+	case "ldap", "ldaps":
+		address := endpoint.Host
+		if len(endpoint.Port()) == 0 {
+			port := "389"
+			if endpoint.Scheme == "ldaps" {
+				port = "636"
+			}
+			address = net.JoinHostPort(endpoint.Hostname(), port)
+		}
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	case "http", "https":
+		client := &http.Client{
+			Transport: &http.Transport{
+				Proxy: func(req *http.Request) (*url.URL, error) { return proxy(req.URL) },
+			},
+			// any response means the endpoint is reachable
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// the operator does not trust the CAs of the identity providers,
+			// oauth-server verifies the certificates with them
+			var certErr *tls.CertificateVerificationError
+			if errors.As(err, &certErr) {
+				return nil
+			}
+			return err
+		}
+		return resp.Body.Close()
+
+	default:
+		return fmt.Errorf("unsupported scheme %q", endpoint.Scheme)
+	}
+}
@@ -0,0 +1,145 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// fakeIDPProber fails the probes of the unreachable URLs and records which
+// proxy every probe would go through
+type fakeIDPProber struct {
+	unreachable map[string]bool
+	proxies     map[string]string
+}
+
+func (p *fakeIDPProber) probe(_ context.Context, endpoint *url.URL, proxy func(*url.URL) (*url.URL, error)) error {
+	proxyURL, err := proxy(endpoint)
+	if err != nil {
+		return err
+	}
+	p.proxies[endpoint.String()] = ""
+	if proxyURL != nil {
+		p.proxies[endpoint.String()] = proxyURL.String()
+	}
+
+	if p.unreachable[endpoint.String()] {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func TestIdentityProviderReachability(t *testing.T) {
+	observedConfig := `{"oauthServer":{"oauthConfig":{"identityProviders":[` +
+		`{"name":"htpasswd","provider":{"kind":"HTPasswdPasswordIdentityProvider"}},` +
+		`{"name":"ldap","provider":{"kind":"LDAPPasswordIdentityProvider","url":"ldaps://ldap.example.com/ou=users,dc=example,dc=com?uid"}},` +
+		`{"name":"keycloak","provider":{"kind":"OpenIDIdentityProvider","urls":{"authorize":"https://keycloak.example.com/auth","token":"https://keycloak.example.com/token"}}},` +
+		`{"name":"github","provider":{"kind":"GitHubIdentityProvider"}},` +
+		`{"name":"gitlab","provider":{"kind":"GitLabIdentityProvider","url":"https://gitlab.internal.example.com"}}]}}}`
+
+	tests := []struct {
+		name        string
+		overrides   string
+		unreachable []string
+		wantStatus  operatorv1.ConditionStatus
+		wantMessage string
+		wantProxies map[string]string
+	}{
+		{
+			name:        "disabled by default",
+			unreachable: []string{"https://keycloak.example.com/token"},
+			wantStatus:  operatorv1.ConditionFalse,
+			wantProxies: map[string]string{},
+		},
+		{
+			name:       "reachable identity providers",
+			overrides:  `{"oauthServer":{"identityProviderReachability":{"enabled":true}}}`,
+			wantStatus: operatorv1.ConditionFalse,
+			wantProxies: map[string]string{
+				"ldaps://ldap.example.com/ou=users,dc=example,dc=com?uid": "",
+				"https://keycloak.example.com/token":                      "http://proxy.example.com:3128",
+				"https://github.com":                                      "http://proxy.example.com:3128",
+				"https://gitlab.internal.example.com":                     "",
+			},
+		},
+		{
+			name:        "unreachable identity providers",
+			overrides:   `{"oauthServer":{"identityProviderReachability":{"enabled":true,"timeout":"2s"}}}`,
+			unreachable: []string{"https://keycloak.example.com/token", "https://gitlab.internal.example.com"},
+			wantStatus:  operatorv1.ConditionTrue,
+			wantMessage: `identity provider "keycloak": https://keycloak.example.com/token is unreachable: connection refused` + "\n" +
+				`identity provider "gitlab": https://gitlab.internal.example.com is unreachable: connection refused`,
+			wantProxies: map[string]string{
+				"ldaps://ldap.example.com/ou=users,dc=example,dc=com?uid": "",
+				"https://keycloak.example.com/token":                      "http://proxy.example.com:3128",
+				"https://github.com":                                      "http://proxy.example.com:3128",
+				"https://gitlab.internal.example.com":                     "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Proxy{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.ProxyStatus{
+					HTTPSProxy: "http://proxy.example.com:3128",
+					NoProxy:    ".internal.example.com",
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			spec := &operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(observedConfig)}}
+			if len(tt.overrides) > 0 {
+				spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tt.overrides)}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(spec, &operatorv1.OperatorStatus{}, nil)
+
+			prober := &fakeIDPProber{unreachable: map[string]bool{}, proxies: map[string]string{}}
+			for _, endpoint := range tt.unreachable {
+				prober.unreachable[endpoint] = true
+			}
+
+			c := &idpReachabilityController{
+				operatorClient: operatorClient,
+				proxyLister:    configv1listers.NewProxyLister(indexer),
+				prober:         prober,
+			}
+			if err := c.sync(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, status, _, _ := operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, idpReachabilityConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", idpReachabilityConditionType)
+			}
+			if condition.Status != tt.wantStatus || condition.Message != tt.wantMessage {
+				t.Errorf("expected the condition %s with %q, got %s with %q", tt.wantStatus, tt.wantMessage, condition.Status, condition.Message)
+			}
+			if !equality.Semantic.DeepEqual(tt.wantProxies, prober.proxies) {
+				t.Errorf("expected the probes through the proxies %v, got %v", tt.wantProxies, prober.proxies)
+			}
+		})
+	}
+}
+
+func TestGetIDPReachabilityConfig(t *testing.T) {
+	if _, err := getIDPReachabilityConfig([]byte(`{"oauthServer":{"identityProviderReachability":{"enabled":true,"timeout":"-1s"}}}`)); err == nil {
+		t.Errorf("expected a negative timeout to be rejected")
+	}
+}
@@ -0,0 +1,228 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	observeoauth "github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation/oauth"
+)
+
+const (
+	idpValidationConditionType = "IdentityProviderValidationDegraded"
+
+	ldapBindTimeout = 10 * time.Second
+	// ldapBindBackoff is how long a failed bind is reported before binding
+	// again, it doubles with every failure up to ldapMaxBindBackoff
+	ldapBindBackoff    = 5 * time.Minute
+	ldapMaxBindBackoff = time.Hour
+)
+
+// ldapBindResult is the outcome of the last bind with a bind config, the
+// successful binds are not repeated until the config changes
+type ldapBindResult struct {
+	err      error
+	failures int
+	nextBind time.Time
+}
+
+type idpValidationController struct {
+	operatorClient  v1helpers.OperatorClient
+	oauthLister     configv1listers.OAuthLister
+	configMapLister corev1listers.ConfigMapLister
+	secretLister    corev1listers.SecretLister
+	ldapBinder      ldapBinder
+	clock           clock.PassiveClock
+
+	// ldapBindResults are keyed by the hash of the bind configs
+	ldapBindResults map[string]*ldapBindResult
+}
+
+// NewIdentityProviderValidationController returns a controller that checks
+// every identity provider of the cluster OAuth config on its own and lists
+// the invalid ones along with why in the IdentityProviderValidationDegraded
+// condition of the operator. The config observer keeps the last valid
+// identity providers in the oauth-server config when some become invalid,
+// the condition says which provider is held back and what to fix. On top of
+// the checks of the config observer, the LDAP providers get bound to with
+// their bind credentials. A bind is repeated only once the URL, the
+// credentials or the CA of the provider change, the failed ones also after a
+// backoff.
+func NewIdentityProviderValidationController(
+	operatorClient v1helpers.OperatorClient,
+	oauthInformer configv1informers.OAuthInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	secretInformer corev1informers.SecretInformer,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &idpValidationController{
+		operatorClient:  operatorClient,
+		oauthLister:     oauthInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		secretLister:    secretInformer.Lister(),
+		ldapBinder:      &networkLDAPBinder{},
+		clock:           clock.RealClock{},
+		ldapBindResults: map[string]*ldapBindResult{},
+	}
+
+	return factory.New().
+		WithInformers(
+			oauthInformer.Informer(),
+			configMapInformer.Informer(),
+			secretInformer.Informer(),
+		).
+		WithSync(c.sync).
+		ResyncEvery(wait.Jitter(5*time.Minute, 1.0)).
+		ToController("IdentityProviderValidationController", recorder.WithComponentSuffix("identity-provider-validation-controller"))
+}
+
+func (c *idpValidationController) sync(ctx context.Context, _ factory.SyncContext) error {
+	oauthConfig, err := c.oauthLister.Get("cluster")
+	if kerrors.IsNotFound(err) {
+		oauthConfig = &configv1.OAuth{}
+	} else if err != nil {
+		return err
+	}
+
+	var invalid []string
+	boundConfigs := sets.NewString()
+	for i := range oauthConfig.Spec.IdentityProviders {
+		idp := &oauthConfig.Spec.IdentityProviders[i]
+		if err := c.validateIdentityProvider(ctx, idp, boundConfigs); err != nil {
+			invalid = append(invalid, fmt.Sprintf("identity provider %q: %v", idp.Name, err))
+		}
+	}
+	// forget the results of the bind configs that are no longer in use
+	for key := range c.ldapBindResults {
+		if !boundConfigs.Has(key) {
+			delete(c.ldapBindResults, key)
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   idpValidationConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(invalid) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "InvalidIdentityProviders"
+		condition.Message = strings.Join(invalid, "\n")
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+func (c *idpValidationController) validateIdentityProvider(ctx context.Context, idp *configv1.IdentityProvider, boundConfigs sets.String) error {
+	if err := observeoauth.ValidateIdentityProvider(c.configMapLister, c.secretLister, idp); err != nil {
+		return err
+	}
+	if idp.Type != configv1.IdentityProviderTypeLDAP {
+		return nil
+	}
+
+	bindConfig, key, err := c.ldapBindConfig(idp.LDAP)
+	if err != nil {
+		return err
+	}
+	boundConfigs.Insert(key)
+	return c.bind(ctx, key, bindConfig)
+}
+
+// bind binds with the config unless it already succeeded or the last failure
+// is still backing off, the last result is returned then
+func (c *idpValidationController) bind(ctx context.Context, key string, bindConfig *ldapBindConfig) error {
+	result, ok := c.ldapBindResults[key]
+	if ok && (result.err == nil || c.clock.Now().Before(result.nextBind)) {
+		return result.err
+	}
+	if !ok {
+		result = &ldapBindResult{}
+		c.ldapBindResults[key] = result
+	}
+
+	bindCtx, cancel := context.WithTimeout(ctx, ldapBindTimeout)
+	defer cancel()
+	result.err = c.ldapBinder.bind(bindCtx, bindConfig)
+	if result.err == nil {
+		result.failures = 0
+		return nil
+	}
+	backoff := ldapBindBackoff
+	for i := 0; i < result.failures && backoff < ldapMaxBindBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > ldapMaxBindBackoff {
+		backoff = ldapMaxBindBackoff
+	}
+	result.failures++
+	result.nextBind = c.clock.Now().Add(backoff)
+	return result.err
+}
+
+// ldapBindConfig reads the connection and the credentials of an LDAP identity
+// provider that already passed the validation of its secrets and configmaps.
+// It returns the hash of everything the bind depends on along with them.
+func (c *idpValidationController) ldapBindConfig(ldapConfig *configv1.LDAPIdentityProvider) (*ldapBindConfig, string, error) {
+	ldapURL, err := url.Parse(ldapConfig.URL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%q is not a valid URL: %v", ldapConfig.URL, err)
+	}
+
+	config := &ldapBindConfig{
+		url:      ldapURL,
+		insecure: ldapConfig.Insecure,
+		bindDN:   ldapConfig.BindDN,
+	}
+
+	var caData string
+
+	if name := ldapConfig.BindPassword.Name; len(name) > 0 {
+		secret, err := c.secretLister.Secrets("openshift-config").Get(name)
+		if err != nil {
+			return nil, "", err
+		}
+		config.bindPassword = string(secret.Data[configv1.BindPasswordKey])
+	}
+
+	if name := ldapConfig.CA.Name; len(name) > 0 {
+		cm, err := c.configMapLister.ConfigMaps("openshift-config").Get(name)
+		if err != nil {
+			return nil, "", err
+		}
+		caData = cm.Data[corev1.ServiceAccountRootCAKey]
+		config.rootCAs = x509.NewCertPool()
+		if !config.rootCAs.AppendCertsFromPEM([]byte(caData)) {
+			return nil, "", fmt.Errorf("no certificates found in configMap openshift-config/%s", name)
+		}
+	}
+
+	hash := sha256.New()
+	for _, field := range []string{ldapConfig.URL, fmt.Sprint(ldapConfig.Insecure), config.bindDN, config.bindPassword, caData} {
+		// the lengths keep the fields apart
+		fmt.Fprintf(hash, "%d:%s", len(field), field)
+	}
+	return config, hex.EncodeToString(hash.Sum(nil)), nil
+}
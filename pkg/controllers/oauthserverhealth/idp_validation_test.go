@@ -0,0 +1,240 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// fakeLDAPBinder accepts only the given password and records the binds
+type fakeLDAPBinder struct {
+	password string
+	binds    []string
+}
+
+func (b *fakeLDAPBinder) bind(_ context.Context, config *ldapBindConfig) error {
+	b.binds = append(b.binds, fmt.Sprintf("%s %s", config.url, config.bindDN))
+	if config.bindPassword != b.password {
+		return fmt.Errorf("bind as %q failed: result code 49", config.bindDN)
+	}
+	return nil
+}
+
+func TestIdentityProviderValidation(t *testing.T) {
+	htpasswd := configv1.IdentityProvider{
+		Name: "htpasswd",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type:     configv1.IdentityProviderTypeHTPasswd,
+			HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: "htpasswd"}},
+		},
+	}
+	ldap := configv1.IdentityProvider{
+		Name: "ldap",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type: configv1.IdentityProviderTypeLDAP,
+			LDAP: &configv1.LDAPIdentityProvider{
+				URL:          "ldap://ldap.example.com/ou=users,dc=example,dc=com?uid",
+				BindDN:       "cn=oauth,dc=example,dc=com",
+				BindPassword: configv1.SecretNameReference{Name: "ldap-bind"},
+				Insecure:     true,
+			},
+		},
+	}
+	missingSecret := configv1.IdentityProvider{
+		Name: "missing",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type:     configv1.IdentityProviderTypeHTPasswd,
+			HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: "missing"}},
+		},
+	}
+	incomplete := configv1.IdentityProvider{
+		Name: "github",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type:   configv1.IdentityProviderTypeGitHub,
+			GitHub: &configv1.GitHubIdentityProvider{ClientSecret: configv1.SecretNameReference{Name: "github"}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		idps         []configv1.IdentityProvider
+		bindPassword string
+		wantStatus   operatorv1.ConditionStatus
+		wantMessage  string
+		wantBinds    int
+	}{
+		{
+			name:         "valid identity providers",
+			idps:         []configv1.IdentityProvider{htpasswd, ldap},
+			bindPassword: "secret",
+			wantStatus:   operatorv1.ConditionFalse,
+			wantBinds:    1,
+		},
+		{
+			name:         "invalid identity providers",
+			idps:         []configv1.IdentityProvider{htpasswd, missingSecret, incomplete, ldap},
+			bindPassword: "rotated",
+			wantStatus:   operatorv1.ConditionTrue,
+			wantMessage: `identity provider "missing": error validating secret openshift-config/missing: secret "missing" not found` + "\n" +
+				`identity provider "github": missing required fields: github.clientID` + "\n" +
+				`identity provider "ldap": bind as "cn=oauth,dc=example,dc=com" failed: result code 49`,
+			wantBinds: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oauthIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := oauthIndexer.Add(&configv1.OAuth{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.OAuthSpec{IdentityProviders: tt.idps},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, secret := range []*corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "htpasswd", Namespace: "openshift-config"},
+					Data:       map[string][]byte{configv1.HTPasswdDataKey: []byte("user:hash")},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "openshift-config"},
+					Data:       map[string][]byte{configv1.BindPasswordKey: []byte("secret")},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "openshift-config"},
+					Data:       map[string][]byte{configv1.ClientSecretKey: []byte("secret")},
+				},
+			} {
+				if err := secretIndexer.Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil)
+			binder := &fakeLDAPBinder{password: tt.bindPassword}
+			c := &idpValidationController{
+				operatorClient:  operatorClient,
+				oauthLister:     configv1listers.NewOAuthLister(oauthIndexer),
+				configMapLister: corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})),
+				secretLister:    corev1listers.NewSecretLister(secretIndexer),
+				ldapBinder:      binder,
+				clock:           clocktesting.NewFakePassiveClock(time.Now()),
+				ldapBindResults: map[string]*ldapBindResult{},
+			}
+			if err := c.sync(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, status, _, _ := operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, idpValidationConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", idpValidationConditionType)
+			}
+			if condition.Status != tt.wantStatus || condition.Message != tt.wantMessage {
+				t.Errorf("expected the condition %s with %q, got %s with %q", tt.wantStatus, tt.wantMessage, condition.Status, condition.Message)
+			}
+			if len(binder.binds) != tt.wantBinds {
+				t.Errorf("expected %d LDAP binds, got %v", tt.wantBinds, binder.binds)
+			}
+		})
+	}
+}
+
+func TestIdentityProviderValidationCachesLDAPBinds(t *testing.T) {
+	ldap := configv1.IdentityProvider{
+		Name: "ldap",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type: configv1.IdentityProviderTypeLDAP,
+			LDAP: &configv1.LDAPIdentityProvider{
+				URL:          "ldap://ldap.example.com/ou=users,dc=example,dc=com?uid",
+				BindDN:       "cn=oauth,dc=example,dc=com",
+				BindPassword: configv1.SecretNameReference{Name: "ldap-bind"},
+				Insecure:     true,
+			},
+		},
+	}
+	oauthIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := oauthIndexer.Add(&configv1.OAuth{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       configv1.OAuthSpec{IdentityProviders: []configv1.IdentityProvider{ldap}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	setBindPassword := func(password string) {
+		if err := secretIndexer.Update(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "openshift-config"},
+			Data:       map[string][]byte{configv1.BindPasswordKey: []byte(password)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setBindPassword("secret")
+
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil)
+	binder := &fakeLDAPBinder{password: "secret"}
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	c := &idpValidationController{
+		operatorClient:  operatorClient,
+		oauthLister:     configv1listers.NewOAuthLister(oauthIndexer),
+		configMapLister: corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})),
+		secretLister:    corev1listers.NewSecretLister(secretIndexer),
+		ldapBinder:      binder,
+		clock:           fakeClock,
+		ldapBindResults: map[string]*ldapBindResult{},
+	}
+
+	sync := func(wantBinds int, wantStatus operatorv1.ConditionStatus) {
+		t.Helper()
+		if err := c.sync(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(binder.binds) != wantBinds {
+			t.Errorf("expected %d LDAP binds, got %v", wantBinds, binder.binds)
+		}
+		_, status, _, _ := operatorClient.GetOperatorState()
+		if condition := v1helpers.FindOperatorCondition(status.Conditions, idpValidationConditionType); condition == nil || condition.Status != wantStatus {
+			t.Errorf("expected the %s condition %s, got %v", idpValidationConditionType, wantStatus, condition)
+		}
+	}
+
+	sync(1, operatorv1.ConditionFalse)
+	// the successful bind is not repeated for the same config
+	fakeClock.SetTime(fakeClock.Now().Add(24 * time.Hour))
+	sync(1, operatorv1.ConditionFalse)
+
+	// a new password is bound with right away
+	setBindPassword("rotated")
+	sync(2, operatorv1.ConditionTrue)
+	// the failure is reported without binding again during the backoff
+	fakeClock.SetTime(fakeClock.Now().Add(ldapBindBackoff - time.Second))
+	sync(2, operatorv1.ConditionTrue)
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	sync(3, operatorv1.ConditionTrue)
+	// the backoff doubles with every failure
+	fakeClock.SetTime(fakeClock.Now().Add(ldapBindBackoff))
+	sync(3, operatorv1.ConditionTrue)
+	fakeClock.SetTime(fakeClock.Now().Add(ldapBindBackoff))
+	sync(4, operatorv1.ConditionTrue)
+
+	// the server accepting the password is noticed once the backoff passes
+	binder.password = "rotated"
+	fakeClock.SetTime(fakeClock.Now().Add(4 * ldapBindBackoff))
+	sync(5, operatorv1.ConditionFalse)
+	sync(5, operatorv1.ConditionFalse)
+}
@@ -0,0 +1,78 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapBindConfig is the connection of oauth-server to an LDAP identity
+// provider along with the credentials it binds with to search for the users
+type ldapBindConfig struct {
+	url *url.URL
+	// insecure connects without TLS to the ldap:// URLs, the ldap:// URLs
+	// use StartTLS otherwise
+	insecure bool
+	// rootCAs verify the certificate of the server, the system roots are
+	// used when nil
+	rootCAs      *x509.CertPool
+	bindDN       string
+	bindPassword string
+}
+
+// ldapBinder binds to an LDAP server with the credentials of an identity
+// provider
+type ldapBinder interface {
+	bind(ctx context.Context, config *ldapBindConfig) error
+}
+
+// networkLDAPBinder connects to the LDAP servers and performs a simple bind,
+// an anonymous one when there is no bind DN, the way oauth-server does before
+// searching for the users
+type networkLDAPBinder struct{}
+
+func (b *networkLDAPBinder) bind(ctx context.Context, config *ldapBindConfig) error {
+	if config.url.Scheme != "ldap" && config.url.Scheme != "ldaps" {
+		return fmt.Errorf("unsupported scheme %q", config.url.Scheme)
+	}
+	// the client does not take a context, the deadline bounds the dial and
+	// every request instead
+	timeout := ldap.DefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: config.url.Hostname(), RootCAs: config.rootCAs}
+
+	// only the scheme and the host, the rest of the URL is the search
+	conn, err := ldap.DialURL((&url.URL{Scheme: config.url.Scheme, Host: config.url.Host}).String(),
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+
+	if config.url.Scheme == "ldap" && !config.insecure {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+
+	if len(config.bindDN) == 0 {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(config.bindDN, config.bindPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("bind as %q failed: %w", config.bindDN, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// serveLDAPBind answers a single simple bind on the listener, accepting only
+// the given credentials. The raw response is sent as is when set.
+func serveLDAPBind(t *testing.T, listener net.Listener, bindDN, bindPassword string, rawResponse []byte) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Errorf("failed to accept the connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	request, err := ber.ReadPacket(conn)
+	if err != nil {
+		t.Errorf("failed to read the bind request: %v", err)
+		return
+	}
+	if len(request.Children) < 2 || request.Children[1].Tag != ldap.ApplicationBindRequest || len(request.Children[1].Children) != 3 {
+		t.Errorf("expected a simple bind request, got %v", request)
+		return
+	}
+	messageID := request.Children[0].Value.(int64)
+	bindRequest := request.Children[1].Children
+
+	if len(rawResponse) > 0 {
+		if _, err := conn.Write(rawResponse); err != nil {
+			t.Errorf("failed to write the bind response: %v", err)
+		}
+		return
+	}
+
+	resultCode, diagnosticMessage := ldap.LDAPResultSuccess, ""
+	if bindRequest[0].Value.(int64) != 3 || bindRequest[1].Value.(string) != bindDN || bindRequest[2].Data.String() != bindPassword {
+		resultCode, diagnosticMessage = ldap.LDAPResultInvalidCredentials, "invalid credentials"
+	}
+	response := ber.NewSequence("LDAP Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	bindResponse := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationBindResponse, nil, "Bind Response")
+	bindResponse.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "ResultCode"))
+	bindResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "MatchedDN"))
+	bindResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, diagnosticMessage, "DiagnosticMessage"))
+	response.AppendChild(bindResponse)
+	if _, err := conn.Write(response.Bytes()); err != nil {
+		t.Errorf("failed to write the bind response: %v", err)
+	}
+}
+
+func TestNetworkLDAPBinder(t *testing.T) {
+	tests := []struct {
+		name         string
+		bindDN       string
+		bindPassword string
+		rawResponse  []byte
+		wantErr      string
+	}{
+		{
+			name:         "valid credentials",
+			bindDN:       "cn=oauth,dc=example,dc=com",
+			bindPassword: "secret",
+		},
+		{
+			name:         "invalid credentials",
+			bindDN:       "cn=oauth,dc=example,dc=com",
+			bindPassword: "wrong",
+			wantErr:      `bind as "cn=oauth,dc=example,dc=com" failed: LDAP Result Code 49 "Invalid Credentials": invalid credentials`,
+		},
+		{
+			// Active Directory encodes the lengths in the long form
+			name:         "long form lengths",
+			bindDN:       "cn=oauth,dc=example,dc=com",
+			bindPassword: "secret",
+			rawResponse:  []byte("\x30\x84\x00\x00\x00\x10\x02\x01\x01\x61\x84\x00\x00\x00\x07\x0a\x01\x00\x04\x00\x04\x00"),
+		},
+		{
+			name:         "long form lengths of a failed bind",
+			bindDN:       "cn=oauth,dc=example,dc=com",
+			bindPassword: "wrong",
+			rawResponse:  []byte("\x30\x84\x00\x00\x00\x17\x02\x01\x01\x61\x84\x00\x00\x00\x0e\x0a\x01\x31\x04\x00\x04\x84\x00\x00\x00\x03bad"),
+			wantErr:      `bind as "cn=oauth,dc=example,dc=com" failed: LDAP Result Code 49 "Invalid Credentials": bad`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				serveLDAPBind(t, listener, "cn=oauth,dc=example,dc=com", "secret", tt.rawResponse)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = (&networkLDAPBinder{}).bind(ctx, &ldapBindConfig{
+				url:          &url.URL{Scheme: "ldap", Host: listener.Addr().String(), Path: "/ou=users,dc=example,dc=com"},
+				insecure:     true,
+				bindDN:       tt.bindDN,
+				bindPassword: tt.bindPassword,
+			})
+			<-done
+
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(tt.wantErr) > 0 && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected the error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
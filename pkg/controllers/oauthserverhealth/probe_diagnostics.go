@@ -0,0 +1,275 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/common"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/configobservation"
+)
+
+const (
+	probeDiagnosticsConditionType = "OAuthServerProbeDiagnostics"
+
+	// defaultProbeFailureThreshold is how long an oauth-server pod has to be
+	// failing its readiness probe before its diagnostics get recorded
+	defaultProbeFailureThreshold = 5 * time.Minute
+
+	// the diagnostics are kept in a condition message, these bound its size
+	maxDiagnosticsSize      = 4096
+	maxEventsPerPod         = 3
+	maxDiagnosticsEventSize = 256
+)
+
+// probeDiagnosticsConfig is read from
+// spec.unsupportedConfigOverrides.oauthServer.probeDiagnostics
+type probeDiagnosticsConfig struct {
+	// Disabled stops the diagnostics from being recorded
+	Disabled bool `json:"disabled,omitempty"`
+	// FailureThreshold is a duration string that overrides the default
+	// probe failure threshold
+	FailureThreshold string `json:"failureThreshold,omitempty"`
+}
+
+// probeDiagnostics is a snapshot of the state of the oauth-server pods that
+// keep failing their readiness probes
+type probeDiagnostics struct {
+	Pods []podDiagnostics `json:"pods"`
+	// Truncated is set when some of the pods were left out to bound the size
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type podDiagnostics struct {
+	Name                  string          `json:"name"`
+	Node                  string          `json:"node,omitempty"`
+	Phase                 corev1.PodPhase `json:"phase"`
+	NotReadySince         string          `json:"notReadySince"`
+	RestartCount          int32           `json:"restartCount"`
+	LastTerminationReason string          `json:"lastTerminationReason,omitempty"`
+	// Events are the most recent probe failure events of the pod
+	Events []string `json:"events,omitempty"`
+}
+
+type probeDiagnosticsController struct {
+	operatorClient v1helpers.OperatorClient
+	podLister      corev1listers.PodLister
+	events         corev1client.EventsGetter
+	clock          clock.PassiveClock
+}
+
+// NewProbeDiagnosticsController returns a controller that records the status
+// and the recent probe failure events of the oauth-server pods that have been
+// failing their readiness probes for a while in the OAuthServerProbeDiagnostics
+// condition of the operator, the condition gets cleared once they recover
+func NewProbeDiagnosticsController(
+	operatorClient v1helpers.OperatorClient,
+	kubeInformersForTargetNamespace informers.SharedInformerFactory,
+	eventsGetter corev1client.EventsGetter,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &probeDiagnosticsController{
+		operatorClient: operatorClient,
+		podLister:      kubeInformersForTargetNamespace.Core().V1().Pods().Lister(),
+		events:         eventsGetter,
+		clock:          clock.RealClock{},
+	}
+
+	return factory.New().
+		WithInformers(
+			operatorClient.Informer(),
+			kubeInformersForTargetNamespace.Core().V1().Pods().Informer(),
+		).
+		WithSync(c.sync).
+		ResyncEvery(wait.Jitter(time.Minute, 1.0)).
+		ToController("OAuthServerProbeDiagnosticsController", recorder.WithComponentSuffix("oauth-server-probe-diagnostics-controller"))
+}
+
+func (c *probeDiagnosticsController) sync(ctx context.Context, _ factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+
+	config, err := getProbeDiagnosticsConfig(spec.UnsupportedConfigOverrides.Raw)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   probeDiagnosticsConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if !config.Disabled {
+		threshold := defaultProbeFailureThreshold
+		if len(config.FailureThreshold) > 0 {
+			threshold, _ = time.ParseDuration(config.FailureThreshold)
+		}
+
+		diagnostics, err := c.collectDiagnostics(ctx, threshold)
+		if err != nil {
+			return err
+		}
+		if len(diagnostics.Pods) > 0 {
+			message, err := diagnostics.bounded()
+			if err != nil {
+				return err
+			}
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = "SustainedProbeFailures"
+			condition.Message = message
+		}
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
+
+func getProbeDiagnosticsConfig(unsupportedConfigOverrides []byte) (*probeDiagnosticsConfig, error) {
+	unsupportedConfig, err := common.UnstructuredConfigFrom(unsupportedConfigOverrides, configobservation.OAuthServerConfigPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the unsupportedConfigOverrides prefix %q: %w", configobservation.OAuthServerConfigPrefix, err)
+	}
+
+	config := struct {
+		ProbeDiagnostics probeDiagnosticsConfig `json:"probeDiagnostics"`
+	}{}
+	if len(unsupportedConfig) > 0 {
+		if err := json.Unmarshal(unsupportedConfig, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the probe diagnostics config: %w", err)
+		}
+	}
+
+	if threshold := config.ProbeDiagnostics.FailureThreshold; len(threshold) > 0 {
+		if duration, err := time.ParseDuration(threshold); err != nil || duration <= 0 {
+			return nil, fmt.Errorf("probeDiagnostics.failureThreshold must be a positive duration, got %q", threshold)
+		}
+	}
+
+	return &config.ProbeDiagnostics, nil
+}
+
+// collectDiagnostics returns the diagnostics of the running oauth-server pods
+// that have not been ready for longer than the threshold
+func (c *probeDiagnosticsController) collectDiagnostics(ctx context.Context, threshold time.Duration) (*probeDiagnostics, error) {
+	pods, err := c.podLister.Pods("openshift-authentication").List(labels.SelectorFromSet(labels.Set{"app": "oauth-openshift"}))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	diagnostics := &probeDiagnostics{}
+	for _, pod := range pods {
+		notReadySince, failing := sustainedProbeFailureSince(pod, c.clock.Now(), threshold)
+		if !failing {
+			continue
+		}
+
+		podDiag := podDiagnostics{
+			Name:          pod.Name,
+			Node:          pod.Spec.NodeName,
+			Phase:         pod.Status.Phase,
+			NotReadySince: notReadySince.UTC().Format(time.RFC3339),
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.Name != "oauth-openshift" {
+				continue
+			}
+			podDiag.RestartCount = containerStatus.RestartCount
+			if terminated := containerStatus.LastTerminationState.Terminated; terminated != nil {
+				podDiag.LastTerminationReason = terminated.Reason
+			}
+		}
+
+		podDiag.Events, err = c.probeFailureEvents(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+
+		diagnostics.Pods = append(diagnostics.Pods, podDiag)
+	}
+
+	return diagnostics, nil
+}
+
+// sustainedProbeFailureSince tells whether the pod runs but has not been
+// ready for longer than the threshold, along with the time it became unready
+func sustainedProbeFailureSince(pod *corev1.Pod, now time.Time, threshold time.Duration) (time.Time, bool) {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return time.Time{}, false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodReady {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue || condition.LastTransitionTime.IsZero() {
+			return time.Time{}, false
+		}
+		return condition.LastTransitionTime.Time, now.Sub(condition.LastTransitionTime.Time) >= threshold
+	}
+	return time.Time{}, false
+}
+
+// probeFailureEvents returns the most recent probe failure events of the pod
+func (c *probeDiagnosticsController) probeFailureEvents(ctx context.Context, pod *corev1.Pod) ([]string, error) {
+	eventList, err := c.events.Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", pod.Name).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unhealthy []corev1.Event
+	for _, event := range eventList.Items {
+		if event.Reason == "Unhealthy" && event.InvolvedObject.Name == pod.Name {
+			unhealthy = append(unhealthy, event)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].LastTimestamp.After(unhealthy[j].LastTimestamp.Time)
+	})
+
+	var messages []string
+	for i := 0; i < len(unhealthy) && i < maxEventsPerPod; i++ {
+		message := fmt.Sprintf("%s (x%d): %s", unhealthy[i].LastTimestamp.UTC().Format(time.RFC3339), unhealthy[i].Count, unhealthy[i].Message)
+		if len(message) > maxDiagnosticsEventSize {
+			message = message[:maxDiagnosticsEventSize-3] + "..."
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// bounded returns the JSON representation of the diagnostics, leaving out the
+// pods that do not fit within maxDiagnosticsSize
+func (d *probeDiagnostics) bounded() (string, error) {
+	for {
+		snapshot, err := json.Marshal(d)
+		if err != nil {
+			return "", err
+		}
+		if len(snapshot) <= maxDiagnosticsSize || len(d.Pods) <= 1 {
+			return string(snapshot), nil
+		}
+		d.Pods = d.Pods[:len(d.Pods)-1]
+		d.Truncated = true
+	}
+}
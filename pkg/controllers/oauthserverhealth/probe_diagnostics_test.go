@@ -0,0 +1,200 @@
+package oauthserverhealth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestProbeDiagnostics(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testPod := func(name string, ready bool, notReadyFor time.Duration) *corev1.Pod {
+		readyStatus := corev1.ConditionFalse
+		if ready {
+			readyStatus = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-authentication", Labels: map[string]string{"app": "oauth-openshift"}},
+			Spec:       corev1.PodSpec{NodeName: "master-0"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: readyStatus, LastTransitionTime: metav1.NewTime(now.Add(-notReadyFor))},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:                 "oauth-openshift",
+						RestartCount:         2,
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error"}},
+					},
+				},
+			},
+		}
+	}
+	probeEvent := func(podName, message string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: podName + "-" + age.String(), Namespace: "openshift-authentication"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: podName, Namespace: "openshift-authentication"},
+			Reason:         "Unhealthy",
+			Message:        message,
+			Count:          10,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		overrides  string
+		pods       []*corev1.Pod
+		events     []runtime.Object
+		wantStatus operatorv1.ConditionStatus
+		wantPods   []string
+		wantEvents int
+	}{
+		{
+			name: "sustained probe failure",
+			pods: []*corev1.Pod{testPod("oauth-openshift-a", false, 10*time.Minute), testPod("oauth-openshift-b", true, time.Hour)},
+			events: []runtime.Object{
+				probeEvent("oauth-openshift-a", "Readiness probe failed: HTTP probe failed with statuscode: 500", time.Minute),
+				probeEvent("oauth-openshift-a", "Readiness probe failed: context deadline exceeded", 2*time.Minute),
+				probeEvent("oauth-openshift-a", "Readiness probe failed: connection refused", 3*time.Minute),
+				probeEvent("oauth-openshift-a", "Readiness probe failed: connection refused", 4*time.Minute),
+				probeEvent("oauth-openshift-b", "Readiness probe failed: connection refused", 50*time.Minute),
+			},
+			wantStatus: operatorv1.ConditionTrue,
+			wantPods:   []string{"oauth-openshift-a"},
+			wantEvents: maxEventsPerPod,
+		},
+		{
+			name:       "probe failure below the threshold",
+			pods:       []*corev1.Pod{testPod("oauth-openshift-a", false, time.Minute)},
+			wantStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:       "configured threshold",
+			overrides:  `{"oauthServer":{"probeDiagnostics":{"failureThreshold":"30s"}}}`,
+			pods:       []*corev1.Pod{testPod("oauth-openshift-a", false, time.Minute)},
+			wantStatus: operatorv1.ConditionTrue,
+			wantPods:   []string{"oauth-openshift-a"},
+		},
+		{
+			name:       "recovered",
+			pods:       []*corev1.Pod{testPod("oauth-openshift-a", true, time.Minute)},
+			wantStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:       "disabled",
+			overrides:  `{"oauthServer":{"probeDiagnostics":{"disabled":true}}}`,
+			pods:       []*corev1.Pod{testPod("oauth-openshift-a", false, time.Hour)},
+			wantStatus: operatorv1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range tt.pods {
+				if err := indexer.Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spec := &operatorv1.OperatorSpec{}
+			if len(tt.overrides) > 0 {
+				spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tt.overrides)}
+			}
+			// start from a previously recorded snapshot to see it cleared
+			operatorClient := v1helpers.NewFakeOperatorClient(spec, &operatorv1.OperatorStatus{
+				Conditions: []operatorv1.OperatorCondition{
+					{Type: probeDiagnosticsConditionType, Status: operatorv1.ConditionTrue, Reason: "SustainedProbeFailures", Message: `{"pods":[{"name":"oauth-openshift-old"}]}`},
+				},
+			}, nil)
+
+			c := &probeDiagnosticsController{
+				operatorClient: operatorClient,
+				podLister:      corev1listers.NewPodLister(indexer),
+				events:         fake.NewSimpleClientset(tt.events...).CoreV1(),
+				clock:          clocktesting.NewFakePassiveClock(now),
+			}
+			if err := c.sync(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, status, _, _ := operatorClient.GetOperatorState()
+			condition := v1helpers.FindOperatorCondition(status.Conditions, probeDiagnosticsConditionType)
+			if condition == nil {
+				t.Fatalf("expected the %s condition", probeDiagnosticsConditionType)
+			}
+			if condition.Status != tt.wantStatus {
+				t.Fatalf("expected the condition status %s, got %s: %s", tt.wantStatus, condition.Status, condition.Message)
+			}
+			if tt.wantStatus == operatorv1.ConditionFalse {
+				if len(condition.Message) > 0 {
+					t.Errorf("expected the diagnostics to be cleared, got %s", condition.Message)
+				}
+				return
+			}
+
+			if len(condition.Message) > maxDiagnosticsSize {
+				t.Errorf("expected the diagnostics to be at most %d bytes, got %d", maxDiagnosticsSize, len(condition.Message))
+			}
+			diagnostics := &probeDiagnostics{}
+			if err := json.Unmarshal([]byte(condition.Message), diagnostics); err != nil {
+				t.Fatalf("unable to decode the diagnostics: %v", err)
+			}
+			if len(diagnostics.Pods) != len(tt.wantPods) {
+				t.Fatalf("expected the diagnostics of %v, got %s", tt.wantPods, condition.Message)
+			}
+			for i, pod := range diagnostics.Pods {
+				if pod.Name != tt.wantPods[i] {
+					t.Errorf("expected the diagnostics of %s, got %s", tt.wantPods[i], pod.Name)
+				}
+				if pod.RestartCount != 2 || pod.LastTerminationReason != "Error" || pod.Node != "master-0" {
+					t.Errorf("expected the container status in the diagnostics, got %#v", pod)
+				}
+				if len(pod.Events) != tt.wantEvents {
+					t.Errorf("expected %d events, got %v", tt.wantEvents, pod.Events)
+				}
+				if tt.wantEvents > 0 && !strings.Contains(pod.Events[0], "statuscode: 500") {
+					t.Errorf("expected the most recent event first, got %v", pod.Events)
+				}
+			}
+		})
+	}
+}
+
+func TestProbeDiagnosticsBounded(t *testing.T) {
+	diagnostics := &probeDiagnostics{}
+	for i := 0; i < 50; i++ {
+		diagnostics.Pods = append(diagnostics.Pods, podDiagnostics{
+			Name:   "oauth-openshift-" + strings.Repeat("x", 40),
+			Events: []string{strings.Repeat("e", maxDiagnosticsEventSize)},
+		})
+	}
+
+	snapshot, err := diagnostics.bounded()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshot) > maxDiagnosticsSize {
+		t.Errorf("expected the snapshot to be at most %d bytes, got %d", maxDiagnosticsSize, len(snapshot))
+	}
+	if !diagnostics.Truncated || len(diagnostics.Pods) == 0 || len(diagnostics.Pods) == 50 {
+		t.Errorf("expected some of the pods to be left out, got %d pods, truncated: %v", len(diagnostics.Pods), diagnostics.Truncated)
+	}
+}
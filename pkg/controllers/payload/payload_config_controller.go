@@ -30,6 +30,7 @@ import (
 	routev1lister "github.com/openshift/client-go/route/listers/route/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -103,7 +104,29 @@ func (c *payloadConfigController) getAuthConfig(ctx context.Context) (*operatorv
 	return operatorConfig, nil
 }
 
-func (c *payloadConfigController) getSessionSecret(ctx context.Context, recorder events.Recorder) []operatorv1.OperatorCondition {
+func (c *payloadConfigController) getSessionSecret(ctx context.Context, syncContext factory.SyncContext, operatorConfig *operatorv1.Authentication) []operatorv1.OperatorCondition {
+	var rotation *sessionSecretRotationConfig
+	if operatorConfig != nil {
+		unsupportedConfig, err := common.UnstructuredConfigFrom(operatorConfig.Spec.UnsupportedConfigOverrides.Raw, configobservation.OAuthServerConfigPrefix)
+		if err == nil {
+			var options *cliConfigOptions
+			options, err = getCLIConfigOptions(unsupportedConfig)
+			if err == nil {
+				rotation = options.SessionSecretRotation
+			}
+		}
+		if err != nil {
+			return []operatorv1.OperatorCondition{
+				{
+					Type:    "OAuthSessionSecretDegraded",
+					Status:  operatorv1.ConditionTrue,
+					Reason:  "InvalidRotationConfig",
+					Message: fmt.Sprintf("Unable to get the rotation configuration of the session secret %q: %v", sessionSecretName, err),
+				},
+			}
+		}
+	}
+
 	secret, err := c.secrets.Secrets("openshift-authentication").Get(ctx, "v4-0-config-system-session", metav1.GetOptions{})
 	if err != nil || !isValidSessionSecret(secret) {
 		klog.V(4).Infof("Failed to get session secret %q: %v (generating new random)", "v4-0-config-system-session", err)
@@ -119,7 +142,25 @@ func (c *payloadConfigController) getSessionSecret(ctx context.Context, recorder
 			}
 		}
 	}
-	if _, _, err := resourceapply.ApplySecret(ctx, c.secrets, recorder, secret); err != nil {
+
+	// the rotated secret changes the resource versions the oauth-server
+	// deployment is hashed from, which rolls it out
+	secret, requeue, err := nextSessionSecret(secret, rotation, time.Now())
+	if err != nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    "OAuthSessionSecretDegraded",
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "RotationFailed",
+				Message: fmt.Sprintf("Failed to rotate the session secret %q: %v", sessionSecretName, err),
+			},
+		}
+	}
+	if requeue > 0 {
+		syncContext.Queue().AddAfter(syncContext.QueueKey(), requeue)
+	}
+
+	if _, _, err := resourceapply.ApplySecret(ctx, c.secrets, syncContext.Recorder(), secret); err != nil {
 		return []operatorv1.OperatorCondition{
 			{
 				Type:    "OAuthSessionSecretDegraded",
@@ -133,8 +174,20 @@ func (c *payloadConfigController) getSessionSecret(ctx context.Context, recorder
 }
 
 func (c *payloadConfigController) sync(ctx context.Context, syncContext factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(spec.ManagementState) {
+		return nil
+	}
+
 	foundConditions := []operatorv1.OperatorCondition{}
-	foundConditions = append(foundConditions, c.getSessionSecret(ctx, syncContext.Recorder())...)
+
+	operatorConfig, operatorConfigConditions := c.getAuthConfig(ctx)
+	foundConditions = append(foundConditions, operatorConfigConditions...)
+
+	foundConditions = append(foundConditions, c.getSessionSecret(ctx, syncContext, operatorConfig)...)
 
 	route, routeConditions := common.GetOAuthServerRoute(c.routeLister, "OAuthConfigRoute")
 	foundConditions = append(foundConditions, routeConditions...)
@@ -142,9 +195,6 @@ func (c *payloadConfigController) sync(ctx context.Context, syncContext factory.
 	service, serviceConditions := common.GetOAuthServerService(c.serviceLister, "OAuthConfigService")
 	foundConditions = append(foundConditions, serviceConditions...)
 
-	operatorConfig, operatorConfigConditions := c.getAuthConfig(ctx)
-	foundConditions = append(foundConditions, operatorConfigConditions...)
-
 	// we need route and service to be not nil
 	if len(foundConditions) == 0 {
 		oauthConfigConditions := c.handleOAuthConfig(ctx, operatorConfig, route, service, syncContext.Recorder())
@@ -193,7 +243,7 @@ func (c *payloadConfigController) handleOAuthConfig(ctx context.Context, operato
 			},
 			SessionConfig: &osinv1.SessionConfig{
 				SessionSecretsFile:   "/var/config/system/secrets/v4-0-config-system-session/v4-0-config-system-session",
-				SessionMaxAgeSeconds: 5 * 60, // 5 minutes
+				SessionMaxAgeSeconds: sessionMaxAgeSeconds,
 				SessionName:          "ssn",
 			},
 		},
@@ -247,7 +297,36 @@ func (c *payloadConfigController) handleOAuthConfig(ctx context.Context, operato
 		}
 	}
 
-	expectedCLIConfig := getCliConfigMap(completeConfigBytes)
+	cliConfigOptions, err := getCLIConfigOptions(unsupportedConfig)
+	if err != nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    "OAuthConfigDegraded",
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "GetOAuthServerUnsupportedConfigFailed",
+				Message: fmt.Sprintf("Unable to get oauth-server configuration options: %v", err),
+			},
+		}
+	}
+
+	cliConfigData := map[string]string{
+		cliConfigKey: string(completeConfigBytes),
+	}
+	if cliConfigOptions.SplitConfig {
+		cliConfigData, err = splitCLIConfig(completeConfigBytes)
+		if err != nil {
+			return []operatorv1.OperatorCondition{
+				{
+					Type:    "OAuthConfigDegraded",
+					Status:  operatorv1.ConditionTrue,
+					Reason:  "SplitConfigFailed",
+					Message: fmt.Sprintf("Failed to split the CLI config: %v", err),
+				},
+			}
+		}
+	}
+
+	expectedCLIConfig := getCliConfigMap(cliConfigData)
 
 	_, _, err = resourceapply.ApplyConfigMap(ctx, c.configMaps, recorder, expectedCLIConfig)
 	if err != nil {
@@ -264,10 +343,10 @@ func (c *payloadConfigController) handleOAuthConfig(ctx context.Context, operato
 	return nil
 }
 
-func getCliConfigMap(completeConfigBytes []byte) *corev1.ConfigMap {
+func getCliConfigMap(data map[string]string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "v4-0-config-system-cliconfig",
+			Name:      cliConfigKey,
 			Namespace: "openshift-authentication",
 			Labels: map[string]string{
 				"app": "oauth-openshift",
@@ -275,9 +354,7 @@ func getCliConfigMap(completeConfigBytes []byte) *corev1.ConfigMap {
 			Annotations:     map[string]string{},
 			OwnerReferences: nil, // TODO
 		},
-		Data: map[string]string{
-			"v4-0-config-system-cliconfig": string(completeConfigBytes),
-		},
+		Data: data,
 	}
 }
 
@@ -344,22 +421,12 @@ func randomSessionSecret() (*corev1.Secret, error) {
 
 }
 func newSessionSecretsJSON() ([]byte, error) {
-	const (
-		sha256KeyLenBytes = sha256.BlockSize // max key size with HMAC SHA256
-		aes256KeyLenBytes = 32               // max key size with AES (AES-256)
-	)
-
 	secrets := &osinv1.SessionSecrets{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "SessionSecrets",
 			APIVersion: "operatorv1client",
 		},
-		Secrets: []osinv1.SessionSecret{
-			{
-				Authentication: randomString(sha256KeyLenBytes), // 64 chars
-				Encryption:     randomString(aes256KeyLenBytes), // 32 chars
-			},
-		},
+		Secrets: []osinv1.SessionSecret{newSessionSecret()},
 	}
 	secretsBytes, err := json.Marshal(secrets)
 	if err != nil {
@@ -369,6 +436,18 @@ func newSessionSecretsJSON() ([]byte, error) {
 	return secretsBytes, nil
 }
 
+func newSessionSecret() osinv1.SessionSecret {
+	const (
+		sha256KeyLenBytes = sha256.BlockSize // max key size with HMAC SHA256
+		aes256KeyLenBytes = 32               // max key size with AES (AES-256)
+	)
+
+	return osinv1.SessionSecret{
+		Authentication: randomString(sha256KeyLenBytes), // 64 chars
+		Encryption:     randomString(aes256KeyLenBytes), // 32 chars
+	}
+}
+
 // needs to be in lib-go
 func randomBytes(size int) []byte {
 	b := make([]byte, size)
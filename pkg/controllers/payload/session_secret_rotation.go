@@ -0,0 +1,141 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	osinv1 "github.com/openshift/api/osin/v1"
+)
+
+const (
+	sessionSecretName = "v4-0-config-system-session"
+
+	// sessionMaxAgeSeconds is how long the sessions of oauth-server are valid
+	sessionMaxAgeSeconds = 5 * 60
+
+	// sessionSecretRotatedAtAnnotation records when the session secret was
+	// last rotated, the rotation interval and the grace period start then
+	sessionSecretRotatedAtAnnotation = "operator.openshift.io/session-secret-rotated-at"
+	// sessionSecretRotateAnnotation requests an immediate rotation of the
+	// session secret, it is removed once the secret is rotated
+	sessionSecretRotateAnnotation = "operator.openshift.io/rotate-session-secret"
+)
+
+// sessionSecretRotationConfig is read from
+// spec.unsupportedConfigOverrides.oauthServer.sessionSecretRotation
+type sessionSecretRotationConfig struct {
+	// Interval is a duration string of how often the session secret gets
+	// rotated
+	Interval string `json:"interval,omitempty"`
+	// GracePeriod is a duration string of how long the previous session
+	// secret still decrypts the existing sessions after a rotation, it
+	// defaults to the max age of the sessions
+	GracePeriod string `json:"gracePeriod,omitempty"`
+}
+
+func (r *sessionSecretRotationConfig) validate() error {
+	if r == nil {
+		return nil
+	}
+	if interval, err := time.ParseDuration(r.Interval); err != nil || interval <= 0 {
+		return fmt.Errorf("sessionSecretRotation.interval must be a positive duration, got %q", r.Interval)
+	}
+	if len(r.GracePeriod) > 0 {
+		if grace, err := time.ParseDuration(r.GracePeriod); err != nil || grace < 0 {
+			return fmt.Errorf("sessionSecretRotation.gracePeriod must be a non-negative duration, got %q", r.GracePeriod)
+		}
+	}
+	return nil
+}
+
+// interval returns the rotation interval, zero when the secret only gets
+// rotated on demand. The config is expected to be validated.
+func (r *sessionSecretRotationConfig) interval() time.Duration {
+	if r == nil {
+		return 0
+	}
+	interval, _ := time.ParseDuration(r.Interval)
+	return interval
+}
+
+// gracePeriod returns the grace period of the previous session secret, the
+// config is expected to be validated
+func (r *sessionSecretRotationConfig) gracePeriod() time.Duration {
+	if r == nil || len(r.GracePeriod) == 0 {
+		return sessionMaxAgeSeconds * time.Second
+	}
+	grace, _ := time.ParseDuration(r.GracePeriod)
+	return grace
+}
+
+// nextSessionSecret returns the session secret to apply in place of the
+// existing valid one. A rotation puts a new secret in front of the current one
+// so that the new sessions get encrypted with the new secret while the
+// existing ones can still be decrypted until the grace period passes and the
+// previous secret is dropped. The previous secret of an on-demand rotation is
+// dropped the same way without any rotation config. The time to the next
+// change of the secret is returned along, zero when none is scheduled.
+func nextSessionSecret(existing *corev1.Secret, rotation *sessionSecretRotationConfig, now time.Time) (*corev1.Secret, time.Duration, error) {
+	sessionSecrets := &osinv1.SessionSecrets{}
+	if err := json.Unmarshal(existing.Data[sessionSecretName], sessionSecrets); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal the session secret: %w", err)
+	}
+
+	_, rotationRequested := existing.Annotations[sessionSecretRotateAnnotation]
+	if rotation == nil && !rotationRequested && len(sessionSecrets.Secrets) == 1 {
+		return existing, 0, nil
+	}
+
+	rotatedAt, err := time.Parse(time.RFC3339, existing.Annotations[sessionSecretRotatedAtAnnotation])
+	if err != nil {
+		// the secret predates the rotation, its interval starts now
+		rotatedAt = now
+	}
+
+	interval, grace := rotation.interval(), rotation.gracePeriod()
+	switch {
+	case rotationRequested || len(sessionSecrets.Secrets) == 0 || (interval > 0 && !now.Before(rotatedAt.Add(interval))):
+		secrets := []osinv1.SessionSecret{newSessionSecret()}
+		if len(sessionSecrets.Secrets) > 0 {
+			secrets = append(secrets, sessionSecrets.Secrets[0])
+		}
+		sessionSecrets.Secrets = secrets
+		rotatedAt = now
+	case len(sessionSecrets.Secrets) > 1 && !now.Before(rotatedAt.Add(grace)):
+		sessionSecrets.Secrets = sessionSecrets.Secrets[:1]
+	}
+	if grace == 0 {
+		sessionSecrets.Secrets = sessionSecrets.Secrets[:1]
+	}
+
+	sessionSecretsBytes, err := json.Marshal(sessionSecrets)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error marshalling the session secret: %v", err) // should never happen
+	}
+
+	next := existing.DeepCopy()
+	if next.Annotations == nil {
+		next.Annotations = map[string]string{}
+	}
+	next.Annotations[sessionSecretRotatedAtAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+	if rotationRequested {
+		// the trailing dash removes the annotation when the secret is applied
+		delete(next.Annotations, sessionSecretRotateAnnotation)
+		next.Annotations[sessionSecretRotateAnnotation+"-"] = ""
+	}
+	next.Data = map[string][]byte{sessionSecretName: sessionSecretsBytes}
+
+	var requeue time.Duration
+	if len(sessionSecrets.Secrets) > 1 {
+		requeue = rotatedAt.Add(grace).Sub(now)
+	}
+	if interval > 0 {
+		if untilRotation := rotatedAt.Add(interval).Sub(now); requeue <= 0 || untilRotation < requeue {
+			requeue = untilRotation
+		}
+	}
+	return next, requeue, nil
+}
@@ -0,0 +1,217 @@
+package payload
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	osinv1 "github.com/openshift/api/osin/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func testSessionSecret(t *testing.T, rotatedAt string, annotations map[string]string, secrets ...osinv1.SessionSecret) *corev1.Secret {
+	t.Helper()
+	data, err := json.Marshal(&osinv1.SessionSecrets{Secrets: secrets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: sessionSecretName, Namespace: "openshift-authentication", Annotations: map[string]string{}},
+		Data:       map[string][]byte{sessionSecretName: data},
+	}
+	if len(rotatedAt) > 0 {
+		secret.Annotations[sessionSecretRotatedAtAnnotation] = rotatedAt
+	}
+	for key, value := range annotations {
+		secret.Annotations[key] = value
+	}
+	return secret
+}
+
+func sessionSecretsOf(t *testing.T, secret *corev1.Secret) []osinv1.SessionSecret {
+	t.Helper()
+	sessionSecrets := &osinv1.SessionSecrets{}
+	if err := json.Unmarshal(secret.Data[sessionSecretName], sessionSecrets); err != nil {
+		t.Fatal(err)
+	}
+	return sessionSecrets.Secrets
+}
+
+func TestNextSessionSecret(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	current := newSessionSecret()
+	previous := newSessionSecret()
+	daily := &sessionSecretRotationConfig{Interval: "24h", GracePeriod: "10m"}
+
+	tests := []struct {
+		name          string
+		existing      *corev1.Secret
+		rotation      *sessionSecretRotationConfig
+		wantRotated   bool
+		wantSecrets   []osinv1.SessionSecret
+		wantRotatedAt string
+		wantRequeue   time.Duration
+	}{
+		{
+			name:        "no rotation by default",
+			existing:    testSessionSecret(t, "", nil, current),
+			wantSecrets: []osinv1.SessionSecret{current},
+		},
+		{
+			name:          "rotation interval starts for a secret that predates it",
+			existing:      testSessionSecret(t, "", nil, current),
+			rotation:      daily,
+			wantSecrets:   []osinv1.SessionSecret{current},
+			wantRotatedAt: "2026-01-01T10:00:00Z",
+			wantRequeue:   24 * time.Hour,
+		},
+		{
+			name:          "within the rotation interval",
+			existing:      testSessionSecret(t, "2026-01-01T04:00:00Z", nil, current),
+			rotation:      daily,
+			wantSecrets:   []osinv1.SessionSecret{current},
+			wantRotatedAt: "2026-01-01T04:00:00Z",
+			wantRequeue:   18 * time.Hour,
+		},
+		{
+			name:          "rotation interval passed",
+			existing:      testSessionSecret(t, "2025-12-31T10:00:00Z", nil, current),
+			rotation:      daily,
+			wantRotated:   true,
+			wantRotatedAt: "2026-01-01T10:00:00Z",
+			wantRequeue:   10 * time.Minute,
+		},
+		{
+			name:          "previous secret within the grace period",
+			existing:      testSessionSecret(t, "2026-01-01T09:55:00Z", nil, current, previous),
+			rotation:      daily,
+			wantSecrets:   []osinv1.SessionSecret{current, previous},
+			wantRotatedAt: "2026-01-01T09:55:00Z",
+			wantRequeue:   5 * time.Minute,
+		},
+		{
+			name:          "previous secret dropped after the grace period",
+			existing:      testSessionSecret(t, "2026-01-01T09:50:00Z", nil, current, previous),
+			rotation:      daily,
+			wantSecrets:   []osinv1.SessionSecret{current},
+			wantRotatedAt: "2026-01-01T09:50:00Z",
+			wantRequeue:   23*time.Hour + 50*time.Minute,
+		},
+		{
+			name:          "previous secret of a rotation on demand within the grace period",
+			existing:      testSessionSecret(t, "2026-01-01T09:58:00Z", nil, current, previous),
+			wantSecrets:   []osinv1.SessionSecret{current, previous},
+			wantRotatedAt: "2026-01-01T09:58:00Z",
+			wantRequeue:   3 * time.Minute,
+		},
+		{
+			name:          "previous secret of a rotation on demand dropped after the grace period",
+			existing:      testSessionSecret(t, "2026-01-01T09:50:00Z", nil, current, previous),
+			wantSecrets:   []osinv1.SessionSecret{current},
+			wantRotatedAt: "2026-01-01T09:50:00Z",
+		},
+		{
+			name:          "rotation on demand",
+			existing:      testSessionSecret(t, "", map[string]string{sessionSecretRotateAnnotation: ""}, current),
+			wantRotated:   true,
+			wantRotatedAt: "2026-01-01T10:00:00Z",
+			wantRequeue:   sessionMaxAgeSeconds * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, requeue, err := nextSessionSecret(tt.existing, tt.rotation, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			secrets := sessionSecretsOf(t, next)
+			if tt.wantRotated {
+				// the new secret encrypts the new sessions, the current one
+				// still decrypts the existing ones
+				if len(secrets) != 2 || secrets[0] == current || secrets[1] != current {
+					t.Errorf("expected a new secret in front of the current one, got %v", secrets)
+				}
+			} else if !equalSessionSecrets(tt.wantSecrets, secrets) {
+				t.Errorf("expected the secrets %v, got %v", tt.wantSecrets, secrets)
+			}
+			if rotatedAt := next.Annotations[sessionSecretRotatedAtAnnotation]; rotatedAt != tt.wantRotatedAt {
+				t.Errorf("expected the rotation time %q, got %q", tt.wantRotatedAt, rotatedAt)
+			}
+			if requeue != tt.wantRequeue {
+				t.Errorf("expected the next change in %v, got %v", tt.wantRequeue, requeue)
+			}
+		})
+	}
+
+	if err := (&sessionSecretRotationConfig{Interval: "0s"}).validate(); err == nil {
+		t.Errorf("expected a zero rotation interval to be rejected")
+	}
+}
+
+func equalSessionSecrets(a, b []osinv1.SessionSecret) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetSessionSecretRotationOnDemand(t *testing.T) {
+	current := newSessionSecret()
+	kubeClient := fake.NewSimpleClientset(testSessionSecret(t, "", map[string]string{sessionSecretRotateAnnotation: "now"}, current))
+	c := &payloadConfigController{secrets: kubeClient.CoreV1()}
+
+	operatorConfig := &operatorv1.Authentication{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	if conditions := c.getSessionSecret(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")), operatorConfig); len(conditions) > 0 {
+		t.Fatalf("unexpected conditions: %v", conditions)
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets("openshift-authentication").Get(context.Background(), sessionSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets := sessionSecretsOf(t, secret); len(secrets) != 2 || secrets[1] != current {
+		t.Errorf("expected the secret to be rotated with the current one kept, got %v", secrets)
+	}
+	if _, requested := secret.Annotations[sessionSecretRotateAnnotation]; requested {
+		t.Errorf("expected the rotation request to be removed, got the annotations %v", secret.Annotations)
+	}
+	for key := range secret.Annotations {
+		if key == sessionSecretRotateAnnotation+"-" {
+			t.Errorf("expected the removal marker not to be stored, got the annotations %v", secret.Annotations)
+		}
+	}
+	if !isValidSessionSecret(secret) {
+		t.Errorf("expected the rotated secret to be valid")
+	}
+
+	// the previous secret is dropped once the grace period passes even
+	// though no rotation is configured
+	rotated := sessionSecretsOf(t, secret)[0]
+	secret.Annotations[sessionSecretRotatedAtAnnotation] = time.Now().Add(-sessionMaxAgeSeconds*time.Second - time.Minute).UTC().Format(time.RFC3339)
+	if _, err := kubeClient.CoreV1().Secrets("openshift-authentication").Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if conditions := c.getSessionSecret(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")), operatorConfig); len(conditions) > 0 {
+		t.Fatalf("unexpected conditions: %v", conditions)
+	}
+	secret, err = kubeClient.CoreV1().Secrets("openshift-authentication").Get(context.Background(), sessionSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets := sessionSecretsOf(t, secret); len(secrets) != 1 || secrets[0] != rotated {
+		t.Errorf("expected only the rotated secret to be kept after the grace period, got %v", secrets)
+	}
+}
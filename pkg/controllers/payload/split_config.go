@@ -0,0 +1,96 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const cliConfigKey = "v4-0-config-system-cliconfig"
+
+// configSection is a part of the oauthConfig that gets rendered into its own file
+// when the config is split
+type configSection struct {
+	fileName string
+	field    string
+}
+
+// splitConfigSections are the oauthConfig fields that get their own file, the
+// file names are prefixed so that the server reads them in a stable order
+// after the base config
+var splitConfigSections = []configSection{
+	{fileName: "10-identity-providers.json", field: "identityProviders"},
+	{fileName: "20-templates.json", field: "templates"},
+	{fileName: "30-token-config.json", field: "tokenConfig"},
+}
+
+const splitConfigBaseFileName = "00-base.json"
+
+// cliConfigOptions are the operator-side options of the CLI config that are read
+// from the oauthServer prefix of the unsupportedConfigOverrides
+type cliConfigOptions struct {
+	// SplitConfig renders the identity providers, templates and the token
+	// config into separate files of the CLI config configmap
+	SplitConfig bool `json:"splitConfig,omitempty"`
+
+	// SessionSecretRotation rotates the session secret periodically, it can
+	// be rotated on demand regardless by annotating the secret
+	SessionSecretRotation *sessionSecretRotationConfig `json:"sessionSecretRotation,omitempty"`
+}
+
+func getCLIConfigOptions(unsupportedConfig []byte) (*cliConfigOptions, error) {
+	options := &cliConfigOptions{}
+	if len(unsupportedConfig) == 0 {
+		return options, nil
+	}
+	if err := json.Unmarshal(unsupportedConfig, options); err != nil {
+		return nil, err
+	}
+	if err := options.SessionSecretRotation.validate(); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// splitCLIConfig splits the complete config into files that each hold a part
+// of the OsinServerConfig. The base file carries everything that does not
+// belong to any of the sections. Every file is a valid partial config with
+// the apiVersion and kind of the complete one.
+func splitCLIConfig(completeConfigBytes []byte) (map[string]string, error) {
+	base := map[string]interface{}{}
+	if err := json.Unmarshal(completeConfigBytes, &base); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the config: %w", err)
+	}
+
+	oauthConfig, _ := base["oauthConfig"].(map[string]interface{})
+
+	files := map[string]string{}
+	for _, section := range splitConfigSections {
+		value, ok := oauthConfig[section.field]
+		if !ok {
+			continue
+		}
+		delete(oauthConfig, section.field)
+
+		sectionConfig := map[string]interface{}{
+			"apiVersion": base["apiVersion"],
+			"kind":       base["kind"],
+			"oauthConfig": map[string]interface{}{
+				section.field: value,
+			},
+		}
+		// encoding/json sorts the map keys so the files are stable across syncs
+		sectionBytes, err := json.Marshal(sectionConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the %s config: %w", section.field, err)
+		}
+		files[section.fileName] = string(sectionBytes)
+	}
+
+	baseBytes, err := json.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the base config: %w", err)
+	}
+	files[splitConfigBaseFileName] = string(baseBytes)
+
+	return files, nil
+}
@@ -0,0 +1,48 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitCLIConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantFiles map[string]string
+	}{
+		{
+			name:   "all sections",
+			config: `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","servingInfo":{"bindAddress":"0.0.0.0:6443"},"oauthConfig":{"masterURL":"https://oauth","identityProviders":[{"name":"htpasswd"},{"name":"github"}],"templates":{"login":"/login.html"},"tokenConfig":{"accessTokenMaxAgeSeconds":86400}}}`,
+			wantFiles: map[string]string{
+				"00-base.json":               `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"masterURL":"https://oauth"},"servingInfo":{"bindAddress":"0.0.0.0:6443"}}`,
+				"10-identity-providers.json": `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"identityProviders":[{"name":"htpasswd"},{"name":"github"}]}}`,
+				"20-templates.json":          `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"templates":{"login":"/login.html"}}}`,
+				"30-token-config.json":       `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"tokenConfig":{"accessTokenMaxAgeSeconds":86400}}}`,
+			},
+		},
+		{
+			name:   "missing sections are not rendered",
+			config: `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"tokenConfig":{"accessTokenMaxAgeSeconds":86400}}}`,
+			wantFiles: map[string]string{
+				"00-base.json":         `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{}}`,
+				"30-token-config.json": `{"apiVersion":"osin.config.openshift.io/v1","kind":"OsinServerConfig","oauthConfig":{"tokenConfig":{"accessTokenMaxAgeSeconds":86400}}}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the files must be the same on every sync so that the deployment does not roll needlessly
+			for i := 0; i < 3; i++ {
+				files, err := splitCLIConfig([]byte(tt.config))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !cmp.Equal(tt.wantFiles, files) {
+					t.Errorf("unexpected files: %s", cmp.Diff(tt.wantFiles, files))
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	corev1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routeinformer "github.com/openshift/client-go/route/informers/externalversions/route/v1"
 	v1 "github.com/openshift/client-go/route/listers/route/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -25,6 +26,7 @@ import (
 
 // proxyConfigChecker reports bad proxy configurations.
 type proxyConfigChecker struct {
+	operatorClient  v1helpers.OperatorClient
 	routeLister     v1.RouteLister
 	configMapLister corev1lister.ConfigMapLister
 	routeName       string
@@ -41,6 +43,7 @@ func NewProxyConfigChecker(
 	recorder events.Recorder,
 	operatorClient v1helpers.OperatorClient) factory.Controller {
 	p := proxyConfigChecker{
+		operatorClient:  operatorClient,
 		routeLister:     routeInformer.Lister(),
 		configMapLister: configMapInformers.ConfigMapLister(),
 		routeName:       routeName,
@@ -68,6 +71,15 @@ func NewProxyConfigChecker(
 
 // sync attempts to connect to route using configured proxy settings and reports any error.
 func (p *proxyConfigChecker) sync(ctx context.Context, _ factory.SyncContext) error {
+	spec, _, _, err := p.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if spec.ManagementState == operatorv1.Removed {
+		// there is no oauth-server to check in the Removed state
+		return nil
+	}
+
 	proxyConfig := httpproxy.FromEnvironment()
 	if !isProxyConfigured(proxyConfig) {
 		// If proxy is not configured, then it is a no-op.
@@ -96,6 +96,10 @@ func (c *wellKnownReadyController) sync(ctx context.Context, controllerContext f
 	if err != nil {
 		return err
 	}
+	if operatorSpec.ManagementState == operatorv1.Removed {
+		// there is no oauth-server to check in the Removed state
+		return nil
+	}
 
 	authConfig, err := c.authLister.Get("cluster")
 	if err != nil {
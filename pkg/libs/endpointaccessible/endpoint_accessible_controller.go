@@ -68,6 +68,15 @@ func humanizeError(err error) error {
 }
 
 func (c *endpointAccessibleController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if spec.ManagementState == operatorv1.Removed {
+		// there is no oauth-server to check in the Removed state
+		return nil
+	}
+
 	endpoints, err := c.endpointListFn()
 	if err != nil {
 		if apierrors.IsNotFound(err) {
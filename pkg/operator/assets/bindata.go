@@ -662,15 +662,6 @@ spec:
       nodeSelector:
         node-role.kubernetes.io/master: ''
       priorityClassName: system-cluster-critical
-      affinity:
-        podAntiAffinity:
-          preferredDuringSchedulingIgnoredDuringExecution:
-            - weight: 100
-              podAffinityTerm:
-                labelSelector:
-                  matchLabels:
-                    app: oauth-openshift
-                topologyKey: kubernetes.io/hostname
       tolerations:
         - key: node-role.kubernetes.io/master
           operator: Exists
@@ -0,0 +1,19 @@
+package datasync
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var idpSyncFailures = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Name:           "openshift_authentication_operator_idp_sync_failures_total",
+		Help:           "Counts the configmaps and secrets of the identity providers that failed the validation and were not synced to the oauth-server namespace.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"type"},
+)
+
+func init() {
+	legacyregistry.MustRegister(idpSyncFailures)
+}
@@ -18,6 +18,10 @@ type ConfigSyncData struct {
 	// data maps dest -> source
 	// dest is metadata.name for resource in our deployment's namespace
 	data map[string]sourceData
+
+	// conflicts are the volumes that were requested for different files,
+	// a volume only ever projects the file of the last request
+	conflicts []error
 }
 
 type ResourceType string
@@ -32,6 +36,15 @@ type sourceData struct {
 	MountPath string       `json:"mountPath"` // the mount path that this source is mapped to
 	Key       string       `json:"key"`
 	Type      ResourceType `json:"type"`
+
+	// DefaultMode and ItemMode are the permission bits of the files of the
+	// volume, the defaults of the volume source apply when unset
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+	ItemMode    *int32 `json:"itemMode,omitempty"`
+
+	// servingCert makes the certificate get validated as a serving one
+	// rather than as a client certificate
+	servingCert bool
 }
 
 func HandleIdPConfigSync(resourceSyncer resourcesynccontroller.ResourceSyncer, oldData, newData *ConfigSyncData) {
@@ -111,17 +124,76 @@ func (sd *ConfigSyncData) Bytes() ([]byte, error) {
 	return json.Marshal(sd.data)
 }
 
+// Len returns the number of resources to be synchronized, each of them is
+// mounted as a separate volume
+func (sd *ConfigSyncData) Len() int {
+	return len(sd.data)
+}
+
+// SyncedResource is an admin-provided resource from the openshift-config
+// namespace along with the name of its copy in the oauth-server namespace
+type SyncedResource struct {
+	Type   ResourceType
+	Source string
+	Dest   string
+	// Key is the key of the resource data that gets mounted
+	Key string
+}
+
+// Resources returns the resources to be synchronized, sorted by the names of
+// their copies
+func (sd *ConfigSyncData) Resources() []SyncedResource {
+	resources := make([]SyncedResource, 0, len(sd.data))
+	for _, dest := range sets.StringKeySet(sd.data).List() {
+		resources = append(resources, SyncedResource{
+			Type:   sd.data[dest].Type,
+			Source: sd.data[dest].Name,
+			Dest:   dest,
+			Key:    sd.data[dest].Key,
+		})
+	}
+	return resources
+}
+
+// SetModes sets the permission bits of the files of the volume of the given
+// resource, it returns false when the resource is not synchronized
+func (sd *ConfigSyncData) SetModes(dest string, defaultMode, itemMode *int32) bool {
+	data, ok := sd.data[dest]
+	if !ok {
+		return false
+	}
+	data.DefaultMode = defaultMode
+	data.ItemMode = itemMode
+	sd.data[dest] = data
+	return true
+}
+
 // Validate checks that the data to be synchronized is all present, has the required
 // fields, and performs additional validation of certificates and keys
 func (sd *ConfigSyncData) Validate(cmLister corelistersv1.ConfigMapLister, secretsLister corelistersv1.SecretLister) []error {
+	return sd.validate(cmLister, secretsLister, func(sourceData) {})
+}
+
+// ValidateIdPSync validates the data of the identity providers the way Validate
+// does right before it gets synced, the resources that fail the validation are
+// not synced and get counted in the IdP sync failures metric
+func (sd *ConfigSyncData) ValidateIdPSync(cmLister corelistersv1.ConfigMapLister, secretsLister corelistersv1.SecretLister) []error {
+	return sd.validate(cmLister, secretsLister, func(src sourceData) {
+		idpSyncFailures.WithLabelValues(string(src.Type)).Inc()
+	})
+}
+
+func (sd *ConfigSyncData) validate(cmLister corelistersv1.ConfigMapLister, secretsLister corelistersv1.SecretLister, invalid func(sourceData)) []error {
 	errs := []error{}
 	for _, src := range sd.data {
 		if src.Type == SecretType {
 			if secretErrs := validateSecret(secretsLister, src); len(secretErrs) > 0 {
 				errs = append(errs, fmt.Errorf("error validating secret openshift-config/%s: %w", src.Name, errors.NewAggregate(secretErrs)))
+				invalid(src)
 			}
 		} else if cmErrs := validateConfigMap(cmLister, src); len(cmErrs) > 0 {
 			errs = append(errs, fmt.Errorf("error validating configMap openshift-config/%s: %w", src.Name, errors.NewAggregate(cmErrs)))
+			invalid(src)
 		}
 	}
 	return errs
@@ -136,7 +208,7 @@ func (sd *ConfigSyncData) AddIDPSecret(index int, secretRef configv1.SecretNameR
 	}
 
 	dest, data := newSourceDataIDP(index, SecretType, secretRef.Name, field, key)
-	sd.data[dest] = data
+	sd.add(dest, data)
 
 	return path.Join(data.MountPath, key)
 }
@@ -150,14 +222,91 @@ func (sd *ConfigSyncData) AddIDPConfigMap(index int, configMapRef configv1.Confi
 	}
 
 	dest, data := newSourceDataIDP(index, ConfigMapType, configMapRef.Name, field, key)
-	sd.data[dest] = data
+	sd.add(dest, data)
+
+	return path.Join(data.MountPath, key)
+}
+
+// AddUserSecret initializes a sourceData object with proper data for an
+// admin-provided Secret that is not specific to any IdP and adds it among
+// the other secrets stored here
+// Returns the path for the Secret
+func (sd *ConfigSyncData) AddUserSecret(secretRef configv1.SecretNameReference, field, key string) string {
+	if len(secretRef.Name) == 0 {
+		return ""
+	}
+
+	dest, data := newSourceDataUser(SecretType, secretRef.Name, field, key)
+	sd.add(dest, data)
 
 	return path.Join(data.MountPath, key)
 }
 
+// AddUserConfigMap initializes a sourceData object with proper data for an
+// admin-provided ConfigMap that is not specific to any IdP and adds it among
+// the other configmaps stored here
+// Returns the path for the ConfigMap
+func (sd *ConfigSyncData) AddUserConfigMap(configMapRef configv1.ConfigMapNameReference, field, key string) string {
+	if len(configMapRef.Name) == 0 {
+		return ""
+	}
+
+	dest, data := newSourceDataUser(ConfigMapType, configMapRef.Name, field, key)
+	sd.add(dest, data)
+
+	return path.Join(data.MountPath, key)
+}
+
+// AddUserServingCertificate adds both the certificate and the key of an
+// admin-provided serving certificate Secret among the other secrets stored here
+// Returns the paths for the certificate and the key
+func (sd *ConfigSyncData) AddUserServingCertificate(secretRef configv1.SecretNameReference, field string) (string, string) {
+	if len(secretRef.Name) == 0 {
+		return "", ""
+	}
+
+	certDest, certData := newSourceDataUser(SecretType, secretRef.Name, field+"-cert", corev1.TLSCertKey)
+	certData.servingCert = true
+	sd.add(certDest, certData)
+
+	keyDest, keyData := newSourceDataUser(SecretType, secretRef.Name, field+"-key", corev1.TLSPrivateKeyKey)
+	sd.add(keyDest, keyData)
+
+	return path.Join(certData.MountPath, corev1.TLSCertKey), path.Join(keyData.MountPath, corev1.TLSPrivateKeyKey)
+}
+
+// add stores the source of the dest volume, a dest that is already used for
+// another file gets recorded as a conflict as the earlier file would no longer
+// be mounted at the path that was returned for it
+func (sd *ConfigSyncData) add(dest string, data sourceData) {
+	if existing, ok := sd.data[dest]; ok && (existing.Type != data.Type || existing.Name != data.Name || existing.Key != data.Key || existing.MountPath != data.MountPath) {
+		sd.conflicts = append(sd.conflicts, fmt.Errorf("volume %q projects %s %q key %q to %q, it cannot also project %s %q key %q to %q",
+			dest, existing.Type, existing.Name, existing.Key, path.Join(existing.MountPath, existing.Key), data.Type, data.Name, data.Key, path.Join(data.MountPath, data.Key)))
+	}
+	sd.data[dest] = data
+}
+
+// newSourceDataUser returns a name which is unique amongst the user resources
+// that are not bound to an IdP, and sourceData which describes the volumes and
+// mount volumes to mount the CM/Secret to
+func newSourceDataUser(resourceType ResourceType, resourceName, field, key string) (string, sourceData) {
+	dest := getUserName(field)
+
+	return dest, sourceData{
+		Name:      resourceName,
+		MountPath: getUserPath(string(resourceType), dest),
+		Key:       key,
+		Type:      resourceType,
+	}
+}
+
 // ToVolumesAndMounts converts the synchronization data to Volumes and VoulumeMounts
 // so that these can be added to a container spec
 func (sd *ConfigSyncData) ToVolumesAndMounts() ([]corev1.Volume, []corev1.VolumeMount, error) {
+	if len(sd.conflicts) > 0 {
+		return nil, nil, errors.NewAggregate(sd.conflicts)
+	}
+
 	volumes := []corev1.Volume{}
 	volumeMounts := []corev1.VolumeMount{}
 
@@ -185,6 +334,7 @@ func (s sourceData) ToVolumesAndMounts(volName string) (*corev1.Volume, *corev1.
 		{
 			Key:  s.Key,
 			Path: s.Key,
+			Mode: s.ItemMode,
 		},
 	}
 
@@ -194,12 +344,14 @@ func (s sourceData) ToVolumesAndMounts(volName string) (*corev1.Volume, *corev1.
 			LocalObjectReference: corev1.LocalObjectReference{
 				Name: volName,
 			},
-			Items: items,
+			Items:       items,
+			DefaultMode: s.DefaultMode,
 		}
 	case SecretType:
 		vol.Secret = &corev1.SecretVolumeSource{
-			SecretName: volName,
-			Items:      items,
+			SecretName:  volName,
+			Items:       items,
+			DefaultMode: s.DefaultMode,
 		}
 	default:
 		return nil, nil, fmt.Errorf("unknown resource type: %s", s.Type)
@@ -212,6 +364,16 @@ func (s sourceData) ToVolumesAndMounts(volName string) (*corev1.Volume, *corev1.
 	}, nil
 }
 
+func getUserName(field string) string {
+	// user resources that are synced and not bound to an IdP have this prefix
+	return fmt.Sprintf("v4-0-config-user-%s", field)
+}
+
+func getUserPath(resource, dest string) string {
+	// root path for user data that is not bound to an IdP
+	return fmt.Sprintf("/var/config/user/%s/%s", resource, dest)
+}
+
 func getIDPName(i int, field string) string {
 	// idps that are synced have this prefix
 	return fmt.Sprintf("v4-0-config-user-idp-%d-%s", i, field)
@@ -0,0 +1,163 @@
+package datasync
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestConfigSyncData_ToVolumesAndMounts(t *testing.T) {
+	tests := []struct {
+		name            string
+		add             func(sd *ConfigSyncData)
+		wantVolumes     []string
+		wantErrContains string
+	}{
+		{
+			name: "distinct volumes",
+			add: func(sd *ConfigSyncData) {
+				sd.AddIDPSecret(0, configv1.SecretNameReference{Name: "htpasswd"}, "file-data", configv1.HTPasswdDataKey)
+				sd.AddIDPConfigMap(1, configv1.ConfigMapNameReference{Name: "ldap-ca"}, "ca", corev1.ServiceAccountRootCAKey)
+			},
+			wantVolumes: []string{"v4-0-config-user-idp-0-file-data", "v4-0-config-user-idp-1-ca"},
+		},
+		{
+			name: "same file requested twice",
+			add: func(sd *ConfigSyncData) {
+				sd.AddUserConfigMap(configv1.ConfigMapNameReference{Name: "tz"}, "time-zone-data", TimeZoneDataKey)
+				sd.AddUserConfigMap(configv1.ConfigMapNameReference{Name: "tz"}, "time-zone-data", TimeZoneDataKey)
+			},
+			wantVolumes: []string{"v4-0-config-user-time-zone-data"},
+		},
+		{
+			name: "volume requested for another key",
+			add: func(sd *ConfigSyncData) {
+				sd.AddUserSecret(configv1.SecretNameReference{Name: "kube-client"}, "kube-client", corev1.TLSCertKey)
+				sd.AddUserSecret(configv1.SecretNameReference{Name: "kube-client"}, "kube-client", corev1.TLSPrivateKeyKey)
+			},
+			wantErrContains: `volume "v4-0-config-user-kube-client" projects secret "kube-client" key "tls.crt" to "/var/config/user/secret/v4-0-config-user-kube-client/tls.crt", it cannot also project secret "kube-client" key "tls.key"`,
+		},
+		{
+			name: "volume requested for another resource",
+			add: func(sd *ConfigSyncData) {
+				sd.AddIDPConfigMap(0, configv1.ConfigMapNameReference{Name: "ca-one"}, "ca", corev1.ServiceAccountRootCAKey)
+				sd.AddIDPConfigMap(0, configv1.ConfigMapNameReference{Name: "ca-two"}, "ca", corev1.ServiceAccountRootCAKey)
+			},
+			wantErrContains: `volume "v4-0-config-user-idp-0-ca" projects configMap "ca-one" key "ca.crt"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := NewConfigSyncData()
+			tt.add(sd)
+			volumes, mounts, err := sd.ToVolumesAndMounts()
+			if len(tt.wantErrContains) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(volumes) != len(tt.wantVolumes) || len(mounts) != len(tt.wantVolumes) {
+				t.Fatalf("expected %d volumes and mounts, got %d volumes and %d mounts", len(tt.wantVolumes), len(volumes), len(mounts))
+			}
+			for i, name := range tt.wantVolumes {
+				if volumes[i].Name != name || mounts[i].Name != name {
+					t.Errorf("expected volume and mount %d to be %q, got %q and %q", i, name, volumes[i].Name, mounts[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigSyncData_SetModes(t *testing.T) {
+	defaultMode, itemMode := int32(0440), int32(0400)
+
+	sd := NewConfigSyncData()
+	sd.AddIDPSecret(0, configv1.SecretNameReference{Name: "htpasswd"}, "file-data", configv1.HTPasswdDataKey)
+	sd.AddIDPConfigMap(0, configv1.ConfigMapNameReference{Name: "ca"}, "ca", corev1.ServiceAccountRootCAKey)
+
+	if !sd.SetModes("v4-0-config-user-idp-0-file-data", &defaultMode, &itemMode) {
+		t.Fatalf("expected the modes of the secret volume to be set")
+	}
+	if !sd.SetModes("v4-0-config-user-idp-0-ca", nil, &itemMode) {
+		t.Fatalf("expected the modes of the configmap volume to be set")
+	}
+	if sd.SetModes("v4-0-config-user-idp-1-ca", &defaultMode, nil) {
+		t.Errorf("expected no modes to be set for a resource that is not synchronized")
+	}
+
+	volumes, _, err := sd.ToVolumesAndMounts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(volumes))
+	}
+
+	configMapVolume, secretVolume := volumes[0], volumes[1]
+	if configMapVolume.ConfigMap.DefaultMode != nil {
+		t.Errorf("expected no default mode of the configmap volume, got %#o", *configMapVolume.ConfigMap.DefaultMode)
+	}
+	if mode := configMapVolume.ConfigMap.Items[0].Mode; mode == nil || *mode != itemMode {
+		t.Errorf("expected the configmap item mode %#o, got %v", itemMode, mode)
+	}
+	if mode := secretVolume.Secret.DefaultMode; mode == nil || *mode != defaultMode {
+		t.Errorf("expected the secret default mode %#o, got %v", defaultMode, mode)
+	}
+	if mode := secretVolume.Secret.Items[0].Mode; mode == nil || *mode != itemMode {
+		t.Errorf("expected the secret item mode %#o, got %v", itemMode, mode)
+	}
+}
+
+func TestConfigSyncData_ValidateIdPSync(t *testing.T) {
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := secretIndexer.Add(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "htpasswd", Namespace: "openshift-config"},
+		Data:       map[string][]byte{configv1.HTPasswdDataKey: []byte("user:password")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cmLister := corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	secretLister := corev1listers.NewSecretLister(secretIndexer)
+
+	sd := NewConfigSyncData()
+	sd.AddIDPSecret(0, configv1.SecretNameReference{Name: "htpasswd"}, "file-data", configv1.HTPasswdDataKey)
+	sd.AddIDPConfigMap(0, configv1.ConfigMapNameReference{Name: "missing-ca"}, "ca", corev1.ServiceAccountRootCAKey)
+
+	failures := func(resourceType ResourceType) float64 {
+		value, err := testutil.GetCounterMetricValue(idpSyncFailures.WithLabelValues(string(resourceType)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	configMapFailures, secretFailures := failures(ConfigMapType), failures(SecretType)
+
+	if errs := sd.Validate(cmLister, secretLister); len(errs) != 1 {
+		t.Fatalf("expected the missing configmap to be reported, got %v", errs)
+	}
+	if got := failures(ConfigMapType); got != configMapFailures {
+		t.Errorf("expected Validate not to count the IdP sync failures, got %v more", got-configMapFailures)
+	}
+
+	if errs := sd.ValidateIdPSync(cmLister, secretLister); len(errs) != 1 {
+		t.Fatalf("expected the missing configmap to be reported, got %v", errs)
+	}
+	if got := failures(ConfigMapType); got != configMapFailures+1 {
+		t.Errorf("expected one more configmap sync failure, got %v more", got-configMapFailures)
+	}
+	if got := failures(SecretType); got != secretFailures {
+		t.Errorf("expected no more secret sync failures, got %v more", got-secretFailures)
+	}
+}
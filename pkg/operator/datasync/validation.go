@@ -21,10 +21,71 @@ var validators = map[string]func(data []byte) []error{
 	configv1.ClientSecretKey:       noValidation,
 	configv1.HTPasswdDataKey:       noValidation,
 	configv1.BindPasswordKey:       noValidation,
+
+	LocaleBundleKey:     validateNotEmpty,
+	LoginPageSnippetKey: validateNotEmpty,
+
+	SessionStoreURLKey:      validateNotEmpty,
+	SessionStorePasswordKey: validateNotEmpty,
+
+	AuditSinkTokenKey: validateNotEmpty,
+
+	TimeZoneDataKey: validateNotEmpty,
+
+	IdentityProviderIconKey: validateNotEmpty,
+
+	TokenEncryptionKeyKey: validateTokenEncryptionKey,
 }
 
+// LocaleBundleKey is the key of the admin-provided configmap with the
+// translations of the login pages
+const LocaleBundleKey = "locales.json"
+
+// LoginPageSnippetKey is the key of the admin-provided configmaps with the
+// HTML snippets of the login pages
+const LoginPageSnippetKey = "snippet.html"
+
+// the keys of the admin-provided secret with the connection details of the
+// external session store
+const (
+	SessionStoreURLKey      = "url"
+	SessionStorePasswordKey = "password"
+)
+
+// AuditSinkTokenKey is the key of the admin-provided secret with the bearer
+// token for the login audit sink
+const AuditSinkTokenKey = "token"
+
+// TimeZoneDataKey is the key of the admin-provided configmap with the
+// compiled time zone oauth-server uses for its local time
+const TimeZoneDataKey = "localtime"
+
+// IdentityProviderIconKey is the key of the admin-provided configmaps with
+// the icons of the identity providers on the login page
+const IdentityProviderIconKey = "icon"
+
+// TokenEncryptionKeyKey is the key of the admin-provided secret with the
+// AES-256 key oauth-server encrypts the tokens with
+const TokenEncryptionKeyKey = "key"
+
 func noValidation(_ []byte) []error { return []error{} }
 
+func validateNotEmpty(data []byte) []error {
+	if len(data) == 0 {
+		return []error{fmt.Errorf("required data is empty")}
+	}
+	return []error{}
+}
+
+// validatorFor returns the validator for the given key, keys without a known
+// format are only checked for presence
+func validatorFor(key string) func(data []byte) []error {
+	if validator, ok := validators[key]; ok {
+		return validator
+	}
+	return noValidation
+}
+
 func validateSecret(secretsLister corelistersv1.SecretLister, src sourceData) []error {
 	s, err := secretsLister.Secrets("openshift-config").Get(src.Name)
 	if err != nil {
@@ -36,7 +97,10 @@ func validateSecret(secretsLister corelistersv1.SecretLister, src sourceData) []
 		return []error{fmt.Errorf("missing required key: %q", src.Key)}
 	}
 
-	return validators[src.Key](data)
+	if src.servingCert {
+		return ValidateServerCert(data)
+	}
+	return validatorFor(src.Key)(data)
 }
 
 func validateConfigMap(cmLister corelistersv1.ConfigMapLister, src sourceData) []error {
@@ -45,12 +109,25 @@ func validateConfigMap(cmLister corelistersv1.ConfigMapLister, src sourceData) [
 		return []error{err}
 	}
 
+	// the binary files such as the time zone data can only be stored in
+	// the binaryData of the configmap
 	data, exists := cm.Data[src.Key]
 	if !exists {
-		return []error{fmt.Errorf("missing required key: %q", src.Key)}
+		binaryData, exists := cm.BinaryData[src.Key]
+		if !exists {
+			return []error{fmt.Errorf("missing required key: %q", src.Key)}
+		}
+		return validatorFor(src.Key)(binaryData)
 	}
 
-	return validators[src.Key]([]byte(data))
+	return validatorFor(src.Key)([]byte(data))
+}
+
+func validateTokenEncryptionKey(key []byte) []error {
+	if len(key) != 32 {
+		return []error{fmt.Errorf("expected a 32 bytes long AES-256 key, got %d bytes", len(key))}
+	}
+	return []error{}
 }
 
 func validateClientCert(pem []byte) []error {
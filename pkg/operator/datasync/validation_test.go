@@ -46,6 +46,23 @@ func Test_validateSecret(t *testing.T) {
 				testSecret("somesecret", map[string][]byte{corev1.TLSPrivateKeyKey: []byte("invalid value")}),
 			},
 		},
+		{
+			name: "token encryption key of the wrong size",
+			src:  sourceData{Name: "somesecret", Key: TokenEncryptionKeyKey},
+			want: []error{
+				fmt.Errorf("expected a 32 bytes long AES-256 key, got 16 bytes"),
+			},
+			secrets: []*corev1.Secret{
+				testSecret("somesecret", map[string][]byte{TokenEncryptionKeyKey: []byte("0123456789abcdef")}),
+			},
+		},
+		{
+			name: "token encryption key",
+			src:  sourceData{Name: "somesecret", Key: TokenEncryptionKeyKey},
+			secrets: []*corev1.Secret{
+				testSecret("somesecret", map[string][]byte{TokenEncryptionKeyKey: []byte("0123456789abcdef0123456789abcdef")}),
+			},
+		},
 		{
 			name: "happy path",
 			src:  sourceData{Name: "somesecret", Key: corev1.TLSPrivateKeyKey},
@@ -183,6 +200,22 @@ AQsFAANBADfrlKof5CUkxGlX9Rifxv/mWOk8ZuTLWfMYQH2nycBHnmOxy6sR+87W
 -----END CERTIFICATE-----`}),
 			},
 		},
+		{
+			name: "empty binary data",
+			src:  sourceData{Name: "someCM", Key: TimeZoneDataKey},
+			want: []error{
+				fmt.Errorf("required data is empty"),
+			},
+			configMaps: []*corev1.ConfigMap{
+				testBinaryConfigMap("someCM", map[string][]byte{TimeZoneDataKey: {}}),
+			},
+		},
+		{
+			name:       "binary data",
+			src:        sourceData{Name: "someCM", Key: TimeZoneDataKey},
+			want:       []error{},
+			configMaps: []*corev1.ConfigMap{testBinaryConfigMap("someCM", map[string][]byte{TimeZoneDataKey: []byte("TZif2")})},
+		},
 		{
 			name: "happy path",
 			src:  sourceData{Name: "someCM", Key: corev1.ServiceAccountRootCAKey},
@@ -349,3 +382,13 @@ func testConfigMap(name string, data map[string]string) *corev1.ConfigMap {
 		Data: data,
 	}
 }
+
+func testBinaryConfigMap(name string, binaryData map[string][]byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "openshift-config",
+		},
+		BinaryData: binaryData,
+	}
+}
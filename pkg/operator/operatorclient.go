@@ -73,3 +73,37 @@ func (c OperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersio
 
 	return &ret.Status.OperatorStatus, nil
 }
+
+// managementStateOverrideClient reports the `from` management state of the
+// operator as the `to` state to the controllers using it, the operands the
+// `from` state does not apply to are handled as in the `to` state. The state
+// stored in the operator config is left intact by the spec updates of the
+// controllers.
+type managementStateOverrideClient struct {
+	v1helpers.OperatorClient
+	from, to operatorv1.ManagementState
+}
+
+var _ v1helpers.OperatorClient = &managementStateOverrideClient{}
+
+func (c *managementStateOverrideClient) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	spec, status, resourceVersion, err := c.OperatorClient.GetOperatorState()
+	if err != nil || spec.ManagementState != c.from {
+		return spec, status, resourceVersion, err
+	}
+	spec = spec.DeepCopy()
+	spec.ManagementState = c.to
+	return spec, status, resourceVersion, nil
+}
+
+func (c *managementStateOverrideClient) UpdateOperatorSpec(ctx context.Context, resourceVersion string, spec *operatorv1.OperatorSpec) (*operatorv1.OperatorSpec, string, error) {
+	current, _, _, err := c.OperatorClient.GetOperatorState()
+	if err != nil {
+		return nil, "", err
+	}
+	if current.ManagementState == c.from && spec.ManagementState == c.to {
+		spec = spec.DeepCopy()
+		spec.ManagementState = c.from
+	}
+	return c.OperatorClient.UpdateOperatorSpec(ctx, resourceVersion, spec)
+}
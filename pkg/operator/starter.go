@@ -19,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	certinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	apiregistrationclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
@@ -48,7 +49,6 @@ import (
 	"github.com/openshift/library-go/pkg/operator/encryption/controllers/migrators"
 	encryptiondeployer "github.com/openshift/library-go/pkg/operator/encryption/deployer"
 	"github.com/openshift/library-go/pkg/operator/loglevel"
-	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/managementstatecontroller"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
@@ -69,6 +69,7 @@ import (
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/metadata"
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/oauthclientscontroller"
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/oauthendpoints"
+	"github.com/openshift/cluster-authentication-operator/pkg/controllers/oauthserverhealth"
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/payload"
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/proxyconfig"
 	"github.com/openshift/cluster-authentication-operator/pkg/controllers/readiness"
@@ -145,8 +146,10 @@ func RunOperator(ctx context.Context, controllerContext *controllercmd.Controlle
 		authOperatorClient.OperatorV1(),
 	}
 
+	// the resources are synced for both the oauth-server and the
+	// oauth-apiserver, only the latter keeps running in the Removed state
 	resourceSyncer := resourcesynccontroller.NewResourceSyncController(
-		operatorClient,
+		&managementStateOverrideClient{OperatorClient: operatorClient, from: operatorv1.Removed, to: operatorv1.Managed},
 		kubeInformersForNamespaces,
 		v1helpers.CachedSecretGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
 		v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
@@ -366,8 +369,10 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 		controllerContext.EventRecorder,
 	)
 
+	// the workload controller would delete the whole namespace in the Removed
+	// state, the removal controller removes only the oauth-server
 	deploymentController := deployment.NewOAuthServerWorkloadController(
-		operatorCtx.operatorClient,
+		&managementStateOverrideClient{OperatorClient: operatorCtx.operatorClient, from: operatorv1.Removed, to: operatorv1.Unmanaged},
 		workloadcontroller.CountNodesFuncWrapper(operatorCtx.kubeInformersForNamespaces.InformersFor("").Core().V1().Nodes().Lister()),
 		workloadcontroller.EnsureAtMostOnePodPerNode,
 		operatorCtx.kubeClient,
@@ -380,6 +385,43 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 		controllerContext.EventRecorder,
 		operatorCtx.versionRecorder,
 		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-authentication"),
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-config"),
+		operatorCtx.resourceSyncController,
+	)
+
+	oauthServerRemovalController := deployment.NewOAuthServerRemovalController(
+		operatorCtx.operatorClient,
+		operatorCtx.kubeClient,
+		routeClient.RouteV1(),
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-authentication"),
+		routeInformersNamespaced,
+		controllerContext.EventRecorder,
+	)
+
+	legacyregistry.CustomMustRegister(oauthserverhealth.NewHealthCollector(
+		operatorCtx.operatorClient,
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-authentication").Apps().V1().Deployments().Lister(),
+	))
+
+	probeDiagnosticsController := oauthserverhealth.NewProbeDiagnosticsController(
+		operatorCtx.operatorClient,
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-authentication"),
+		operatorCtx.kubeClient.CoreV1(),
+		controllerContext.EventRecorder,
+	)
+
+	idpReachabilityController := oauthserverhealth.NewIdentityProviderReachabilityController(
+		operatorCtx.operatorClient,
+		operatorCtx.operatorConfigInformer.Config().V1().Proxies(),
+		controllerContext.EventRecorder,
+	)
+
+	idpValidationController := oauthserverhealth.NewIdentityProviderValidationController(
+		operatorCtx.operatorClient,
+		operatorCtx.operatorConfigInformer.Config().V1().OAuths(),
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-config").Core().V1().ConfigMaps(),
+		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-config").Core().V1().Secrets(),
+		controllerContext.EventRecorder,
 	)
 
 	workersAvailableController := ingressnodesavailable.NewIngressNodesAvailableController(
@@ -455,9 +497,8 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 		operatorCtx.resourceSyncController,
 	)
 
-	// TODO remove this controller once we support Removed
+	// reports the unknown management states
 	managementStateController := managementstatecontroller.NewOperatorManagementStateController("authentication", operatorCtx.operatorClient, controllerContext.EventRecorder)
-	management.SetOperatorNotRemovable()
 
 	trustDistributionController := trustdistribution.NewTrustDistributionController(
 		operatorCtx.kubeClient.CoreV1(),
@@ -476,6 +517,7 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 	operatorCtx.controllersToRunFunc = append(operatorCtx.controllersToRunFunc,
 		configObserver.Run,
 		deploymentController.Run,
+		oauthServerRemovalController.Run,
 		managementStateController.Run,
 		metadataController.Run,
 		oauthClientsController.Run,
@@ -491,6 +533,9 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 		proxyConfigController.Run,
 		customRouteController.Run,
 		trustDistributionController.Run,
+		probeDiagnosticsController.Run,
+		idpReachabilityController.Run,
+		idpValidationController.Run,
 		func(ctx context.Context, workers int) { staleConditions.Run(ctx, workers) },
 		func(ctx context.Context, workers int) { ingressStateController.Run(ctx, workers) },
 	)
@@ -501,6 +546,14 @@ func prepareOauthOperator(ctx context.Context, controllerContext *controllercmd.
 func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *controllercmd.ControllerContext, operatorCtx *operatorContext) error {
 	eventRecorder := controllerContext.EventRecorder.ForComponent("oauth-apiserver")
 
+	// the Removed management state only removes the oauth-server, the
+	// kube-apiserver keeps depending on the OAuth APIs of the oauth-apiserver
+	apiServerOperatorClient := &managementStateOverrideClient{
+		OperatorClient: operatorCtx.operatorClient,
+		from:           operatorv1.Removed,
+		to:             operatorv1.Managed,
+	}
+
 	// add syncing for etcd certs for oauthapi-server
 	if err := operatorCtx.resourceSyncController.SyncConfigMap(
 		resourcesynccontroller.ResourceLocation{Namespace: "openshift-oauth-apiserver", Name: "etcd-serving-ca"},
@@ -556,7 +609,7 @@ func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *contr
 	const apiServerConditionsPrefix = "APIServer"
 
 	apiServerControllers, err := apiservercontrollerset.NewAPIServerControllerSet(
-		operatorCtx.operatorClient,
+		apiServerOperatorClient,
 		eventRecorder,
 	).WithWorkloadController(
 		"OAuthAPIServerController",
@@ -632,7 +685,7 @@ func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *contr
 			Optional: true,
 		}},
 		operatorCtx.kubeInformersForNamespaces.InformersFor("openshift-oauth-apiserver"),
-		revisionclient.New(operatorCtx.operatorClient, operatorCtx.operatorClient.Client),
+		revisionclient.New(apiServerOperatorClient, operatorCtx.operatorClient.Client),
 		v1helpers.CachedConfigMapGetter(operatorCtx.kubeClient.CoreV1(), operatorCtx.kubeInformersForNamespaces),
 		v1helpers.CachedSecretGetter(operatorCtx.kubeClient.CoreV1(), operatorCtx.kubeInformersForNamespaces),
 	).WithAPIServiceController(
@@ -707,7 +760,7 @@ func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *contr
 	}
 
 	configObserver := oauthapiconfigobservercontroller.NewConfigObserverController(
-		operatorCtx.operatorClient,
+		apiServerOperatorClient,
 		operatorCtx.kubeInformersForNamespaces,
 		operatorCtx.operatorConfigInformer,
 		operatorCtx.resourceSyncController,
@@ -722,7 +775,7 @@ func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *contr
 		operatorCtx.kubeClient.CoreV1(),
 		operatorCtx.configClient.ConfigV1().Authentications(),
 		operatorCtx.operatorClient.Client,
-		operatorCtx.operatorClient,
+		apiServerOperatorClient,
 		operatorCtx.versionRecorder,
 		eventRecorder,
 	)
@@ -760,7 +813,7 @@ func prepareOauthAPIServerOperator(ctx context.Context, controllerContext *contr
 
 	webhookCertsApprover := csr.NewCSRApproverController(
 		"OpenShiftAuthenticator",
-		operatorCtx.operatorClient,
+		apiServerOperatorClient,
 		operatorCtx.kubeClient.CertificatesV1().CertificateSigningRequests(),
 		kubeInformers.Certificates().V1().CertificateSigningRequests(),
 		csr.NewLabelFilter(labelSelector),